@@ -0,0 +1,81 @@
+package activity
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tarish/xmrig"
+)
+
+func TestParseHIDIdleTime(t *testing.T) {
+	out := `  | |   "HIDIdleTime" = 12345678901
+  | |   "HIDScrollAcceleration" = 20480`
+	got, err := parseHIDIdleTime(out)
+	if err != nil {
+		t.Fatalf("parseHIDIdleTime: %v", err)
+	}
+	if want := 12345678901 * time.Nanosecond; got != want {
+		t.Errorf("parseHIDIdleTime = %v, want %v", got, want)
+	}
+
+	if _, err := parseHIDIdleTime("no idle here"); err == nil {
+		t.Error("parseHIDIdleTime accepted output without HIDIdleTime")
+	}
+}
+
+func TestTTYIdleTimeUsesMostRecentTerminal(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for name, ago := range map[string]time.Duration{"0": 10 * time.Minute, "1": 30 * time.Second} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		ts := now.Add(-ago)
+		if err := os.Chtimes(path, ts, ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idle, err := ttyIdleTime([]string{filepath.Join(dir, "[0-9]*")})
+	if err != nil {
+		t.Fatalf("ttyIdleTime: %v", err)
+	}
+	if idle < 29*time.Second || idle > time.Minute {
+		t.Errorf("ttyIdleTime = %v, want about 30s", idle)
+	}
+
+	if _, err := ttyIdleTime([]string{filepath.Join(dir, "none*")}); err == nil {
+		t.Error("ttyIdleTime succeeded with no terminals")
+	}
+}
+
+func TestStep(t *testing.T) {
+	origPause, origResume := pauseMining, resumeMining
+	defer func() { pauseMining, resumeMining = origPause, origResume }()
+
+	var calls []string
+	pauseMining = func() error { calls = append(calls, "pause"); return nil }
+	resumeMining = func() ([]xmrig.PauseReason, error) { calls = append(calls, "resume"); return nil, nil }
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resumeAfter := time.Minute
+
+	paused := false
+	paused = step(logger, paused, time.Second, resumeAfter)    // typing: pause
+	paused = step(logger, paused, time.Second, resumeAfter)    // still typing: no-op
+	paused = step(logger, paused, 30*time.Second, resumeAfter) // idle, but not long enough
+	paused = step(logger, paused, 2*time.Minute, resumeAfter)  // idle long enough: resume
+	paused = step(logger, paused, 3*time.Minute, resumeAfter)  // stays resumed
+
+	if paused {
+		t.Error("paused = true after resuming")
+	}
+	if len(calls) != 2 || calls[0] != "pause" || calls[1] != "resume" {
+		t.Errorf("calls = %v, want [pause resume]", calls)
+	}
+}
@@ -0,0 +1,15 @@
+package activity
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns a file's atime, which for a tty is its last input
+func accessTime(fi os.FileInfo) time.Time {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atim.Sec, st.Atim.Nsec)
+	}
+	return fi.ModTime()
+}
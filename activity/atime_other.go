@@ -0,0 +1,13 @@
+//go:build !linux
+
+package activity
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime falls back to mtime; tty idle time is only used on Linux
+func accessTime(fi os.FileInfo) time.Time {
+	return fi.ModTime()
+}
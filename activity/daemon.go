@@ -0,0 +1,117 @@
+package activity
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"tarish/daemon"
+	"tarish/logging"
+	"tarish/xmrig"
+)
+
+// pollInterval is how often the daemon samples idle time
+const pollInterval = 5 * time.Second
+
+// pauseMining and resumeMining are swapped out in tests. Resuming only
+// drops the activity reason; a manual or schedule pause keeps mining paused.
+var (
+	pauseMining  = func() error { return xmrig.PauseFor(xmrig.PauseActivity) }
+	resumeMining = func() ([]xmrig.PauseReason, error) { return xmrig.ResumeFor(xmrig.PauseActivity) }
+)
+
+// DefaultResumeAfter is how long the machine must be idle before mining resumes
+const DefaultResumeAfter = 2 * time.Minute
+
+// RunDaemon pauses xmrig whenever there was input within pollInterval and
+// resumes it once the machine has been idle for resumeAfter. Blocks until
+// killed. Invoked via the hidden "_activity-daemon" command.
+func RunDaemon(resumeAfter time.Duration) {
+	logger := logging.New(os.Stdout, "activity")
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+
+	logger.Info("started", "pid", os.Getpid(), "resume_after", resumeAfter)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			idle, err := IdleTime()
+			if err != nil {
+				logger.Error("cannot measure idle time, exiting", "err", err)
+				return
+			}
+			// pause.json, not a local flag: an xmrig restart clears it
+			step(logger, xmrig.IsPausedFor(xmrig.PauseActivity), idle, resumeAfter)
+		case <-sig:
+			// Don't leave the miner idle once nobody is watching for activity
+			if xmrig.IsPausedFor(xmrig.PauseActivity) {
+				if _, err := resumeMining(); err != nil {
+					logger.Warn("failed to resume on shutdown", "err", err)
+				}
+			}
+			logger.Info("received signal, shutting down")
+			return
+		}
+	}
+}
+
+// step applies one idle sample and returns whether mining is now paused
+// by the daemon. Resume only undoes the daemon's own pause.
+func step(logger *slog.Logger, paused bool, idle, resumeAfter time.Duration) bool {
+	switch {
+	case !paused && idle < pollInterval:
+		if err := pauseMining(); err != nil {
+			logger.Warn("failed to pause", "err", err)
+			return false
+		}
+		logger.Info("user active, mining paused", "idle", idle.Round(time.Second))
+		return true
+	case paused && idle >= resumeAfter:
+		held, err := resumeMining()
+		if err != nil {
+			logger.Warn("failed to resume", "err", err)
+			return true
+		}
+		if len(held) > 0 {
+			logger.Info("machine idle, mining stays paused", "idle", idle.Round(time.Second), "paused_by", held)
+		} else {
+			logger.Info("machine idle, mining resumed", "idle", idle.Round(time.Second))
+		}
+		return false
+	}
+	return paused
+}
+
+// daemonProc is the background "_activity-daemon" process
+var daemonProc = daemon.New("activity")
+
+// StartDaemon spawns the activity daemon as a background process,
+// replacing one that is already running so a new resumeAfter applies.
+func StartDaemon(resumeAfter time.Duration) error {
+	StopDaemon()
+
+	if _, err := IdleTime(); err != nil {
+		return err
+	}
+
+	_, err := daemonProc.Start(strconv.Itoa(int(resumeAfter.Seconds())))
+	return err
+}
+
+// StopDaemon sends SIGTERM to the activity daemon (if running).
+func StopDaemon() {
+	daemonProc.Stop()
+}
+
+// IsDaemonRunning reports the PID and whether the activity daemon is alive.
+func IsDaemonRunning() (int, bool) {
+	return daemonProc.IsRunning()
+}
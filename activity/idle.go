@@ -0,0 +1,97 @@
+// Package activity pauses mining while the user is at the machine. It
+// measures input idle time and drives xmrig's pause/resume API from a
+// background daemon started by 'tarish start --pause-on-activity'.
+package activity
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdleTime returns how long it has been since the last keyboard or mouse
+// input on this machine.
+func IdleTime() (time.Duration, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return idleTimeDarwin()
+	case "linux":
+		return idleTimeLinux()
+	default:
+		return 0, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+}
+
+// hidIdleRe matches the IOHIDSystem property, in nanoseconds
+var hidIdleRe = regexp.MustCompile(`"HIDIdleTime"\s*=\s*(\d+)`)
+
+// idleTimeDarwin reads HIDIdleTime from `ioreg -c IOHIDSystem`
+func idleTimeDarwin() (time.Duration, error) {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ioreg failed: %w", err)
+	}
+	return parseHIDIdleTime(string(out))
+}
+
+func parseHIDIdleTime(out string) (time.Duration, error) {
+	m := hidIdleRe.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("HIDIdleTime not found in ioreg output")
+	}
+	ns, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ns), nil
+}
+
+// idleTimeLinux asks the X server via xprintidle when a display is
+// available. Otherwise it falls back to terminal idle time, which is what
+// `w` reports: the kernel bumps a tty's atime on input. (/dev/input event
+// devices are only readable by root, so they aren't used.)
+func idleTimeLinux() (time.Duration, error) {
+	if os.Getenv("DISPLAY") != "" {
+		if out, err := exec.Command("xprintidle").Output(); err == nil {
+			ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+			if err == nil {
+				return time.Duration(ms) * time.Millisecond, nil
+			}
+		}
+	}
+	return ttyIdleTime(ttyGlobs)
+}
+
+// ttyGlobs are the terminals whose input counts as user activity
+var ttyGlobs = []string{"/dev/pts/[0-9]*", "/dev/tty[0-9]*"}
+
+// ttyIdleTime returns the time since the most recently read terminal
+func ttyIdleTime(globs []string) (time.Duration, error) {
+	var latest time.Time
+	for _, pattern := range globs {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			fi, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if at := accessTime(fi); at.After(latest) {
+				latest = at
+			}
+		}
+	}
+	if latest.IsZero() {
+		return 0, fmt.Errorf("no terminals or X display to measure idle time")
+	}
+	idle := time.Since(latest)
+	if idle < 0 {
+		idle = 0
+	}
+	return idle, nil
+}
@@ -5,19 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
-	"path/filepath"
-	"strconv"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"tarish/config"
 	"tarish/cpu"
+	"tarish/daemon"
+	"tarish/logging"
 	"tarish/xmrig"
 )
 
@@ -28,50 +27,66 @@ const (
 	heartbeatInterval   = 30 * time.Second
 	configPollInterval  = 3 * time.Second
 	httpTimeout         = 10 * time.Second
+
+	// maxConfigPollInterval caps the config-poll back-off while nothing
+	// is pending. The server's raw-config wait (40s) must outlast it.
+	maxConfigPollInterval = 30 * time.Second
 )
 
-// Guards applyConfigOverride so the heartbeat and config-poll don't race.
+// logger writes to the daemon log; RunDaemon replaces it with one that
+// honours log_level/log_format from tarish.json
+var logger = slog.New(slog.NewTextHandler(os.Stdout, nil)).With("component", "agent")
+
+// Guards applyConfigOverride/applyTarishOverride so the heartbeat and config-poll don't race.
 var configMu sync.Mutex
 
+// pollActivity tells pollConfigLoop to return to fast polling, e.g. after
+// a heartbeat report delivered an override
+var pollActivity = make(chan struct{}, 1)
+
 type ReportResponse struct {
 	OK             bool                   `json:"ok"`
 	ConfigOverride map[string]interface{} `json:"config_override,omitempty"`
+	TarishOverride map[string]interface{} `json:"tarish_override,omitempty"`
+	FetchConfig    bool                   `json:"fetch_config,omitempty"`
 }
 
 // RunDaemon runs the agent heartbeat loop. Blocks until killed.
 // Invoked via the hidden "_agent-daemon" command.
 func RunDaemon() {
+	logger = logging.New(os.Stdout, "agent")
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
 
 	serverURL := config.GetServerURL()
 	if serverURL == "" {
-		fmt.Println("[agent] no server URL configured, exiting")
+		logger.Info("no server URL configured, exiting")
 		return
 	}
 
 	cpuInfo, err := cpu.Detect()
 	if err != nil {
-		fmt.Printf("[agent] failed to detect CPU: %v\n", err)
+		logger.Error("failed to detect CPU", "err", err)
 		return
 	}
 
-	fmt.Printf("[agent] started (pid %d), reporting to %s every %v\n",
-		os.Getpid(), serverURL, heartbeatInterval)
-	fmt.Printf("[agent] CPU: %s (%s, %d cores)\n", cpuInfo.RawModel, cpuInfo.Family, cpuInfo.Cores)
+	logger.Info("started", "pid", os.Getpid(), "server", serverURL, "interval", heartbeatInterval)
+	logger.Info("detected CPU", "model", cpuInfo.RawModel, "family", cpuInfo.Family, "cores", cpuInfo.Cores)
 
 	// Initial delay to let xmrig fully start
 	select {
 	case <-time.After(5 * time.Second):
 	case <-sig:
-		fmt.Println("[agent] received signal during startup, exiting")
+		logger.Info("received signal during startup, exiting")
 		return
 	}
 
 	sendReport(cpuInfo, serverURL)
 
-	// Fast config-poll loop: checks for pending overrides every 3s so
-	// dashboard config edits are applied almost immediately.
+	// Fast config-poll loop: checks for pending overrides every 3s (backing
+	// off while idle) so dashboard config edits are applied almost
+	// immediately.
 	stopPoll := make(chan struct{})
 	go pollConfigLoop(serverURL, stopPoll)
 
@@ -82,19 +97,27 @@ func RunDaemon() {
 		select {
 		case <-ticker.C:
 			if config.GetServerURL() == "" {
-				fmt.Println("[agent] server URL removed, exiting")
+				logger.Info("server URL removed, exiting")
 				close(stopPoll)
 				return
 			}
 			sendReport(cpuInfo, config.GetServerURL())
+			if rotated, err := xmrig.RotateLogIfLarge(); err != nil {
+				logger.Warn("failed to rotate xmrig log", "err", err)
+			} else if rotated {
+				logger.Info("rotated xmrig log (size limit reached)")
+			}
 		case <-sig:
-			fmt.Println("[agent] received signal, shutting down")
+			logger.Info("received signal, shutting down")
 			close(stopPoll)
 			return
 		}
 	}
 }
 
+// daemonProc is the background "_agent-daemon" process
+var daemonProc = daemon.New("agent")
+
 // StartDaemon spawns the agent daemon as a background process.
 func StartDaemon() error {
 	serverURL := config.GetServerURL()
@@ -107,76 +130,23 @@ func StartDaemon() error {
 		return nil
 	}
 
-	exe, err := os.Executable()
+	pid, err := daemonProc.Start()
 	if err != nil {
-		return fmt.Errorf("cannot locate tarish binary: %w", err)
-	}
-	exe, _ = filepath.EvalSymlinks(exe)
-
-	logDir := daemonLogDir()
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return fmt.Errorf("cannot create log dir: %w", err)
-	}
-
-	logPath := filepath.Join(logDir, "agent-daemon.log")
-	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return fmt.Errorf("cannot open daemon log: %w", err)
-	}
-
-	cmd := exec.Command(exe, "_agent-daemon")
-	cmd.Stdout = logFile
-	cmd.Stderr = logFile
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-
-	if err := cmd.Start(); err != nil {
-		logFile.Close()
-		return fmt.Errorf("failed to start agent daemon: %w", err)
-	}
-
-	if err := saveDaemonPID(cmd.Process.Pid); err != nil {
-		cmd.Process.Kill()
-		logFile.Close()
 		return err
 	}
 
-	go func() {
-		cmd.Wait()
-		logFile.Close()
-		os.Remove(daemonPIDFile())
-	}()
-
-	fmt.Printf("Agent: reporting to %s (pid %d)\n", serverURL, cmd.Process.Pid)
+	fmt.Printf("Agent: reporting to %s (pid %d)\n", serverURL, pid)
 	return nil
 }
 
 // StopDaemon sends SIGTERM to the agent daemon (if running).
 func StopDaemon() {
-	pid, running := IsDaemonRunning()
-	if !running {
-		return
-	}
-	if p, err := os.FindProcess(pid); err == nil {
-		_ = p.Signal(syscall.SIGTERM)
-		time.Sleep(200 * time.Millisecond)
-		if isProcessAlive(pid) {
-			_ = p.Signal(syscall.SIGKILL)
-		}
-	}
-	os.Remove(daemonPIDFile())
+	daemonProc.Stop()
 }
 
 // IsDaemonRunning reports the PID and whether the agent daemon is alive.
 func IsDaemonRunning() (int, bool) {
-	data, err := os.ReadFile(daemonPIDFile())
-	if err != nil {
-		return 0, false
-	}
-	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
-	if err != nil {
-		return 0, false
-	}
-	return pid, isProcessAlive(pid)
+	return daemonProc.IsRunning()
 }
 
 func sendReport(cpuInfo *cpu.Info, serverURL string) {
@@ -184,38 +154,33 @@ func sendReport(cpuInfo *cpu.Info, serverURL string) {
 
 	body, err := json.Marshal(report)
 	if err != nil {
-		fmt.Printf("[agent] marshal error: %v\n", err)
+		logger.Error("failed to marshal report", "err", err)
 		return
 	}
 
 	client := &http.Client{Timeout: httpTimeout}
-	url := serverURL + "/api/report"
-
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	resp, err := postReport(client, serverURL+"/api/report", body)
 	if err != nil {
-		fmt.Printf("[agent] request error: %v\n", err)
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	agentKey := config.GetServerAgentKey()
-	if agentKey != "" {
-		req.Header.Set("Authorization", "Bearer "+agentKey)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("[agent] report failed: %v\n", err)
+		logger.Warn("report failed", "err", err)
+		enqueueReport(report)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		respBody, _ := io.ReadAll(resp.Body)
-		fmt.Printf("[agent] server returned %d: %s\n", resp.StatusCode, string(respBody))
+		logger.Warn("report rejected", "status", resp.StatusCode, "body", string(respBody))
+		// Keep the sample if the server is having trouble; 4xx means the
+		// report itself is bad and retrying won't help.
+		if resp.StatusCode >= 500 {
+			enqueueReport(report)
+		}
 		return
 	}
 
+	// Server is reachable again: deliver anything buffered while it wasn't.
+	flushQueue(client, serverURL)
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return
@@ -227,34 +192,53 @@ func sendReport(cpuInfo *cpu.Info, serverURL string) {
 	}
 
 	if report.Hashrate != nil {
-		fmt.Printf("[agent] report ok (hashrate: %.1f H/s)\n", report.Hashrate.Current)
+		logger.Info("report ok", "hashrate", report.Hashrate.Current)
 	} else {
-		fmt.Println("[agent] report ok (hashrate: unavailable)")
+		logger.Info("report ok", "hashrate", "unavailable")
 	}
 
-	if response.ConfigOverride != nil {
-		minerID := report.MinerID
-		if minerID == "" {
-			minerID = report.WorkerID
+	minerID := report.MinerID
+	if minerID == "" {
+		minerID = report.WorkerID
+	}
+	if response.hasWork() {
+		// someone is editing this miner; more edits are likely to follow
+		select {
+		case pollActivity <- struct{}{}:
+		default:
 		}
+	}
+	if response.ConfigOverride != nil {
 		applyConfigOverride(response.ConfigOverride, serverURL, minerID)
 	}
+	if response.TarishOverride != nil {
+		applyTarishOverride(response.TarishOverride, serverURL, minerID)
+	}
+	if response.FetchConfig {
+		sendLiveConfig(serverURL, minerID)
+	}
 }
 
-// readMinerID reads the miner ID (api.id or api.worker-id) from the runtime config.
+// hasWork reports whether the server asked the agent to do anything
+func (r *ReportResponse) hasWork() bool {
+	return r.ConfigOverride != nil || r.TarishOverride != nil || r.FetchConfig
+}
+
+// readMinerID reads the miner ID (api.id or api.worker-id) from the runtime
+// config, falling back to the same persisted ID buildReport uses.
 func readMinerID() string {
 	runtimePath := xmrig.GetRuntimeConfigPath()
 	data, err := os.ReadFile(runtimePath)
 	if err != nil {
-		return ""
+		return minerID()
 	}
 	var raw map[string]interface{}
 	if json.Unmarshal(data, &raw) != nil {
-		return ""
+		return minerID()
 	}
 	api, _ := raw["api"].(map[string]interface{})
 	if api == nil {
-		return ""
+		return minerID()
 	}
 	if id, ok := api["id"].(string); ok && id != "" {
 		return id
@@ -262,108 +246,197 @@ func readMinerID() string {
 	if wid, ok := api["worker-id"].(string); ok && wid != "" {
 		return wid
 	}
-	return ""
+	return minerID()
 }
 
 // pollConfigLoop polls the server for pending config overrides every few
 // seconds so that dashboard edits are applied almost immediately instead
-// of waiting for the next 30s heartbeat.
+// of waiting for the next 30s heartbeat. While nothing is pending the
+// interval doubles up to maxConfigPollInterval; any activity resets it.
 func pollConfigLoop(serverURL string, stop <-chan struct{}) {
 	minerID := readMinerID()
 	if minerID == "" {
-		fmt.Println("[agent] config-poll: cannot determine miner ID, skipping")
+		logger.Warn("config-poll: cannot determine miner ID, skipping")
 		return
 	}
 
 	client := &http.Client{Timeout: 5 * time.Second}
 	pendingURL := fmt.Sprintf("%s/api/miners/%s/config/pending", serverURL, minerID)
 
-	ticker := time.NewTicker(configPollInterval)
-	defer ticker.Stop()
+	interval := configPollInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
+	etag := ""
 	for {
 		select {
 		case <-stop:
 			return
-		case <-ticker.C:
-			checkPendingConfig(client, pendingURL, serverURL, minerID)
+		case <-pollActivity:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			interval = configPollInterval
+		case <-timer.C:
+			var active bool
+			etag, active = checkPendingConfig(client, pendingURL, serverURL, minerID, etag)
+			interval = nextPollInterval(interval, active)
 		}
+		timer.Reset(interval)
 	}
 }
 
-func checkPendingConfig(client *http.Client, pendingURL, serverURL, minerID string) {
+// nextPollInterval is the config-poll interval after a poll that did
+// (active) or didn't find anything pending
+func nextPollInterval(current time.Duration, active bool) time.Duration {
+	if active {
+		return configPollInterval
+	}
+	return min(current*2, maxConfigPollInterval)
+}
+
+// checkPendingConfig fetches and applies pending overrides. etag is the
+// ETag of the last idle answer; the server replies 304 while nothing has
+// changed. Returns the ETag to send next time and whether there was
+// anything to apply.
+func checkPendingConfig(client *http.Client, pendingURL, serverURL, minerID, etag string) (string, bool) {
 	req, err := http.NewRequest("GET", pendingURL, nil)
 	if err != nil {
-		return
+		return etag, false
 	}
 	if agentKey := config.GetServerAgentKey(); agentKey != "" {
 		req.Header.Set("Authorization", "Bearer "+agentKey)
 	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return
+		return etag, false
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return etag, false
+	}
 	if resp.StatusCode != 200 {
-		return
+		return "", false
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return
+		return "", false
 	}
 
 	var response ReportResponse
 	if json.Unmarshal(body, &response) != nil {
-		return
+		return "", false
+	}
+
+	// Only an idle answer is cached: one carrying work stays uncached so a
+	// failed apply is retried on the next poll
+	if !response.hasWork() {
+		return resp.Header.Get("ETag"), false
 	}
 
 	if response.ConfigOverride != nil {
 		applyConfigOverride(response.ConfigOverride, serverURL, minerID)
 	}
+	if response.TarishOverride != nil {
+		applyTarishOverride(response.TarishOverride, serverURL, minerID)
+	}
+	if response.FetchConfig {
+		sendLiveConfig(serverURL, minerID)
+	}
+	return "", true
 }
 
-func applyConfigOverride(override map[string]interface{}, serverURL, minerID string) {
-	configMu.Lock()
-	defer configMu.Unlock()
-
-	port, accessToken := xmrig.GetHTTPConfigFromRuntime()
-
-	body, err := json.Marshal(override)
+// sendLiveConfig answers the server's fetch_config request by posting
+// xmrig's current config for the dashboard's raw-config view
+func sendLiveConfig(serverURL, minerID string) {
+	live, err := xmrig.GetLiveConfig()
 	if err != nil {
-		fmt.Printf("[agent] failed to marshal config override: %v\n", err)
+		logger.Warn("cannot read live config for server", "err", err)
+		return
+	}
+	body, err := json.Marshal(redactPoolCredentials(live))
+	if err != nil {
+		logger.Error("failed to marshal live config", "err", err)
 		return
 	}
 
 	client := &http.Client{Timeout: 5 * time.Second}
-	url := fmt.Sprintf("http://127.0.0.1:%d/1/config", port)
+	reportURL := fmt.Sprintf("%s/api/miners/%s/config/report", serverURL, minerID)
 
-	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
+	req, err := http.NewRequest("POST", reportURL, bytes.NewReader(body))
 	if err != nil {
-		fmt.Printf("[agent] failed to create PUT request: %v\n", err)
+		logger.Error("failed to create live config request", "err", err)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if accessToken != "" {
-		req.Header.Set("Authorization", "Bearer "+accessToken)
+	if agentKey := config.GetServerAgentKey(); agentKey != "" {
+		req.Header.Set("Authorization", "Bearer "+agentKey)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("[agent] failed to apply config: %v\n", err)
+		logger.Warn("failed to send live config", "err", err)
 		return
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 200 || resp.StatusCode == 204 {
-		fmt.Println("[agent] applied config override from server")
-		ackConfigOverride(serverURL, minerID)
-	} else {
+	if resp.StatusCode != 200 {
 		respBody, _ := io.ReadAll(resp.Body)
-		fmt.Printf("[agent] xmrig rejected config (HTTP %d): %s\n", resp.StatusCode, string(respBody))
+		logger.Warn("live config rejected", "status", resp.StatusCode, "body", string(respBody))
+		return
 	}
+	logger.Info("sent live config to server")
+}
+
+// configRevertKey marks an override asking the agent to drop live edits and
+// go back to the selected on-disk config (models.ConfigRevertKey)
+const configRevertKey = "_tarish_revert"
+
+func applyConfigOverride(override map[string]interface{}, serverURL, minerID string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	live, liveErr := xmrig.GetLiveConfig()
+	if revert, _ := override[configRevertKey].(bool); revert {
+		if liveErr != nil {
+			logger.Error("cannot revert config: live config unavailable", "err", liveErr)
+			return
+		}
+		configPath, _, err := xmrig.GetConfigForCurrentSystem()
+		if err == nil {
+			override, err = xmrig.RevertConfig(configPath, live)
+		}
+		if err != nil {
+			logger.Error("cannot revert config", "err", err)
+			return
+		}
+		logger.Info("reverting to on-disk config", "path", configPath)
+	}
+
+	// Edits made from a reported config carry masked pool credentials
+	if !restorePoolCredentials(override, live) {
+		logger.Error("cannot apply config: it has redacted pool credentials and the live config has none to restore")
+		return
+	}
+
+	if liveErr != nil {
+		logger.Warn("cannot read live config to diff override", "err", liveErr)
+	} else {
+		logConfigDiff(live, override)
+	}
+
+	if err := xmrig.PutLiveConfig(override); err != nil {
+		logger.Warn("failed to apply config", "err", err)
+		return
+	}
+	logger.Info("applied config override from server")
+	ackConfigOverride(serverURL, minerID)
 }
 
 func ackConfigOverride(serverURL, minerID string) {
@@ -372,7 +445,7 @@ func ackConfigOverride(serverURL, minerID string) {
 
 	req, err := http.NewRequest("POST", ackURL, nil)
 	if err != nil {
-		fmt.Printf("[agent] failed to create ack request: %v\n", err)
+		logger.Error("failed to create ack request", "err", err)
 		return
 	}
 
@@ -383,49 +456,25 @@ func ackConfigOverride(serverURL, minerID string) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("[agent] failed to ack config: %v\n", err)
+		logger.Warn("failed to ack config", "err", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 200 {
-		fmt.Println("[agent] config override acknowledged")
+		logger.Info("config override acknowledged")
 	} else {
 		respBody, _ := io.ReadAll(resp.Body)
-		fmt.Printf("[agent] ack failed (HTTP %d): %s\n", resp.StatusCode, string(respBody))
-	}
-}
-
-// ---------- internal helpers ----------
-
-func daemonPIDFile() string {
-	dir, err := config.ConfigDir()
-	if err != nil {
-		return "/tmp/tarish-agent-daemon.pid"
-	}
-	return filepath.Join(dir, "agent-daemon.pid")
-}
-
-func daemonLogDir() string {
-	dir, err := config.ConfigDir()
-	if err != nil {
-		return "/tmp"
+		logger.Warn("config ack failed", "status", resp.StatusCode, "body", string(respBody))
 	}
-	return filepath.Join(dir, "log")
 }
 
-func saveDaemonPID(pid int) error {
-	path := daemonPIDFile()
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return err
-	}
-	return os.WriteFile(path, []byte(strconv.Itoa(pid)), 0644)
+// DaemonLogPath returns the agent daemon's log file
+func DaemonLogPath() string {
+	return daemonProc.LogPath()
 }
 
-func isProcessAlive(pid int) bool {
-	p, err := os.FindProcess(pid)
-	if err != nil {
-		return false
-	}
-	return p.Signal(syscall.Signal(0)) == nil
+// MinerID returns the ID this agent reports under
+func MinerID() string {
+	return readMinerID()
 }
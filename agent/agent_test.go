@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"tarish/xmrig"
+)
+
+func TestNextPollInterval(t *testing.T) {
+	interval := configPollInterval
+	var seen []time.Duration
+	for i := 0; i < 6; i++ {
+		interval = nextPollInterval(interval, false)
+		seen = append(seen, interval)
+	}
+	want := []time.Duration{6 * time.Second, 12 * time.Second, 24 * time.Second, 30 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("idle back-off = %v, want %v", seen, want)
+		}
+	}
+
+	if got := nextPollInterval(maxConfigPollInterval, true); got != configPollInterval {
+		t.Errorf("after activity = %v, want %v", got, configPollInterval)
+	}
+}
+
+func TestReadMinerIDUsesPersistedID(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// No runtime config yet: the ID 'tarish start' will use is created
+	id := readMinerID()
+	want, err := xmrig.LoadOrCreateMinerID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != want {
+		t.Errorf("readMinerID() = %q, want persisted %q", id, want)
+	}
+}
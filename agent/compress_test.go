@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostReportFallsBackToPlain(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer plainReports.Store(false)
+
+	// a server that predates compressed reports: decodes the raw body
+	var encodings []string
+	oldServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if json.NewDecoder(r.Body).Decode(new(map[string]interface{})) != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+		}
+	}))
+	defer oldServer.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := postReport(oldServer.Client(), oldServer.URL, []byte(`{"miner_id":"m1"}`))
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("postReport #%d = %v, %v; want 200", i+1, resp, err)
+		}
+		resp.Body.Close()
+	}
+	if len(encodings) != 3 || encodings[0] != "gzip" || encodings[1] != "" || encodings[2] != "" {
+		t.Errorf("encodings sent = %q, want gzip, then plain from then on", encodings)
+	}
+}
+
+func TestPostReportCompresses(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil || r.Header.Get("Content-Encoding") != "gzip" {
+			http.Error(w, "not gzip", http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(zr)
+		got = string(data)
+	}))
+	defer srv.Close()
+
+	resp, err := postReport(srv.Client(), srv.URL, []byte(`{"miner_id":"m1"}`))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("postReport = %v, %v; want 200", resp, err)
+	}
+	resp.Body.Close()
+	if got != `{"miner_id":"m1"}` || plainReports.Load() {
+		t.Errorf("server got %q (plain fallback %v), want the gzipped report", got, plainReports.Load())
+	}
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// maxLoggedChanges caps the per-key log lines for one override; a
+// dashboard edit touches a handful of keys, a wholesale replacement more
+const maxLoggedChanges = 50
+
+// secretConfigKeys are never written to the agent log
+var secretConfigKeys = map[string]bool{
+	"access-token": true,
+	"pass":         true,
+	"password":     true,
+}
+
+// configChange is one changed leaf between two xmrig configs. Path is
+// dotted with array indexes, e.g. "pools[0].url"; Old/New are absent when
+// the key was added/removed.
+type configChange struct {
+	Path     string
+	Old, New interface{}
+	HasOld   bool
+	HasNew   bool
+	Secret   bool
+}
+
+func (c configChange) String() string {
+	if c.Secret {
+		return fmt.Sprintf("%s: (changed)", c.Path)
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Path, formatConfigValue(c.Old, c.HasOld), formatConfigValue(c.New, c.HasNew))
+}
+
+func formatConfigValue(v interface{}, present bool) string {
+	if !present {
+		return "(unset)"
+	}
+	data, err := json.Marshal(redactSecrets(v))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// redactSecrets returns v with secret keys in nested objects masked, for
+// logging a whole added or removed object such as a pool
+func redactSecrets(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			if secretConfigKeys[k] {
+				out[k] = "(redacted)"
+			} else {
+				out[k] = redactSecrets(child)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = redactSecrets(child)
+		}
+		return out
+	}
+	return v
+}
+
+// diffConfig lists the leaves that differ between old and new, sorted by
+// path. Nested objects and arrays are compared element by element.
+func diffConfig(old, new map[string]interface{}) []configChange {
+	var changes []configChange
+	diffValue("", old, new, true, true, false, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffValue(path string, old, new interface{}, hasOld, hasNew, secret bool, changes *[]configChange) {
+	if hasOld && hasNew {
+		oldMap, oldIsMap := old.(map[string]interface{})
+		newMap, newIsMap := new.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			keys := make(map[string]bool)
+			for k := range oldMap {
+				keys[k] = true
+			}
+			for k := range newMap {
+				keys[k] = true
+			}
+			for k := range keys {
+				childPath := k
+				if path != "" {
+					childPath = path + "." + k
+				}
+				o, ho := oldMap[k]
+				n, hn := newMap[k]
+				diffValue(childPath, o, n, ho, hn, secret || secretConfigKeys[k], changes)
+			}
+			return
+		}
+
+		oldList, oldIsList := old.([]interface{})
+		newList, newIsList := new.([]interface{})
+		if oldIsList && newIsList {
+			for i := 0; i < len(oldList) || i < len(newList); i++ {
+				var o, n interface{}
+				ho, hn := i < len(oldList), i < len(newList)
+				if ho {
+					o = oldList[i]
+				}
+				if hn {
+					n = newList[i]
+				}
+				diffValue(fmt.Sprintf("%s[%d]", path, i), o, n, ho, hn, secret, changes)
+			}
+			return
+		}
+
+		if reflect.DeepEqual(old, new) {
+			return
+		}
+	}
+
+	*changes = append(*changes, configChange{
+		Path: path, Old: old, New: new,
+		HasOld: hasOld, HasNew: hasNew,
+		Secret: secret,
+	})
+}
+
+// logConfigDiff logs what applying override will change in the running
+// xmrig's config, so the agent log shows what a dashboard edit did
+func logConfigDiff(live, override map[string]interface{}) {
+	changes := diffConfig(live, override)
+	if len(changes) == 0 {
+		logger.Info("config override matches the live config")
+		return
+	}
+
+	logger.Info("config override changes", "count", len(changes))
+	for i, c := range changes {
+		if i == maxLoggedChanges {
+			logger.Info("config override changes truncated", "omitted", len(changes)-i)
+			break
+		}
+		logger.Info("config change", "change", c.String())
+	}
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffConfig(t *testing.T) {
+	var live, override map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"donate-level": 1,
+		"cpu": {"enabled": true, "max-threads-hint": 100},
+		"http": {"access-token": "old-token"},
+		"pools": [{"url": "a:3333", "pass": "x"}],
+		"background": false
+	}`), &live)
+	json.Unmarshal([]byte(`{
+		"donate-level": 1,
+		"cpu": {"enabled": true, "max-threads-hint": 50},
+		"http": {"access-token": "new-token"},
+		"pools": [{"url": "b:3333", "pass": "x"}, {"url": "c:3333", "pass": "secret"}],
+		"log-file": null
+	}`), &override)
+
+	var got []string
+	for _, c := range diffConfig(live, override) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"background: false -> (unset)",
+		"cpu.max-threads-hint: 100 -> 50",
+		"http.access-token: (changed)",
+		"log-file: (unset) -> null",
+		`pools[0].url: "a:3333" -> "b:3333"`,
+		`pools[1]: (unset) -> {"pass":"(redacted)","url":"c:3333"}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffConfig =\n%q\nwant\n%q", got, want)
+	}
+
+	if changes := diffConfig(live, live); len(changes) != 0 {
+		t.Errorf("diffConfig(live, live) = %v, want none", changes)
+	}
+}
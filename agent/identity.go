@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"tarish/config"
+	"tarish/xmrig"
+)
+
+// minerID returns the miner ID 'tarish start' puts in api.id, for when the
+// runtime config has none (e.g. xmrig was never started through tarish),
+// creating it if missing so the server sees the same ID once xmrig runs.
+// The derived ID is only used when the data dir can't be written.
+func minerID() string {
+	id, err := xmrig.LoadOrCreateMinerID()
+	if err != nil {
+		logger.Warn("failed to load miner ID, deriving one", "err", err)
+		return fallbackMinerID()
+	}
+	return id
+}
+
+// fallbackMinerID derives a stable ID from the hostname and the first
+// hardware address. The short hostname is used so FQDN vs. short-name
+// reports map to the same ID.
+func fallbackMinerID() string {
+	hostname, _ := os.Hostname()
+	if i := strings.IndexByte(hostname, '.'); i > 0 {
+		hostname = hostname[:i]
+	}
+	hostname = strings.ToLower(hostname)
+
+	sum := sha256.Sum256([]byte(hostname + "|" + primaryMAC()))
+	id := hex.EncodeToString(sum[:])[:12]
+	if hostname == "" {
+		return "host-" + id
+	}
+	return hostname + "-" + id
+}
+
+// primaryMAC returns the hardware address of the first non-loopback
+// interface, by name, so the choice doesn't depend on interface order.
+func primaryMAC() string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].Name < ifaces[j].Name })
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		// Always the default list: editing the VPN filter in tarish.json
+		// must not change this miner's identity
+		if xmrig.IsVPNInterface(iface.Name, config.DefaultVPNInterfacePrefixes) {
+			continue
+		}
+		return iface.HardwareAddr.String()
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"tarish/config"
+)
+
+const (
+	// maxQueuedReports caps the on-disk queue (~4h of heartbeats at 30s).
+	maxQueuedReports = 500
+	// flushBatchSize is how many queued reports go in one /api/report/batch call.
+	flushBatchSize = 100
+)
+
+// Guards the queue file; the heartbeat loop is the only writer today but
+// flushes and enqueues must never interleave.
+var queueMu sync.Mutex
+
+func queueFile() string {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "/tmp/tarish-report-queue.jsonl"
+	}
+	return filepath.Join(dir, "report-queue.jsonl")
+}
+
+// enqueueReport appends a report that could not be delivered. When the
+// queue is full the oldest entries are dropped.
+func enqueueReport(report *StatusReport) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	queued := loadQueue()
+	queued = append(queued, report)
+	if len(queued) > maxQueuedReports {
+		queued = queued[len(queued)-maxQueuedReports:]
+	}
+
+	if err := saveQueue(queued); err != nil {
+		logger.Error("failed to queue report", "err", err)
+		return
+	}
+	logger.Info("report queued", "pending", len(queued))
+}
+
+// flushQueue sends queued reports to /api/report/batch. Reports that were
+// delivered are removed; the rest, including any the server turned away
+// under its rate limit, stay queued for the next attempt.
+func flushQueue(client *http.Client, serverURL string) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	queued := loadQueue()
+	if len(queued) == 0 {
+		return
+	}
+
+	sent := 0
+	for sent < len(queued) {
+		end := sent + flushBatchSize
+		if end > len(queued) {
+			end = len(queued)
+		}
+		limited, err := postBatch(client, serverURL, queued[sent:end])
+		if err != nil {
+			logger.Warn("queue flush failed", "err", err)
+			break
+		}
+		sent = end - limited
+		if limited > 0 {
+			logger.Info("server rate-limited the flush, keeping the rest queued", "limited", limited)
+			break
+		}
+	}
+
+	if sent == 0 {
+		return
+	}
+	if err := saveQueue(queued[sent:]); err != nil {
+		logger.Error("failed to update report queue", "err", err)
+		return
+	}
+	logger.Info("flushed queued reports", "sent", sent, "pending", len(queued)-sent)
+}
+
+// postBatch sends reports and returns how many from the end the server
+// left unprocessed because of its rate limit
+func postBatch(client *http.Client, serverURL string, reports []*StatusReport) (int, error) {
+	body, err := json.Marshal(reports)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := postReport(client, serverURL+"/api/report/batch", body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Limited int `json:"limited"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result.Limited < 0 || result.Limited > len(reports) {
+		result.Limited = 0
+	}
+	return result.Limited, nil
+}
+
+// loadQueue reads the queue file, skipping lines that don't parse.
+func loadQueue() []*StatusReport {
+	f, err := os.Open(queueFile())
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var queued []*StatusReport
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var report StatusReport
+		if json.Unmarshal(scanner.Bytes(), &report) == nil {
+			queued = append(queued, &report)
+		}
+	}
+	return queued
+}
+
+// saveQueue rewrites the queue file atomically; an empty queue removes it.
+func saveQueue(queued []*StatusReport) error {
+	path := queueFile()
+	if len(queued) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, report := range queued {
+		line, err := json.Marshal(report)
+		if err != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlushQueueKeepsRateLimitedReports(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".local", "share", "tarish"), 0755)
+
+	// the server takes two of the five reports and rate-limits the rest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true, "accepted": 2, "limited": 3}`))
+	}))
+	defer server.Close()
+
+	if err := saveQueue([]*StatusReport{{MinerID: "r1"}, {MinerID: "r2"}, {MinerID: "r3"}, {MinerID: "r4"}, {MinerID: "r5"}}); err != nil {
+		t.Fatal(err)
+	}
+	flushQueue(server.Client(), server.URL)
+
+	queued := loadQueue()
+	if len(queued) != 3 || queued[0].MinerID != "r3" {
+		t.Errorf("queue after flush = %d reports starting %v, want r3..r5", len(queued), queued)
+	}
+}
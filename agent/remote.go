@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tarish/config"
+)
+
+// RemoteMiner is the server's view of a miner (GET /api/miners/{id})
+type RemoteMiner struct {
+	ID            string          `json:"id"`
+	MinerID       string          `json:"miner_id"`
+	WorkerID      string          `json:"worker_id"`
+	Name          string          `json:"name"`
+	Hostname      string          `json:"hostname"`
+	IP            string          `json:"ip"`
+	CPUModel      string          `json:"cpu_model"`
+	CPUFamily     string          `json:"cpu_family"`
+	Cores         int             `json:"cores"`
+	OS            string          `json:"os"`
+	Arch          string          `json:"arch"`
+	XmrigVersion  string          `json:"xmrig_version"`
+	TarishVersion string          `json:"tarish_version"`
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	Hashrate      *HashrateReport `json:"hashrate,omitempty"`
+	LastSeen      time.Time       `json:"last_seen"`
+	Status        string          `json:"status"`
+}
+
+// PingServer checks that the configured server is reachable and accepts the
+// agent key, returning the round-trip time.
+func PingServer() (time.Duration, error) {
+	serverURL := config.GetServerURL()
+	if serverURL == "" {
+		return 0, fmt.Errorf("no server URL configured (use 'tarish server set <url>')")
+	}
+
+	client := &http.Client{Timeout: httpTimeout}
+	req, err := http.NewRequest("GET", strings.TrimRight(serverURL, "/")+"/api/ping", nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if agentKey := config.GetServerAgentKey(); agentKey != "" {
+		req.Header.Set("Authorization", "Bearer "+agentKey)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("server not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	elapsed := time.Since(start)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return elapsed, nil
+	case http.StatusUnauthorized:
+		return 0, fmt.Errorf("server rejected the agent key (401)")
+	case http.StatusNotFound:
+		return 0, fmt.Errorf("no /api/ping on this server (404), check the URL or upgrade tarish-server")
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
+// QueuedReports returns how many undelivered reports are waiting on disk.
+func QueuedReports() int {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	return len(loadQueue())
+}
+
+// FetchRemoteMiner asks the configured server for a miner's last reported state.
+func FetchRemoteMiner(minerID string) (*RemoteMiner, error) {
+	serverURL := config.GetServerURL()
+	if serverURL == "" {
+		return nil, fmt.Errorf("no server URL configured (use 'tarish server set <url>')")
+	}
+
+	client := &http.Client{Timeout: httpTimeout}
+	reqURL := fmt.Sprintf("%s/api/miners/%s", strings.TrimRight(serverURL, "/"), url.PathEscape(minerID))
+
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if agentKey := config.GetServerAgentKey(); agentKey != "" {
+		req.Header.Set("Authorization", "Bearer "+agentKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("server not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("miner %q not found on server", minerID)
+	}
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var miner RemoteMiner
+	if err := json.NewDecoder(resp.Body).Decode(&miner); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &miner, nil
+}
@@ -7,9 +7,12 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"tarish/config"
 	"tarish/cpu"
+	"tarish/optimize"
 	"tarish/xmrig"
 )
 
@@ -22,6 +25,8 @@ type HashrateReport struct {
 type StatusReport struct {
 	MinerID       string                 `json:"miner_id"`
 	WorkerID      string                 `json:"worker_id"`
+	Name          string                 `json:"name,omitempty"`
+	Tag           string                 `json:"tag,omitempty"`
 	Hostname      string                 `json:"hostname"`
 	IP            string                 `json:"ip"`
 	CPUModel      string                 `json:"cpu_model"`
@@ -30,10 +35,18 @@ type StatusReport struct {
 	OS            string                 `json:"os"`
 	Arch          string                 `json:"arch"`
 	XmrigVersion  string                 `json:"xmrig_version"`
+	Algo          string                 `json:"algo,omitempty"`
 	UptimeSeconds int64                  `json:"uptime_seconds"`
 	Hashrate      *HashrateReport        `json:"hashrate,omitempty"`
+	Accepted      int64                  `json:"accepted"`
+	Rejected      int64                  `json:"rejected"`
 	Config        map[string]interface{} `json:"config,omitempty"`
+	ConfigName    string                 `json:"config_name,omitempty"`
+	UsingFallback bool                   `json:"using_fallback_config"`
+	Hugepages     bool                   `json:"hugepages_enabled"`
+	MSR           bool                   `json:"msr_enabled"`
 	TarishVersion string                 `json:"tarish_version"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
 }
 
 func buildReport(cpuInfo *cpu.Info, version string) *StatusReport {
@@ -41,12 +54,17 @@ func buildReport(cpuInfo *cpu.Info, version string) *StatusReport {
 
 	report := &StatusReport{
 		Hostname:      hostname,
+		Name:          config.GetMinerName(),
+		Tag:           config.GetMinerTag(),
 		CPUModel:      cpuInfo.RawModel,
 		CPUFamily:     cpuInfo.Family,
 		Cores:         cpuInfo.Cores,
 		OS:            cpuInfo.OS,
 		Arch:          cpuInfo.Arch,
+		ConfigName:    xmrig.GetSelectedConfigName(),
+		UsingFallback: xmrig.UsingFallbackConfig(),
 		TarishVersion: version,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
 	}
 
 	// Get miner_id and worker_id from the runtime config file (these don't change)
@@ -66,13 +84,17 @@ func buildReport(cpuInfo *cpu.Info, version string) *StatusReport {
 	}
 
 	// Read LIVE config from xmrig API (reflects applied overrides)
-	port, accessToken := xmrig.GetHTTPConfigFromRuntime()
-	liveConfig := fetchLiveConfig(port, accessToken)
-	if liveConfig != nil {
-		report.Config = liveConfig
+	if liveConfig, err := xmrig.GetLiveConfig(); err == nil {
+		report.Config = redactPoolCredentials(liveConfig)
+	}
+
+	// The server keys miners on miner_id/worker_id and rejects reports
+	// with neither, so make sure there's always a stable one.
+	if report.MinerID == "" && report.WorkerID == "" {
+		report.MinerID = minerID()
 	}
 
-	report.IP = detectLANIP()
+	report.IP = xmrig.DetectLANIP(true)
 	if report.IP == "" && report.WorkerID != "" {
 		report.IP = workerIDToIP(report.WorkerID)
 	}
@@ -80,7 +102,13 @@ func buildReport(cpuInfo *cpu.Info, version string) *StatusReport {
 	apiStatus := fetchLocalXmrigAPI()
 	if apiStatus != nil {
 		report.XmrigVersion = apiStatus.Version
+		report.Algo = apiStatus.Algo
 		report.UptimeSeconds = apiStatus.Uptime
+		report.Accepted = int64(apiStatus.Connection.Accepted)
+		report.Rejected = int64(apiStatus.Connection.Rejected)
+		report.Hugepages = apiStatus.HugepagesEnabled()
+		// xmrig can only apply its MSR preset with the msr module loaded
+		report.MSR = apiStatus.CPU.MSR != "" && apiStatus.CPU.MSR != "none" && optimize.MSRModuleLoaded()
 		if len(apiStatus.Hashrate.Total) >= 3 {
 			report.Hashrate = &HashrateReport{
 				Current: apiStatus.Hashrate.Total[0],
@@ -93,115 +121,74 @@ func buildReport(cpuInfo *cpu.Info, version string) *StatusReport {
 	return report
 }
 
-func fetchLiveConfig(port int, accessToken string) map[string]interface{} {
-	client := &http.Client{Timeout: 2 * time.Second}
-	url := fmt.Sprintf("http://127.0.0.1:%d/1/config", port)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil
-	}
-	if accessToken != "" {
-		req.Header.Set("Authorization", "Bearer "+accessToken)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil
-	}
-
-	var cfg map[string]interface{}
-	if json.Unmarshal(body, &cfg) != nil {
-		return nil
-	}
-	return cfg
-}
-
-// detectLANIP returns a real LAN IP address, skipping VPN/tunnel interfaces.
-// Prefers RFC1918 addresses (192.168.x, 10.x, 172.16-31.x).
-func detectLANIP() string {
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return ""
-	}
-
-	var fallback string
-	for _, iface := range ifaces {
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+// redactedCredential stands in for pool credentials sent to the server
+const redactedCredential = "(redacted)"
+
+// redactPoolCredentials returns cfg with each pool's user and pass masked,
+// so wallets and pool passwords (often expanded from ${ENV:...}) don't
+// leave the machine. cfg itself is not modified.
+func redactPoolCredentials(cfg map[string]interface{}) map[string]interface{} {
+	pools, ok := cfg["pools"].([]interface{})
+	if !ok {
+		return cfg
+	}
+	out := make(map[string]interface{}, len(cfg))
+	for k, v := range cfg {
+		out[k] = v
+	}
+	redacted := make([]interface{}, len(pools))
+	for i, p := range pools {
+		pool, ok := p.(map[string]interface{})
+		if !ok {
+			redacted[i] = p
 			continue
 		}
-		// Skip common VPN/tunnel interface names
-		name := iface.Name
-		if isVPNInterface(name) {
-			continue
+		copied := make(map[string]interface{}, len(pool))
+		for k, v := range pool {
+			if (k == "user" || k == "pass") && v != nil {
+				v = redactedCredential
+			}
+			copied[k] = v
 		}
+		redacted[i] = copied
+	}
+	out["pools"] = redacted
+	return out
+}
 
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
-		}
-		for _, addr := range addrs {
-			ipNet, ok := addr.(*net.IPNet)
-			if !ok {
+// restorePoolCredentials puts the live pool credentials back into an
+// override built from a redacted config, matching pools by index. It
+// reports false if a redacted value is left with nothing to restore it from.
+func restorePoolCredentials(override, live map[string]interface{}) bool {
+	pools, _ := override["pools"].([]interface{})
+	livePools, _ := live["pools"].([]interface{})
+	ok := true
+	for i, p := range pools {
+		pool, _ := p.(map[string]interface{})
+		for _, key := range []string{"user", "pass"} {
+			if pool[key] != redactedCredential {
 				continue
 			}
-			ip := ipNet.IP.To4()
-			if ip == nil {
-				continue
-			}
-			if isPrivateIP(ip) {
-				return ip.String()
+			var livePool map[string]interface{}
+			if i < len(livePools) {
+				livePool, _ = livePools[i].(map[string]interface{})
 			}
-			if fallback == "" {
-				fallback = ip.String()
+			if v, found := livePool[key]; found {
+				pool[key] = v
+			} else {
+				ok = false
 			}
 		}
 	}
-	return fallback
-}
-
-func isVPNInterface(name string) bool {
-	prefixes := []string{"tun", "tap", "utun", "wg", "tailscale", "nordlynx", "proton", "mullvad"}
-	for _, p := range prefixes {
-		if len(name) >= len(p) && name[:len(p)] == p {
-			return true
-		}
-	}
-	return false
-}
-
-func isPrivateIP(ip net.IP) bool {
-	private := []net.IPNet{
-		{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
-		{IP: net.IP{172, 16, 0, 0}, Mask: net.CIDRMask(12, 32)},
-		{IP: net.IP{192, 168, 0, 0}, Mask: net.CIDRMask(16, 32)},
-	}
-	for _, n := range private {
-		if n.Contains(ip) {
-			return true
-		}
-	}
-	return false
+	return ok
 }
 
+// workerIDToIP recovers the IP from legacy IP-style worker IDs
+// ("192-168-1-50"); hostname worker IDs yield "".
 func workerIDToIP(workerID string) string {
-	ip := ""
-	for _, c := range workerID {
-		if c == '-' {
-			ip += "."
-		} else {
-			ip += string(c)
-		}
+	ip := strings.ReplaceAll(workerID, "-", ".")
+	if net.ParseIP(ip) == nil {
+		return ""
 	}
 	return ip
 }
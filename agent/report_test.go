@@ -0,0 +1,42 @@
+package agent
+
+import "testing"
+
+func TestRedactPoolCredentials(t *testing.T) {
+	live := map[string]interface{}{
+		"donate-level": 0.0,
+		"pools": []interface{}{
+			map[string]interface{}{"url": "pool:3333", "user": "4wallet", "pass": "secret"},
+			map[string]interface{}{"url": "backup:3333", "user": "4wallet", "pass": nil},
+		},
+	}
+
+	redacted := redactPoolCredentials(live)
+	pools := redacted["pools"].([]interface{})
+	first, second := pools[0].(map[string]interface{}), pools[1].(map[string]interface{})
+	if first["user"] != redactedCredential || first["pass"] != redactedCredential || first["url"] != "pool:3333" {
+		t.Errorf("first pool = %v", first)
+	}
+	if second["pass"] != nil {
+		t.Errorf("unset pass = %v, want it left unset", second["pass"])
+	}
+	if live["pools"].([]interface{})[0].(map[string]interface{})["user"] != "4wallet" {
+		t.Error("redactPoolCredentials modified the live config")
+	}
+
+	// a dashboard edit of the reported config gets the real values back
+	first["url"] = "newpool:3333"
+	if !restorePoolCredentials(redacted, live) {
+		t.Fatal("restorePoolCredentials = false with the live config at hand")
+	}
+	if first["user"] != "4wallet" || first["pass"] != "secret" || first["url"] != "newpool:3333" {
+		t.Errorf("restored pool = %v", first)
+	}
+
+	added := map[string]interface{}{"pools": []interface{}{
+		map[string]interface{}{"user": redactedCredential},
+	}}
+	if restorePoolCredentials(added, nil) {
+		t.Error("restorePoolCredentials = true with nothing to restore from")
+	}
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"tarish/config"
+	"tarish/update"
+)
+
+// applyTarishOverride applies tarish-level settings pushed by the server.
+// Keys match tarish.json; unknown keys and bad values are logged and
+// skipped. The override is acked only if every known key was applied.
+func applyTarishOverride(settings map[string]interface{}, serverURL, minerID string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	ok := true
+	for key, value := range settings {
+		if err := applyTarishSetting(key, value); err != nil {
+			logger.Warn("failed to apply tarish setting", "key", key, "err", err)
+			ok = false
+			continue
+		}
+		logger.Info("applied tarish setting", "key", key, "value", value)
+	}
+
+	if ok {
+		ackTarishOverride(serverURL, minerID)
+	}
+}
+
+func applyTarishSetting(key string, value interface{}) error {
+	switch key {
+	case "auto_update":
+		enabled, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool, got %T", value)
+		}
+		if err := config.SetAutoUpdate(enabled); err != nil {
+			return err
+		}
+		if !enabled {
+			update.StopDaemon()
+			return nil
+		}
+		return update.StartDaemon()
+	case "check_interval_hours":
+		hours, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("expected number, got %T", value)
+		}
+		return config.SetCheckInterval(int(hours))
+	case "tls-xmrig-proxy":
+		enabled, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool, got %T", value)
+		}
+		// Takes effect on the next 'tarish start'
+		return config.SetTLSXmrigProxy(enabled)
+	case "donate_level_floor":
+		level, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("expected number, got %T", value)
+		}
+		// Takes effect on the next 'tarish start'
+		return config.SetDonateLevelFloor(int(level))
+	default:
+		logger.Warn("ignoring unknown tarish setting", "key", key)
+		return nil
+	}
+}
+
+func ackTarishOverride(serverURL, minerID string) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	ackURL := fmt.Sprintf("%s/api/miners/%s/settings/ack", serverURL, minerID)
+
+	req, err := http.NewRequest("POST", ackURL, nil)
+	if err != nil {
+		logger.Error("failed to create settings ack request", "err", err)
+		return
+	}
+	if agentKey := config.GetServerAgentKey(); agentKey != "" {
+		req.Header.Set("Authorization", "Bearer "+agentKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Warn("failed to ack settings", "err", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 200 {
+		logger.Info("tarish settings acknowledged")
+	} else {
+		respBody, _ := io.ReadAll(resp.Body)
+		logger.Warn("settings ack failed", "status", resp.StatusCode, "body", string(respBody))
+	}
+}
@@ -2,12 +2,18 @@ package antisleep
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+
+	"tarish/config"
+	"tarish/proc"
 )
 
 // Guard represents an anti-sleep guard that prevents system sleep
@@ -17,6 +23,55 @@ type Guard struct {
 	active bool
 }
 
+// Mode selects which kinds of sleep are inhibited
+type Mode int
+
+const (
+	// ModeFull blocks idle sleep, manual suspend and lid-close actions
+	ModeFull Mode = iota
+	// ModeLidOnly keeps the machine running with the lid closed but still
+	// allows idle and manual suspend
+	ModeLidOnly
+)
+
+func (m Mode) String() string {
+	if m == ModeLidOnly {
+		return "lid-only"
+	}
+	return "full"
+}
+
+// ParseMode parses a mode name as printed by Mode.String
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "full":
+		return ModeFull, nil
+	case "lid-only":
+		return ModeLidOnly, nil
+	}
+	return ModeFull, fmt.Errorf("unknown sleep mode %q (use full or lid-only)", name)
+}
+
+// inhibitWhat returns the systemd-inhibit --what value for the mode
+func (m Mode) inhibitWhat() string {
+	if m == ModeLidOnly {
+		return "handle-lid-switch"
+	}
+	return "idle:sleep:handle-lid-switch"
+}
+
+// caffeinateFlags returns the caffeinate flags for the mode.
+// -d: prevent display from sleeping
+// -i: prevent system from idle sleeping
+// -m: prevent disk from idle sleeping
+// -s: prevent system from sleeping (only works when on AC power)
+func (m Mode) caffeinateFlags() string {
+	if m == ModeLidOnly {
+		return "-dm"
+	}
+	return "-dim"
+}
+
 var (
 	globalGuard *Guard
 	guardMu     sync.Mutex
@@ -24,7 +79,7 @@ var (
 
 // Enable prevents the system from going to sleep
 // This function starts a background process that keeps the system awake
-func Enable() error {
+func Enable(mode Mode) error {
 	guardMu.Lock()
 	defer guardMu.Unlock()
 
@@ -33,28 +88,39 @@ func Enable() error {
 		return nil
 	}
 
+	// Replace a process another invocation started (possibly in another
+	// mode) instead of leaving it running unrecorded
+	killSystemProcess()
+
 	guard := &Guard{}
 
 	switch runtime.GOOS {
 	case "darwin":
-		if err := guard.enableMacOS(); err != nil {
+		if err := guard.enableMacOS(mode); err != nil {
 			return fmt.Errorf("failed to enable sleep prevention on macOS: %w", err)
 		}
 	case "linux":
-		if err := guard.enableLinux(); err != nil {
+		if err := guard.enableLinux(mode); err != nil {
 			return fmt.Errorf("failed to enable sleep prevention on Linux: %w", err)
 		}
 	default:
 		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
 
+	// Record the PID so a later tarish invocation stops this process, and
+	// only this one: the user may run caffeinate themselves. A guard no
+	// other invocation could stop isn't left running.
+	if err := savePID(guard.cmd.Process.Pid); err != nil {
+		guard.stop()
+		return fmt.Errorf("failed to record sleep prevention PID: %w", err)
+	}
 	globalGuard = guard
 	return nil
 }
 
 // Disable allows the system to sleep normally again.
-// It stops the in-process guard if present, and also kills any orphaned
-// sleep-prevention process on the system (cross-process cleanup).
+// It stops the in-process guard if present, and also kills the
+// sleep-prevention process an earlier tarish invocation started.
 func Disable() error {
 	guardMu.Lock()
 	defer guardMu.Unlock()
@@ -64,25 +130,88 @@ func Disable() error {
 		globalGuard.stop()
 	}
 
-	// Cross-process cleanup: kill the system-level process
+	// Cross-process cleanup: kill the process another invocation started
 	killSystemProcess()
 	return nil
 }
 
-// killSystemProcess terminates sleep-prevention processes on the system.
+// pidFile holds the PID of the sleep-prevention process tarish started
+func pidFile() string {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "antisleep.pid")
+}
+
+func savePID(pid int) error {
+	path := pidFile()
+	if path == "" {
+		return fmt.Errorf("cannot determine home directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(pid)), 0644)
+}
+
+// guardCommands are the processes a guard runs that another tarish
+// invocation may stop; swapped out in tests
+var guardCommands = []string{"caffeinate", "systemd-inhibit"}
+
+// recordedPID returns the PID from pidFile if it still names the guard
+// process tarish started. A file written before the last boot, or a PID
+// now used by some other program, is not trusted.
+func recordedPID() (int, bool) {
+	path := pidFile()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	if fi, err := os.Stat(path); err == nil {
+		if boot, err := proc.BootTime(); err == nil && fi.ModTime().Before(boot) {
+			return 0, false
+		}
+	}
+	if !proc.Alive(pid) {
+		return 0, false
+	}
+	name, err := proc.Name(pid)
+	if err != nil {
+		return 0, false
+	}
+	for _, cmd := range guardCommands {
+		if name == cmd {
+			return pid, true
+		}
+	}
+	return 0, false
+}
+
+// killSystemProcess terminates the sleep-prevention process tarish
+// recorded, and nothing else
 func killSystemProcess() {
-	switch runtime.GOOS {
-	case "darwin":
-		exec.Command("pkill", "-f", "caffeinate -dim").Run()
-	case "linux":
-		exec.Command("pkill", "-f", "systemd-inhibit.*tarish.*sleep").Run()
+	if pid, ok := recordedPID(); ok {
+		// systemd-inhibit doesn't pass SIGTERM on to its "sleep infinity"
+		children := proc.Children(pid)
+		syscall.Kill(pid, syscall.SIGTERM)
+		for _, child := range children {
+			if name, err := proc.Name(child); err == nil && name == "sleep" {
+				syscall.Kill(child, syscall.SIGTERM)
+			}
+		}
 	}
+	os.Remove(pidFile())
 }
 
 // IsEnabled returns whether sleep prevention is currently active.
-// It checks the in-process guard first, then falls back to detecting
-// the actual system process (caffeinate / systemd-inhibit) so it works
-// across separate tarish invocations (e.g. tarish status).
+// It checks the in-process guard first, then falls back to the recorded
+// system process (caffeinate / systemd-inhibit) so it works across
+// separate tarish invocations (e.g. tarish status).
 func IsEnabled() bool {
 	guardMu.Lock()
 	defer guardMu.Unlock()
@@ -97,36 +226,25 @@ func IsEnabled() bool {
 		}
 	}
 
-	// Cross-process check: detect the actual running process
+	// Cross-process check: is the recorded process still running
 	return isActiveOnSystem()
 }
 
-// isActiveOnSystem detects whether the sleep-prevention process is running
-// on the system, regardless of which tarish invocation started it.
+// isActiveOnSystem reports whether the sleep-prevention process is running,
+// regardless of which tarish invocation started it.
 func isActiveOnSystem() bool {
-	switch runtime.GOOS {
-	case "darwin":
-		out, err := exec.Command("pgrep", "-f", "caffeinate -dim").Output()
-		return err == nil && len(strings.TrimSpace(string(out))) > 0
-	case "linux":
-		out, err := exec.Command("pgrep", "-f", "systemd-inhibit.*tarish.*sleep").Output()
-		return err == nil && len(strings.TrimSpace(string(out))) > 0
-	}
-	return false
+	_, alive := recordedPID()
+	return alive
 }
 
 // enableMacOS uses caffeinate to prevent system sleep on macOS
-func (g *Guard) enableMacOS() error {
+func (g *Guard) enableMacOS(mode Mode) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	// caffeinate options:
-	// -d: prevent display from sleeping
-	// -i: prevent system from idle sleeping
-	// -m: prevent disk from idle sleeping
-	// -s: prevent system from sleeping (only works when on AC power)
-	// We use -dim to prevent all types of sleep
-	cmd := exec.Command("caffeinate", "-dim")
+	// See Mode.caffeinateFlags: full mode uses -dim to prevent all types
+	// of sleep, lid-only drops -i so idle sleep still works
+	cmd := exec.Command("caffeinate", mode.caffeinateFlags())
 
 	// Set process group so we can kill it cleanly
 	cmd.SysProcAttr = &syscall.SysProcAttr{
@@ -152,7 +270,7 @@ func (g *Guard) enableMacOS() error {
 }
 
 // enableLinux uses systemd-inhibit to prevent system sleep on Linux
-func (g *Guard) enableLinux() error {
+func (g *Guard) enableLinux(mode Mode) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
@@ -164,13 +282,14 @@ func (g *Guard) enableLinux() error {
 
 	// systemd-inhibit options:
 	// --what=idle:sleep:handle-lid-switch - prevent idle, sleep, and lid close actions
+	//   (ModeLidOnly uses --what=handle-lid-switch)
 	// --who=tarish - identify the inhibitor
 	// --why="Mining in progress" - reason for inhibition
 	// --mode=block - block sleep completely
 	// sleep infinity - keep the inhibitor alive without depending on stdin
 	cmd := exec.Command(
 		"systemd-inhibit",
-		"--what=idle:sleep:handle-lid-switch",
+		"--what="+mode.inhibitWhat(),
 		"--who=tarish",
 		"--why=Mining in progress - 24/7 operation required",
 		"--mode=block",
@@ -1,7 +1,10 @@
 package antisleep
 
 import (
+	"os"
+	"os/exec"
 	"runtime"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -25,7 +28,7 @@ func TestEnableDisable(t *testing.T) {
 	}
 
 	// Test Enable
-	err := Enable()
+	err := Enable(ModeFull)
 	if err != nil {
 		t.Fatalf("Failed to enable sleep prevention: %v", err)
 	}
@@ -39,7 +42,7 @@ func TestEnableDisable(t *testing.T) {
 	}
 
 	// Test idempotent Enable
-	err = Enable()
+	err = Enable(ModeFull)
 	if err != nil {
 		t.Fatalf("Enable should be idempotent: %v", err)
 	}
@@ -74,7 +77,7 @@ func TestMultipleEnableDisable(t *testing.T) {
 
 	// Enable and disable multiple times
 	for i := 0; i < 3; i++ {
-		err := Enable()
+		err := Enable(ModeFull)
 		if err != nil {
 			t.Fatalf("Enable failed on iteration %d: %v", i, err)
 		}
@@ -104,7 +107,7 @@ func TestPlatformSpecific(t *testing.T) {
 
 	switch runtime.GOOS {
 	case "darwin":
-		err := guard.enableMacOS()
+		err := guard.enableMacOS(ModeFull)
 		if err != nil {
 			t.Fatalf("Failed to enable macOS sleep prevention: %v", err)
 		}
@@ -120,7 +123,7 @@ func TestPlatformSpecific(t *testing.T) {
 		}
 
 	case "linux":
-		err := guard.enableLinux()
+		err := guard.enableLinux(ModeFull)
 		if err != nil {
 			t.Fatalf("Failed to enable Linux sleep prevention: %v", err)
 		}
@@ -140,6 +143,27 @@ func TestPlatformSpecific(t *testing.T) {
 	}
 }
 
+// TestModeFlags checks the inhibit flags each mode selects
+func TestModeFlags(t *testing.T) {
+	tests := []struct {
+		mode       Mode
+		what       string
+		caffeinate string
+	}{
+		{ModeFull, "idle:sleep:handle-lid-switch", "-dim"},
+		{ModeLidOnly, "handle-lid-switch", "-dm"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mode.inhibitWhat(); got != tt.what {
+			t.Errorf("%s: inhibitWhat() = %q, want %q", tt.mode, got, tt.what)
+		}
+		if got := tt.mode.caffeinateFlags(); got != tt.caffeinate {
+			t.Errorf("%s: caffeinateFlags() = %q, want %q", tt.mode, got, tt.caffeinate)
+		}
+	}
+}
+
 // Benchmark for Enable operation
 func BenchmarkEnable(b *testing.B) {
 	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
@@ -147,7 +171,88 @@ func BenchmarkEnable(b *testing.B) {
 	}
 
 	for i := 0; i < b.N; i++ {
-		Enable()
+		Enable(ModeFull)
 		Disable()
 	}
 }
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range []Mode{ModeFull, ModeLidOnly} {
+		if got, err := ParseMode(mode.String()); err != nil || got != mode {
+			t.Errorf("ParseMode(%q) = %v, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseMode("display"); err == nil {
+		t.Error("ParseMode accepted an unknown mode")
+	}
+}
+
+// TestDisableKillsOnlyRecordedProcess checks that Disable stops the process
+// tarish recorded and leaves look-alikes the user started alone
+func TestDisableKillsOnlyRecordedProcess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	orig := guardCommands
+	guardCommands = []string{"sleep"}
+	defer func() { guardCommands = orig }()
+
+	ours, users := startSleep(t), startSleep(t)
+	if err := savePID(ours.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	if !isActiveOnSystem() {
+		t.Fatal("recorded process should count as active")
+	}
+
+	Disable()
+	ours.Wait()
+
+	if isActiveOnSystem() {
+		t.Error("sleep prevention still active after Disable")
+	}
+	if err := users.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("Disable killed a process tarish didn't start: %v", err)
+	}
+}
+
+// TestRecordedPIDIsVerified checks that a PID file is only trusted while it
+// names a guard command and was written this boot
+func TestRecordedPIDIsVerified(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	other := startSleep(t)
+	if err := savePID(other.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	// "sleep" isn't caffeinate or systemd-inhibit: a reused PID
+	if _, ok := recordedPID(); ok {
+		t.Error("recordedPID trusted a process that isn't a guard")
+	}
+	Disable()
+	if err := other.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("Disable killed an unrelated process: %v", err)
+	}
+
+	orig := guardCommands
+	guardCommands = []string{"sleep"}
+	defer func() { guardCommands = orig }()
+	if err := savePID(other.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Unix(1000000000, 0)
+	if err := os.Chtimes(pidFile(), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := recordedPID(); ok {
+		t.Error("recordedPID trusted a PID file from before boot")
+	}
+}
+
+func startSleep(t *testing.T) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill(); cmd.Wait() })
+	return cmd
+}
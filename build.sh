@@ -35,6 +35,21 @@ printf '%s' "${VERSION}" > version
 echo -e "${YELLOW}version${NC} <- ${VERSION}"
 echo ""
 
+# Checksums of the bundled xmrig binaries, embedded and verified on extraction
+(
+    echo "{"
+    first=1
+    for f in $(find bin -type f -name 'xmrig_*' | sort); do
+        [ $first -eq 1 ] || echo ","
+        first=0
+        printf '  "%s": "%s"' "$f" "$(shasum -a 256 "$f" | awk '{print $1}')"
+    done
+    echo ""
+    echo "}"
+) > bin/checksums.json
+echo -e "${YELLOW}bin/checksums.json${NC} <- $(find bin -type f -name 'xmrig_*' | wc -l | tr -d ' ') binaries"
+echo ""
+
 # Create build directory
 mkdir -p "${BUILD_DIR}"
 
@@ -3,16 +3,23 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	neturl "net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 const (
 	configFileName          = "tarish.json"
 	DefaultCheckIntervalHrs = 2
+	DefaultLogMaxSizeMB     = 50
 )
 
+// DefaultVPNInterfacePrefixes are the interface name prefixes the agent
+// skips when picking the IP to report, unless vpn_interface_prefixes is set
+var DefaultVPNInterfacePrefixes = []string{"tun", "tap", "utun", "wg", "tailscale", "nordlynx", "proton", "mullvad"}
+
 // Config holds persistent tarish settings
 type Config struct {
 	AutoUpdate         bool   `json:"auto_update"`
@@ -20,10 +27,46 @@ type Config struct {
 	LastChecked        string `json:"last_checked,omitempty"`         // RFC3339
 	TLSXmrigProxy      *bool  `json:"tls-xmrig-proxy,omitempty"`     // default true
 	ServerURL          string `json:"server_url,omitempty"`
-	ServerAgentKey     string `json:"server_agent_key,omitempty"`
+	ServerAgentKey     string `json:"server_agent_key,omitempty"` // "enc:v1:..." when secret.key exists
 	ServerAPIKey       string `json:"server_api_key,omitempty"` // deprecated, migrated to server_agent_key
+	APILANBind         bool   `json:"api-lan-bind,omitempty"`   // default false: xmrig API on 127.0.0.1 only
+	RotateAPIToken     bool   `json:"rotate-api-token,omitempty"` // fresh xmrig access-token on every start
+	MinerName          string `json:"miner_name,omitempty"`       // friendly label shown on the dashboard
+	MinerTag           string `json:"miner_tag,omitempty"`        // groups rigs meant to run the same config
+	DonateLevelFloor   int    `json:"donate_level_floor,omitempty"` // minimum xmrig donate-level, 0 = none
+	LogMaxSizeMB       int    `json:"log_max_size_mb,omitempty"`    // rotate xmrig.log past this size, default 50
+
+	// VPNInterfacePrefixes replaces DefaultVPNInterfacePrefixes when set;
+	// an empty list ([]) disables VPN filtering entirely
+	VPNInterfacePrefixes *[]string `json:"vpn_interface_prefixes,omitempty"`
+	// LANInterfaces are interface name prefixes that are never skipped,
+	// e.g. ["tailscale"] to report a Tailscale-only rig's tailnet IP
+	LANInterfaces []string `json:"lan_interfaces,omitempty"`
+
+	LogLevel  string `json:"log_level,omitempty"`  // daemon log level: debug, info (default), warn, error
+	LogFormat string `json:"log_format,omitempty"` // daemon log format: text (default) or json
+
+	Schedule []string `json:"schedule,omitempty"` // mining windows, "HH:MM-HH:MM" local time; empty = always
+
+	// Values substituted into ${WALLET}, ${WORKER} and ${POOL} when an
+	// xmrig config is a template
+	Wallet string `json:"wallet,omitempty"`
+	Worker string `json:"worker,omitempty"` // default: the worker-id below
+	Pool   string `json:"pool,omitempty"`
+
+	// How xmrig's api.worker-id is chosen at start: hostname (default), ip
+	// or custom, which uses WorkerIDValue
+	WorkerIDStrategy string `json:"worker_id_strategy,omitempty"`
+	WorkerIDValue    string `json:"worker_id,omitempty"`
 }
 
+// Worker-id strategies
+const (
+	WorkerIDHostname = "hostname" // short hostname
+	WorkerIDIP       = "ip"       // LAN IPv4 with dashes, e.g. 192-168-1-50
+	WorkerIDCustom   = "custom"   // a fixed value
+)
+
 // ConfigDir returns ~/.local/share/tarish (user-wide, same as install share on Linux/macOS)
 func ConfigDir() (string, error) {
 	home, err := os.UserHomeDir()
@@ -87,6 +130,45 @@ func Load() *Config {
 	return &cfg
 }
 
+// Export returns the config for moving to another machine. The agent key
+// is decrypted, since its encryption is bound to this machine, or left out
+// when redact is set; the last update check stays behind.
+func Export(redact bool) (*Config, error) {
+	cfg := Load()
+	cfg.LastChecked = ""
+	cfg.ServerAPIKey = ""
+	if redact {
+		cfg.ServerAgentKey = ""
+		return cfg, nil
+	}
+	key, err := decryptSecret(cfg.ServerAgentKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot export agent key: %w", err)
+	}
+	cfg.ServerAgentKey = key
+	return cfg, nil
+}
+
+// Import replaces the config with an exported one. The agent key is
+// encrypted if secret.key exists here; an export without one keeps this
+// machine's current key.
+func Import(imported *Config) error {
+	current := Load()
+	cfg := *imported
+	cfg.LastChecked = current.LastChecked
+	cfg.ServerAPIKey = ""
+	if cfg.ServerAgentKey == "" {
+		cfg.ServerAgentKey = current.ServerAgentKey
+	} else {
+		stored, err := encryptSecret(cfg.ServerAgentKey)
+		if err != nil {
+			return err
+		}
+		cfg.ServerAgentKey = stored
+	}
+	return Save(&cfg)
+}
+
 // Save writes config to disk
 func Save(cfg *Config) error {
 	dir, err := ConfigDir()
@@ -116,6 +198,16 @@ func SetAutoUpdate(enabled bool) error {
 	return Save(cfg)
 }
 
+// SetCheckInterval persists how often the update daemon checks for releases
+func SetCheckInterval(hours int) error {
+	if hours <= 0 {
+		return fmt.Errorf("interval must be a positive number of hours")
+	}
+	cfg := Load()
+	cfg.CheckIntervalHours = hours
+	return Save(cfg)
+}
+
 // IsAutoUpdateEnabled returns the current auto-update preference
 func IsAutoUpdateEnabled() bool {
 	return Load().AutoUpdate
@@ -188,6 +280,74 @@ func SetTLSXmrigProxy(enabled bool) error {
 	return Save(cfg)
 }
 
+// GetDonateLevelFloor returns the minimum donate-level applied to xmrig
+func GetDonateLevelFloor() int {
+	return Load().DonateLevelFloor
+}
+
+// SetDonateLevelFloor persists the minimum donate-level (0 disables the floor)
+func SetDonateLevelFloor(level int) error {
+	if level < 0 || level > 100 {
+		return fmt.Errorf("donate level floor %d out of range (0-100)", level)
+	}
+	cfg := Load()
+	cfg.DonateLevelFloor = level
+	return Save(cfg)
+}
+
+// GetLogMaxSize returns the xmrig log size (in bytes) that triggers rotation
+func GetLogMaxSize() int64 {
+	mb := Load().LogMaxSizeMB
+	if mb <= 0 {
+		mb = DefaultLogMaxSizeMB
+	}
+	return int64(mb) * 1024 * 1024
+}
+
+// GetSchedule returns the configured mining windows
+func GetSchedule() []string {
+	return Load().Schedule
+}
+
+// SetSchedule persists the mining windows (nil or empty removes the schedule)
+func SetSchedule(windows []string) error {
+	cfg := Load()
+	cfg.Schedule = windows
+	return Save(cfg)
+}
+
+// SetTemplateValue persists the value for a config template placeholder
+// (wallet, worker or pool); an empty value clears it
+func SetTemplateValue(name, value string) error {
+	cfg := Load()
+	switch name {
+	case "wallet":
+		cfg.Wallet = value
+	case "worker":
+		cfg.Worker = value
+	case "pool":
+		cfg.Pool = value
+	default:
+		return fmt.Errorf("unknown template value %q (expected wallet, worker or pool)", name)
+	}
+	return Save(cfg)
+}
+
+// GetVPNInterfacePrefixes returns the interface prefixes the agent treats
+// as VPN/tunnels. An explicitly empty list means no filtering.
+func GetVPNInterfacePrefixes() []string {
+	cfg := Load()
+	if cfg.VPNInterfacePrefixes == nil {
+		return DefaultVPNInterfacePrefixes
+	}
+	return *cfg.VPNInterfacePrefixes
+}
+
+// GetLANInterfaces returns the interface prefixes exempt from VPN filtering
+func GetLANInterfaces() []string {
+	return Load().LANInterfaces
+}
+
 // FormatTLSStatus returns a human-readable summary of the TLS xmrig-proxy config
 func FormatTLSStatus() string {
 	if IsTLSXmrigProxyEnabled() {
@@ -196,6 +356,40 @@ func FormatTLSStatus() string {
 	return "disabled"
 }
 
+// IsAPILANBindEnabled returns whether the xmrig HTTP API may keep the
+// host from the shipped config. Defaults to false (forced to 127.0.0.1).
+func IsAPILANBindEnabled() bool {
+	return Load().APILANBind
+}
+
+// SetAPILANBind persists the xmrig API LAN bind preference
+func SetAPILANBind(enabled bool) error {
+	cfg := Load()
+	cfg.APILANBind = enabled
+	return Save(cfg)
+}
+
+// FormatAPIBindStatus returns a human-readable summary of the xmrig API bind setting
+func FormatAPIBindStatus() string {
+	if IsAPILANBindEnabled() {
+		return "lan (config host)"
+	}
+	return "local (127.0.0.1)"
+}
+
+// IsAPITokenRotationEnabled returns whether a fresh xmrig API access-token
+// is generated into the runtime config on every start
+func IsAPITokenRotationEnabled() bool {
+	return Load().RotateAPIToken
+}
+
+// SetAPITokenRotation persists the xmrig API token rotation preference
+func SetAPITokenRotation(enabled bool) error {
+	cfg := Load()
+	cfg.RotateAPIToken = enabled
+	return Save(cfg)
+}
+
 // GetServerURL returns the configured tarish server URL (empty if not set)
 func GetServerURL() string {
 	return Load().ServerURL
@@ -208,15 +402,116 @@ func SetServerURL(url string) error {
 	return Save(cfg)
 }
 
-// GetServerAgentKey returns the configured agent key for server auth
+// NormalizeServerURL checks that raw is an http(s) URL with a host and
+// strips any trailing slash, since the agent appends API paths to it
+func NormalizeServerURL(raw string) (string, error) {
+	u, err := neturl.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid server URL %q: must start with http:// or https://", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q: missing host", raw)
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// ClearServer removes the server URL and agent key
+func ClearServer() error {
+	cfg := Load()
+	cfg.ServerURL = ""
+	cfg.ServerAgentKey = ""
+	return Save(cfg)
+}
+
+// GetServerAgentKey returns the configured agent key for server auth, or
+// "" if it is encrypted and can't be decrypted on this machine
 func GetServerAgentKey() string {
-	return Load().ServerAgentKey
+	key, err := LoadServerAgentKey()
+	if err != nil {
+		return ""
+	}
+	return key
 }
 
-// SetServerAgentKey persists the agent key for server authentication
+// LoadServerAgentKey returns the agent key, decrypting it if needed
+func LoadServerAgentKey() (string, error) {
+	return decryptSecret(Load().ServerAgentKey)
+}
+
+// SetServerAgentKey persists the agent key for server authentication,
+// encrypted when secret.key exists
 func SetServerAgentKey(key string) error {
+	stored, err := encryptSecret(key)
+	if err != nil {
+		return err
+	}
 	cfg := Load()
-	cfg.ServerAgentKey = key
+	cfg.ServerAgentKey = stored
+	return Save(cfg)
+}
+
+// GetMinerName returns the friendly name reported to the dashboard
+func GetMinerName() string {
+	return Load().MinerName
+}
+
+// SetMinerName persists the friendly name reported to the dashboard
+func SetMinerName(name string) error {
+	cfg := Load()
+	cfg.MinerName = name
+	return Save(cfg)
+}
+
+// GetMinerTag returns the tag the server groups identical rigs by
+func GetMinerTag() string {
+	return Load().MinerTag
+}
+
+// SetMinerTag persists the tag reported to the dashboard; "" clears it
+func SetMinerTag(tag string) error {
+	cfg := Load()
+	cfg.MinerTag = tag
+	return Save(cfg)
+}
+
+// GetWorkerIDStrategy returns the worker-id strategy and, for custom, its
+// value
+func GetWorkerIDStrategy() (strategy, value string) {
+	cfg := Load()
+	switch cfg.WorkerIDStrategy {
+	case WorkerIDIP:
+		return WorkerIDIP, ""
+	case WorkerIDCustom:
+		if cfg.WorkerIDValue != "" {
+			return WorkerIDCustom, cfg.WorkerIDValue
+		}
+	}
+	return WorkerIDHostname, ""
+}
+
+// SetWorkerIDStrategy persists the worker-id strategy; value is required
+// for custom and ignored otherwise
+func SetWorkerIDStrategy(strategy, value string) error {
+	value = strings.TrimSpace(value)
+	switch strategy {
+	case WorkerIDHostname, WorkerIDIP:
+		value = ""
+	case WorkerIDCustom:
+		if value == "" {
+			return fmt.Errorf("custom worker-id needs a value")
+		}
+		if strings.ContainsAny(value, " \t\n") {
+			return fmt.Errorf("worker-id %q must not contain whitespace", value)
+		}
+	default:
+		return fmt.Errorf("unknown worker-id strategy %q (want hostname, ip or custom)", strategy)
+	}
+	cfg := Load()
+	cfg.WorkerIDStrategy = strategy
+	cfg.WorkerIDValue = value
 	return Save(cfg)
 }
 
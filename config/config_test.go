@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeServerURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"https://dash.example.com", "https://dash.example.com", false},
+		{" http://10.0.0.5:8080/ ", "http://10.0.0.5:8080", false},
+		{"https://dash.example.com/tarish/", "https://dash.example.com/tarish", false},
+		{"dash.example.com", "", true},
+		{"ftp://dash.example.com", "", true},
+		{"http://", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeServerURL(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeServerURL(%q) = %q, %v; want %q, err=%v", tt.raw, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestExportImportMovesAgentKey(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	machineID := filepath.Join(home, "machine-id")
+	os.WriteFile(machineID, []byte("machine-a"), 0644)
+	origPaths := machineIDPaths
+	machineIDPaths = []string{machineID}
+	defer func() { machineIDPaths = origPaths }()
+
+	SetServerURL("https://dash.example.com")
+	SetServerAgentKey("agent-secret")
+	if err := EnableEncryption(); err != nil {
+		t.Fatalf("EnableEncryption: %v", err)
+	}
+	RecordCheck()
+
+	exported, err := Export(false)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if exported.ServerAgentKey != "agent-secret" || exported.LastChecked != "" {
+		t.Fatalf("Export = key %q, last_checked %q; want the plain key and no check time", exported.ServerAgentKey, exported.LastChecked)
+	}
+	if redacted, _ := Export(true); redacted.ServerAgentKey != "" {
+		t.Errorf("Export(redact) kept the agent key")
+	}
+
+	// another machine, which encrypts its own keys
+	t.Setenv("HOME", t.TempDir())
+	os.WriteFile(machineID, []byte("machine-b"), 0644)
+	if err := EnableEncryption(); err != nil {
+		t.Fatalf("EnableEncryption: %v", err)
+	}
+	if err := Import(exported); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if got := GetServerURL(); got != "https://dash.example.com" {
+		t.Errorf("server URL = %q after import", got)
+	}
+	if stored := Load().ServerAgentKey; stored == "agent-secret" {
+		t.Error("imported agent key stored in plain text despite secret.key")
+	}
+	if got := GetServerAgentKey(); got != "agent-secret" {
+		t.Errorf("GetServerAgentKey = %q after import, want agent-secret", got)
+	}
+}
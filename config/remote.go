@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ValidateRemoteSettings checks settings the server pushes to an agent
+// (PUT /api/miners/{id}/settings): every key must be one the agent
+// applies (agent.applyTarishSetting; keep the two in step), with a value
+// it accepts. Keys are checked in sorted order so the error is the same
+// every time.
+func ValidateRemoteSettings(settings map[string]interface{}) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := validateRemoteSetting(key, settings[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRemoteSetting(key string, value interface{}) error {
+	switch key {
+	case "auto_update", "tls-xmrig-proxy":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected true or false, got %v", key, value)
+		}
+	case "check_interval_hours":
+		if n, ok := wholeNumber(value); !ok || n <= 0 {
+			return fmt.Errorf("%s: expected a positive whole number of hours, got %v", key, value)
+		}
+	case "donate_level_floor":
+		if n, ok := wholeNumber(value); !ok || n < 0 || n > 100 {
+			return fmt.Errorf("%s: expected a whole number from 0 to 100, got %v", key, value)
+		}
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// wholeNumber returns a JSON number that has no fractional part
+func wholeNumber(value interface{}) (float64, bool) {
+	n, ok := value.(float64)
+	return n, ok && n == math.Trunc(n)
+}
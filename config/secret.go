@@ -0,0 +1,172 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	secretKeyFileName = "secret.key"
+	encryptedPrefix   = "enc:v1:"
+)
+
+// machineIDPaths are read in order; the first one that exists binds the
+// encryption key to this machine
+var machineIDPaths = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+func secretKeyPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, secretKeyFileName), nil
+}
+
+// IsEncryptionEnabled reports whether secret.key exists, i.e. whether the
+// agent key is stored encrypted
+func IsEncryptionEnabled() bool {
+	path, err := secretKeyPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// encryptionKey derives the AES key from secret.key and the machine ID, so
+// a copied config dir doesn't decrypt elsewhere. Returns nil when no key
+// material is configured.
+func encryptionKey() ([]byte, error) {
+	path, err := secretKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	h := sha256.New()
+	h.Write(secret)
+	for _, p := range machineIDPaths {
+		if id, err := os.ReadFile(p); err == nil {
+			h.Write([]byte(strings.TrimSpace(string(id))))
+			break
+		}
+	}
+	return h.Sum(nil), nil
+}
+
+// encryptSecret encrypts value with AES-GCM when key material exists and
+// returns it unchanged otherwise
+func encryptSecret(value string) (string, error) {
+	key, err := encryptionKey()
+	if err != nil || key == nil || value == "" {
+		return value, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret reverses encryptSecret; plaintext values pass through
+func decryptSecret(stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedPrefix) {
+		return stored, nil
+	}
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", fmt.Errorf("value is encrypted but %s is missing", secretKeyFileName)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("corrupt encrypted value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("corrupt encrypted value")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt (encrypted on another machine or %s changed)", secretKeyFileName)
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EnableEncryption creates secret.key (if needed) and re-saves the agent
+// key encrypted with it
+func EnableEncryption() error {
+	agentKey, err := LoadServerAgentKey()
+	if err != nil {
+		return err
+	}
+
+	path, err := secretKeyPath()
+	if err != nil {
+		return err
+	}
+	if !IsEncryptionEnabled() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		secret := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, secret, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return SetServerAgentKey(agentKey)
+}
+
+// DisableEncryption stores the agent key as plaintext again and removes
+// secret.key
+func DisableEncryption() error {
+	agentKey, err := LoadServerAgentKey()
+	if err != nil {
+		return err
+	}
+	path, err := secretKeyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return SetServerAgentKey(agentKey)
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAgentKeyEncryption(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	machineID := filepath.Join(home, "machine-id")
+	if err := os.WriteFile(machineID, []byte("machine-a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origPaths := machineIDPaths
+	machineIDPaths = []string{machineID}
+	defer func() { machineIDPaths = origPaths }()
+
+	// no key material: plaintext, as before
+	if err := SetServerAgentKey("agent-secret"); err != nil {
+		t.Fatalf("SetServerAgentKey: %v", err)
+	}
+	if stored := Load().ServerAgentKey; stored != "agent-secret" {
+		t.Fatalf("stored = %q, want plaintext", stored)
+	}
+
+	if err := EnableEncryption(); err != nil {
+		t.Fatalf("EnableEncryption: %v", err)
+	}
+	stored := Load().ServerAgentKey
+	if !strings.HasPrefix(stored, encryptedPrefix) || strings.Contains(stored, "agent-secret") {
+		t.Fatalf("stored = %q, want encrypted", stored)
+	}
+	if got := GetServerAgentKey(); got != "agent-secret" {
+		t.Fatalf("GetServerAgentKey = %q, want agent-secret", got)
+	}
+
+	// the same files on another machine don't decrypt
+	os.WriteFile(machineID, []byte("machine-b\n"), 0644)
+	if _, err := LoadServerAgentKey(); err == nil {
+		t.Fatal("decrypting with a different machine ID should fail")
+	}
+	os.WriteFile(machineID, []byte("machine-a\n"), 0644)
+
+	if err := DisableEncryption(); err != nil {
+		t.Fatalf("DisableEncryption: %v", err)
+	}
+	if stored := Load().ServerAgentKey; stored != "agent-secret" {
+		t.Fatalf("stored = %q after DisableEncryption, want plaintext", stored)
+	}
+	if IsEncryptionEnabled() {
+		t.Fatal("secret.key should be removed")
+	}
+}
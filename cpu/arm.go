@@ -0,0 +1,127 @@
+package cpu
+
+import (
+	"strings"
+)
+
+// armCPU holds the ARM fields of /proc/cpuinfo, which has no "model name"
+// on most ARM kernels (or a useless "ARMv7 Processor rev 3 (v7l)")
+type armCPU struct {
+	implementer string // "CPU implementer", e.g. 0x41
+	part        string // "CPU part", e.g. 0xd08
+	hardware    string // "Hardware", e.g. BCM2835
+	board       string // "Model" (Raspberry Pi OS), else the device tree or DMI name
+}
+
+// armImplementers maps "CPU implementer" codes to vendors
+var armImplementers = map[string]string{
+	"0x41": "ARM",
+	"0x48": "HiSilicon",
+	"0x51": "Qualcomm",
+	"0x61": "Apple",
+	"0xc0": "Ampere",
+}
+
+// armParts maps implementer/part to core names
+var armParts = map[string]string{
+	"0x41/0xd03": "Cortex-A53",
+	"0x41/0xd04": "Cortex-A35",
+	"0x41/0xd05": "Cortex-A55",
+	"0x41/0xd07": "Cortex-A57",
+	"0x41/0xd08": "Cortex-A72",
+	"0x41/0xd09": "Cortex-A73",
+	"0x41/0xd0a": "Cortex-A75",
+	"0x41/0xd0b": "Cortex-A76",
+	"0x41/0xd0d": "Cortex-A77",
+	"0x41/0xd41": "Cortex-A78",
+	"0x41/0xd0c": "Neoverse-N1",
+	"0x41/0xd40": "Neoverse-V1",
+	"0x41/0xd49": "Neoverse-N2",
+	"0x41/0xd4f": "Neoverse-V2",
+	"0xc0/0xac3": "AmpereOne",
+}
+
+// core returns the core name, e.g. "Cortex-A72", or "" if unknown
+func (a armCPU) core() string {
+	return armParts[strings.ToLower(a.implementer+"/"+a.part)]
+}
+
+// describe returns a model string for an ARM CPU: the board with its core
+// ("Raspberry Pi 4 Model B Rev 1.4 (Cortex-A72)"), else vendor and core
+// ("ARM Neoverse-N1"), else the Hardware line.
+func (a armCPU) describe() string {
+	core := a.core()
+	switch {
+	case a.board != "" && core != "":
+		return a.board + " (" + core + ")"
+	case a.board != "":
+		return a.board
+	case core != "":
+		if vendor := armImplementers[strings.ToLower(a.implementer)]; vendor != "" && !strings.HasPrefix(core, vendor) {
+			return vendor + " " + core
+		}
+		return core
+	case a.hardware != "":
+		return a.hardware
+	}
+	return "unknown"
+}
+
+// armFamilies maps text in a normalized ARM model to a family, most
+// specific first. Boards map to their SoC, servers to their core.
+var armFamilies = []struct {
+	match, family string
+}{
+	{"raspberry_pi_5", "bcm2712"},
+	{"bcm2712", "bcm2712"},
+	{"raspberry_pi_4", "bcm2711"},
+	{"bcm2711", "bcm2711"},
+	{"raspberry_pi_3", "bcm2837"},
+	{"bcm2837", "bcm2837"},
+	{"altra", "ampere_altra"},
+	{"ampereone", "ampere_one"},
+	{"neoverse_n1", "neoverse_n1"},
+	{"neoverse_n2", "neoverse_n2"},
+	{"neoverse_v1", "neoverse_v1"},
+	{"neoverse_v2", "neoverse_v2"},
+	{"cortex_a76", "cortex_a76"},
+	{"cortex_a72", "cortex_a72"},
+	{"cortex_a53", "cortex_a53"},
+}
+
+// armFamily returns the family for a normalized ARM model, or ""
+func armFamily(model string) string {
+	for _, f := range armFamilies {
+		if strings.Contains(model, f.match) {
+			return f.family
+		}
+	}
+	return ""
+}
+
+// ARMBaseFamily returns the core family an ARM SoC or server family is
+// built on (bcm2711 -> cortex_a72, ampere_altra -> neoverse_n1), so a
+// config for the core applies; otherwise family itself
+func ARMBaseFamily(family string) string {
+	switch family {
+	case "bcm2712":
+		return "cortex_a76"
+	case "bcm2711":
+		return "cortex_a72"
+	case "bcm2837":
+		return "cortex_a53"
+	case "ampere_altra":
+		return "neoverse_n1"
+	}
+	return family
+}
+
+// IsARMFamily reports whether family is one armFamily returns
+func IsARMFamily(family string) bool {
+	for _, f := range armFamilies {
+		if f.family == family {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package cpu
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// randomXScratchpadKB is the L3 each RandomX thread wants for its scratchpad
+const randomXScratchpadKB = 2048
+
+// sysCPUDir is swapped out in tests
+var sysCPUDir = "/sys/devices/system/cpu"
+
+// SuggestThreads returns a recommended xmrig thread count. Each RandomX
+// thread wants 2MB of L3, so with a known L3 size that bounds the count,
+// up to one thread per logical CPU (as xmrig's own auto-config does).
+// Without it, one thread per physical core is the safe choice.
+func SuggestThreads(info *Info) int {
+	threads := info.PhysicalCores
+	if info.L3CacheKB > 0 {
+		threads = info.L3CacheKB / randomXScratchpadKB
+		if threads > info.Threads {
+			threads = info.Threads
+		}
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	return threads
+}
+
+// linuxL3CacheKB totals the L3 caches in sysfs. CPUs sharing a cache all
+// list it, so each is counted once by its shared_cpu_list; AMD parts have
+// one L3 per CCX, which cpu0 alone would undercount.
+func linuxL3CacheKB() int {
+	dirs, _ := filepath.Glob(filepath.Join(sysCPUDir, "cpu[0-9]*", "cache", "index[0-9]*"))
+	seen := make(map[string]bool)
+	total := 0
+	for _, dir := range dirs {
+		if readSysFile(filepath.Join(dir, "level")) != "3" {
+			continue
+		}
+		shared := readSysFile(filepath.Join(dir, "shared_cpu_list"))
+		if seen[shared] {
+			continue
+		}
+		seen[shared] = true
+		total += parseCacheSizeKB(readSysFile(filepath.Join(dir, "size")))
+	}
+	return total
+}
+
+// darwinL3CacheKB reads hw.l3cachesize (bytes). Apple Silicon has no L3
+// and reports none.
+func darwinL3CacheKB() int {
+	out, err := exec.Command("sysctl", "-n", "hw.l3cachesize").Output()
+	if err != nil {
+		return 0
+	}
+	bytes, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return bytes / 1024
+}
+
+// parseCacheSizeKB parses a sysfs cache size such as "32768K" or "32M"
+func parseCacheSizeKB(size string) int {
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(size, "K"):
+		size = strings.TrimSuffix(size, "K")
+	case strings.HasSuffix(size, "M"):
+		size = strings.TrimSuffix(size, "M")
+		multiplier = 1024
+	default:
+		return 0
+	}
+	n, err := strconv.Atoi(size)
+	if err != nil {
+		return 0
+	}
+	return n * multiplier
+}
+
+func readSysFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
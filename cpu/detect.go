@@ -6,25 +6,34 @@ import (
 	"os/exec"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
 // Info holds CPU detection results
 type Info struct {
-	Model    string
-	Family   string // e.g., "apple_m3", "intel", "amd"
-	Cores    int
-	Arch     string // "arm64" or "amd64"
-	OS       string // "darwin" or "linux"
-	RawModel string // Original unprocessed model string
+	Model     string
+	Family    string   // e.g., "apple_m3", "intel", "amd"
+	Cores     int      // logical CPUs available to tarish, same as Threads
+	Arch      string   // "arm64" or "amd64"
+	OS        string   // "darwin" or "linux"
+	RawModel  string   // Original unprocessed model string
+	Features  []string // RandomX-relevant features, e.g. "aes", "avx2"
+	L3CacheKB int      // total L3 cache, 0 if unknown or there is none
+
+	// PhysicalCores counts cores, Threads counts hardware threads (logical
+	// CPUs); they differ when SMT (hyperthreading) is on
+	PhysicalCores int
+	Threads       int
 }
 
 // Detect detects CPU information for the current system
 func Detect() (*Info, error) {
 	info := &Info{
-		Cores: runtime.NumCPU(),
-		Arch:  runtime.GOARCH,
-		OS:    runtime.GOOS,
+		Cores:   runtime.NumCPU(),
+		Threads: runtime.NumCPU(),
+		Arch:    runtime.GOARCH,
+		OS:      runtime.GOOS,
 	}
 
 	var err error
@@ -42,12 +51,24 @@ func Detect() (*Info, error) {
 		return nil, err
 	}
 
+	// Without SMT information assume none. A CPU affinity mask (containers,
+	// taskset) can leave fewer threads than the machine has cores.
+	if info.PhysicalCores <= 0 || info.PhysicalCores > info.Threads {
+		info.PhysicalCores = info.Threads
+	}
+
 	info.Family = determineFamily(info.Model)
 	return info, nil
 }
 
 // detectDarwin detects CPU on macOS
 func detectDarwin(info *Info) error {
+	info.Features = darwinFeatures(info.Arch)
+	info.L3CacheKB = darwinL3CacheKB()
+	if out, err := exec.Command("sysctl", "-n", "hw.physicalcpu").Output(); err == nil {
+		info.PhysicalCores, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+	}
+
 	// Try to get Apple Silicon model first
 	if info.Arch == "arm64" {
 		// Get chip name using sysctl
@@ -90,30 +111,98 @@ func detectDarwin(info *Info) error {
 
 // detectLinux detects CPU on Linux
 func detectLinux(info *Info) error {
-	file, err := os.Open("/proc/cpuinfo")
+	info.L3CacheKB = linuxL3CacheKB()
+
+	data, err := os.ReadFile("/proc/cpuinfo")
 	if err != nil {
 		info.Model = "unknown"
 		info.RawModel = "unknown"
 		return nil
 	}
-	defer file.Close()
+	arm := parseCPUInfo(info, string(data))
+	if arm.implementer != "" {
+		if arm.board == "" {
+			arm.board = platformName()
+		}
+		info.RawModel = arm.describe()
+		info.Model = normalizeModel(info.RawModel)
+	}
+	return nil
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "model name") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				info.RawModel = strings.TrimSpace(parts[1])
-				info.Model = normalizeModel(info.RawModel)
-				return nil
+// platformName returns the board or server name from the device tree
+// (ARM boards) or DMI (ARM servers, only when it names the vendor's CPU)
+func platformName() string {
+	for _, path := range []string{"/proc/device-tree/model", "/sys/firmware/devicetree/base/model"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if model := strings.TrimSpace(strings.TrimRight(string(data), "\x00")); model != "" {
+				return model
 			}
 		}
 	}
+	// Ampere Altra systems report the Neoverse-N1 core in cpuinfo; the
+	// platform is only visible in DMI
+	for _, path := range []string{"/sys/devices/virtual/dmi/id/product_name", "/sys/devices/virtual/dmi/id/sys_vendor"} {
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(strings.ToLower(string(data)), "altra") {
+			return "Ampere Altra"
+		}
+	}
+	return ""
+}
 
+// parseCPUInfo fills info from /proc/cpuinfo: the model from the first
+// "model name", features from the first "flags" (x86) or "Features" (ARM),
+// and physical cores from the distinct "physical id"/"core id" pairs. The
+// ARM identification fields are returned for detectLinux to describe.
+func parseCPUInfo(info *Info, cpuinfo string) armCPU {
 	info.Model = "unknown"
 	info.RawModel = "unknown"
-	return nil
+	gotModel, gotFlags := false, false
+	physicalID := ""
+	cores := make(map[string]bool)
+	var arm armCPU
+
+	scanner := bufio.NewScanner(strings.NewReader(cpuinfo))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "processor":
+			physicalID = ""
+		case "model name":
+			if !gotModel {
+				info.RawModel = value
+				info.Model = normalizeModel(value)
+				gotModel = true
+			}
+		case "flags", "Features":
+			if !gotFlags {
+				info.Features = parseFeatures(strings.Fields(value))
+				gotFlags = true
+			}
+		case "physical id":
+			physicalID = value
+		case "core id":
+			cores[physicalID+"/"+value] = true
+		case "CPU implementer":
+			if arm.implementer == "" {
+				arm.implementer = value
+			}
+		case "CPU part":
+			if arm.part == "" {
+				arm.part = value
+			}
+		case "Hardware":
+			arm.hardware = value
+		case "Model":
+			arm.board = value
+		}
+	}
+	info.PhysicalCores = len(cores)
+	return arm
 }
 
 // normalizeModel converts raw model string to a normalized form
@@ -140,6 +229,11 @@ func normalizeModel(raw string) string {
 func determineFamily(model string) string {
 	modelLower := strings.ToLower(model)
 
+	// ARM boards and servers, before Apple: "bcm2711" contains "m2"
+	if family := armFamily(modelLower); family != "" {
+		return family
+	}
+
 	// Apple Silicon detection
 	if strings.Contains(modelLower, "apple") || strings.Contains(modelLower, "m1") ||
 		strings.Contains(modelLower, "m2") || strings.Contains(modelLower, "m3") ||
@@ -280,6 +374,11 @@ func determineFamily(model string) string {
 	return "generic"
 }
 
+// SMT reports whether the cores run more than one thread each
+func (i *Info) SMT() bool {
+	return i.Threads > i.PhysicalCores
+}
+
 // GetConfigName returns the suggested config filename for this CPU
 func (i *Info) GetConfigName() string {
 	return i.Family + ".json"
@@ -0,0 +1,184 @@
+package cpu
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const ryzenCPUInfo = `processor	: 0
+vendor_id	: AuthenticAMD
+cpu family	: 25
+model name	: AMD Ryzen 9 5950X 16-Core Processor
+flags		: fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush mmx fxsr sse sse2 ht syscall nx mmxext fxsr_opt pdpe1gb rdtscp lm constant_tsc pni pclmulqdq monitor ssse3 fma cx16 sse4_1 sse4_2 movbe popcnt aes xsave avx f16c rdrand lahf_lm bmi1 avx2 smep bmi2
+
+processor	: 1
+vendor_id	: AuthenticAMD
+model name	: AMD Ryzen 9 5950X 16-Core Processor
+flags		: fpu sse2
+`
+
+const raspberryPiCPUInfo = `processor	: 0
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm crc32 cpuid
+CPU implementer	: 0x41
+CPU part	: 0xd08
+
+Hardware	: BCM2835
+Model		: Raspberry Pi 4 Model B Rev 1.4
+`
+
+func TestParseCPUInfoFeatures(t *testing.T) {
+	tests := []struct {
+		name, cpuinfo string
+		model         string
+		features      []string
+	}{
+		{"x86", ryzenCPUInfo, "AMD Ryzen 9 5950X 16-Core Processor",
+			[]string{"sse2", "ssse3", "sse4_1", "sse4_2", "aes", "avx", "avx2", "bmi2", "pdpe1gb"}},
+		{"arm", raspberryPiCPUInfo, "unknown", []string{"asimd"}},
+		{"empty", "", "unknown", nil},
+	}
+	for _, tt := range tests {
+		info := &Info{}
+		parseCPUInfo(info, tt.cpuinfo)
+		if info.RawModel != tt.model {
+			t.Errorf("%s: RawModel = %q, want %q", tt.name, info.RawModel, tt.model)
+		}
+		if !reflect.DeepEqual(info.Features, tt.features) {
+			t.Errorf("%s: Features = %v, want %v", tt.name, info.Features, tt.features)
+		}
+	}
+
+	info := &Info{}
+	parseCPUInfo(info, ryzenCPUInfo)
+	if !info.HasFeature("avx2") || info.HasFeature("avx512f") {
+		t.Errorf("HasFeature: avx2 = %v, avx512f = %v", info.HasFeature("avx2"), info.HasFeature("avx512f"))
+	}
+}
+
+func TestParseFeaturesMacOS(t *testing.T) {
+	// machdep.cpu.features + leaf7_features + extfeatures on an Intel Mac
+	flags := []string{"FPU", "SSE2", "SSSE3", "SSE4.1", "SSE4.2", "AES", "AVX1.0", "BMI1", "AVX2", "BMI2", "SYSCALL", "1GBPAGE"}
+	want := []string{"sse2", "ssse3", "sse4_1", "sse4_2", "aes", "avx", "avx2", "bmi2", "pdpe1gb"}
+	if got := parseFeatures(flags); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFeatures(Intel Mac) = %v, want %v", got, want)
+	}
+
+	hwOptional := "hw.optional.arm.FEAT_AES: 1\nhw.optional.arm.FEAT_PMULL: 1\nhw.optional.arm.FEAT_SHA256: 1\nhw.optional.arm.FEAT_SME: 0\nhw.optional.AdvSIMD: 1\n"
+	want = []string{"aes", "asimd", "pmull", "sha2"}
+	if got := parseFeatures(parseHWOptional(hwOptional)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFeatures(Apple Silicon) = %v, want %v", got, want)
+	}
+}
+
+func TestParseCPUInfoPhysicalCores(t *testing.T) {
+	// two sockets, two cores each, two threads per core
+	var cpuinfo string
+	for _, c := range []struct{ socket, core string }{
+		{"0", "0"}, {"0", "1"}, {"1", "0"}, {"1", "1"},
+		{"0", "0"}, {"0", "1"}, {"1", "0"}, {"1", "1"},
+	} {
+		cpuinfo += "processor\t: n\nphysical id\t: " + c.socket + "\ncore id\t\t: " + c.core + "\n\n"
+	}
+
+	info := &Info{Threads: 8}
+	parseCPUInfo(info, cpuinfo)
+	if info.PhysicalCores != 4 || !info.SMT() {
+		t.Errorf("PhysicalCores = %d, SMT = %v; want 4 cores with SMT", info.PhysicalCores, info.SMT())
+	}
+
+	// ARM cpuinfo has no core ids; Detect treats that as one thread per core
+	info = &Info{}
+	parseCPUInfo(info, raspberryPiCPUInfo)
+	if info.PhysicalCores != 0 {
+		t.Errorf("PhysicalCores = %d without core ids, want 0 (unknown)", info.PhysicalCores)
+	}
+}
+
+func TestSuggestThreads(t *testing.T) {
+	tests := []struct {
+		name string
+		info Info
+		want int
+	}{
+		{"cache bound", Info{PhysicalCores: 8, Threads: 16, L3CacheKB: 16 * 1024}, 8},
+		{"cache allows SMT threads", Info{PhysicalCores: 16, Threads: 32, L3CacheKB: 64 * 1024}, 32},
+		{"more cache than threads", Info{PhysicalCores: 4, Threads: 4, L3CacheKB: 32 * 1024}, 4},
+		{"no L3", Info{PhysicalCores: 8, Threads: 8}, 8},
+		{"tiny L3", Info{PhysicalCores: 2, Threads: 4, L3CacheKB: 1024}, 1},
+	}
+	for _, tt := range tests {
+		if got := SuggestThreads(&tt.info); got != tt.want {
+			t.Errorf("%s: SuggestThreads = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLinuxL3CacheKBCountsSharedCachesOnce(t *testing.T) {
+	dir := t.TempDir()
+	origDir := sysCPUDir
+	sysCPUDir = dir
+	defer func() { sysCPUDir = origDir }()
+
+	// two CCXs with a 32MB L3 each, shared by cpus 0-1 and 2-3
+	for cpu, shared := range []string{"0-1", "0-1", "2-3", "2-3"} {
+		for index, cache := range [][3]string{{"2", "512K", strconv.Itoa(cpu)}, {"3", "32768K", shared}} {
+			path := filepath.Join(dir, "cpu"+strconv.Itoa(cpu), "cache", "index"+strconv.Itoa(index))
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			os.WriteFile(filepath.Join(path, "level"), []byte(cache[0]+"\n"), 0644)
+			os.WriteFile(filepath.Join(path, "size"), []byte(cache[1]+"\n"), 0644)
+			os.WriteFile(filepath.Join(path, "shared_cpu_list"), []byte(cache[2]+"\n"), 0644)
+		}
+	}
+
+	if got := linuxL3CacheKB(); got != 64*1024 {
+		t.Errorf("linuxL3CacheKB = %d, want %d", got, 64*1024)
+	}
+	for size, want := range map[string]int{"32768K": 32768, "32M": 32768, "": 0, "big": 0} {
+		if got := parseCacheSizeKB(size); got != want {
+			t.Errorf("parseCacheSizeKB(%q) = %d, want %d", size, got, want)
+		}
+	}
+}
+
+func TestARMDetection(t *testing.T) {
+	tests := []struct {
+		name, cpuinfo, platform string
+		rawModel, family        string
+	}{
+		{"raspberry pi 4", raspberryPiCPUInfo, "",
+			"Raspberry Pi 4 Model B Rev 1.4 (Cortex-A72)", "bcm2711"},
+		{"raspberry pi 5 via device tree", "processor\t: 0\nCPU implementer\t: 0x41\nCPU part\t: 0xd0b\n", "Raspberry Pi 5 Model B Rev 1.0",
+			"Raspberry Pi 5 Model B Rev 1.0 (Cortex-A76)", "bcm2712"},
+		{"ampere altra", "processor\t: 0\nCPU implementer\t: 0x41\nCPU part\t: 0xd0c\n", "Ampere Altra",
+			"Ampere Altra (Neoverse-N1)", "ampere_altra"},
+		{"graviton2", "processor\t: 0\nCPU implementer\t: 0x41\nCPU part\t: 0xd0c\n", "",
+			"ARM Neoverse-N1", "neoverse_n1"},
+		{"ampereone", "processor\t: 0\nCPU implementer\t: 0xc0\nCPU part\t: 0xac3\n", "",
+			"AmpereOne", "ampere_one"},
+		{"unknown part", "processor\t: 0\nCPU implementer\t: 0x41\nCPU part\t: 0xfff\nHardware\t: BCM2711\n", "",
+			"BCM2711", "bcm2711"},
+	}
+	for _, tt := range tests {
+		arm := parseCPUInfo(&Info{}, tt.cpuinfo)
+		if arm.board == "" {
+			arm.board = tt.platform
+		}
+		raw := arm.describe()
+		if raw != tt.rawModel {
+			t.Errorf("%s: model = %q, want %q", tt.name, raw, tt.rawModel)
+		}
+		if family := determineFamily(normalizeModel(raw)); family != tt.family {
+			t.Errorf("%s: family = %q, want %q", tt.name, family, tt.family)
+		}
+	}
+
+	if base := ARMBaseFamily("bcm2711"); base != "cortex_a72" {
+		t.Errorf("ARMBaseFamily(bcm2711) = %q, want cortex_a72", base)
+	}
+}
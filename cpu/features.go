@@ -0,0 +1,92 @@
+package cpu
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// knownFeatures are the CPU features that matter for RandomX and xmrig,
+// in the order Info.Features lists them. Names follow /proc/cpuinfo.
+var knownFeatures = []string{
+	// x86
+	"sse2", "ssse3", "sse4_1", "sse4_2", "aes", "avx", "avx2", "avx512f", "bmi2",
+	"pdpe1gb", // 1GB huge pages for the RandomX dataset
+	// ARM
+	"asimd", "pmull", "sha2",
+}
+
+// featureAliases maps other spellings (macOS sysctl) to knownFeatures names
+var featureAliases = map[string]string{
+	"sse4.1":  "sse4_1",
+	"sse4.2":  "sse4_2",
+	"avx1.0":  "avx",
+	"1gbpage": "pdpe1gb",
+	"neon":    "asimd",
+	"advsimd": "asimd",
+	"sha256":  "sha2",
+}
+
+// HasFeature reports whether the CPU has feature, e.g. "avx2"
+func (i *Info) HasFeature(feature string) bool {
+	for _, f := range i.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFeatures picks the known features out of a list of CPU flags
+func parseFeatures(flags []string) []string {
+	have := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		flag = strings.ToLower(flag)
+		if alias, ok := featureAliases[flag]; ok {
+			flag = alias
+		}
+		have[flag] = true
+	}
+
+	var features []string
+	for _, f := range knownFeatures {
+		if have[f] {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// darwinFeatures reads CPU features from sysctl: machdep.cpu.features,
+// leaf7_features and extfeatures on Intel Macs, hw.optional on Apple Silicon
+func darwinFeatures(arch string) []string {
+	if arch == "arm64" {
+		out, err := exec.Command("sysctl", "hw.optional").Output()
+		if err != nil {
+			return nil
+		}
+		return parseFeatures(parseHWOptional(string(out)))
+	}
+
+	var flags []string
+	for _, key := range []string{"machdep.cpu.features", "machdep.cpu.leaf7_features", "machdep.cpu.extfeatures"} {
+		if out, err := exec.Command("sysctl", "-n", key).Output(); err == nil {
+			flags = append(flags, strings.Fields(string(out))...)
+		}
+	}
+	return parseFeatures(flags)
+}
+
+// parseHWOptional returns the enabled flags in `sysctl hw.optional` output
+// ("hw.optional.arm.FEAT_AES: 1" gives "aes")
+func parseHWOptional(out string) []string {
+	var flags []string
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(value) != "1" {
+			continue
+		}
+		name := key[strings.LastIndexByte(key, '.')+1:]
+		flags = append(flags, strings.TrimPrefix(name, "FEAT_"))
+	}
+	return flags
+}
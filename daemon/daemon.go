@@ -0,0 +1,143 @@
+// Package daemon runs tarish features as background processes. tarish
+// re-executes itself with a hidden "_<name>-daemon" command, records the
+// PID in ~/.local/share/tarish/<name>-daemon.pid and appends the output to
+// log/<name>-daemon.log there.
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"tarish/config"
+)
+
+// Daemon is one named background process, e.g. New("agent") runs
+// "tarish _agent-daemon"
+type Daemon struct {
+	name string
+}
+
+// New returns the daemon called name
+func New(name string) *Daemon {
+	return &Daemon{name: name}
+}
+
+// Start spawns the daemon with args after its hidden command and returns
+// its PID. It doesn't check for a running instance; callers decide
+// whether to keep or replace one.
+func (d *Daemon) Start(args ...string) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("cannot locate tarish binary: %w", err)
+	}
+	exe, _ = filepath.EvalSymlinks(exe)
+
+	if err := os.MkdirAll(LogDir(), 0755); err != nil {
+		return 0, fmt.Errorf("cannot create log dir: %w", err)
+	}
+
+	logFile, err := os.OpenFile(d.LogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("cannot open daemon log: %w", err)
+	}
+
+	cmd := exec.Command(exe, append([]string{"_" + d.name + "-daemon"}, args...)...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return 0, fmt.Errorf("failed to start %s daemon: %w", d.name, err)
+	}
+
+	if err := d.savePID(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		logFile.Close()
+		return 0, err
+	}
+
+	// Detach – let the daemon run independently.
+	go func() {
+		cmd.Wait()
+		logFile.Close()
+		os.Remove(d.PIDFile())
+	}()
+
+	return cmd.Process.Pid, nil
+}
+
+// Stop sends SIGTERM to the daemon (if running), then SIGKILL if it
+// hasn't exited shortly after.
+func (d *Daemon) Stop() {
+	pid, running := d.IsRunning()
+	if !running {
+		return
+	}
+	if p, err := os.FindProcess(pid); err == nil {
+		_ = p.Signal(syscall.SIGTERM)
+		time.Sleep(200 * time.Millisecond)
+		if isProcessAlive(pid) {
+			_ = p.Signal(syscall.SIGKILL)
+		}
+	}
+	os.Remove(d.PIDFile())
+}
+
+// IsRunning reports the PID and whether the daemon is alive.
+func (d *Daemon) IsRunning() (int, bool) {
+	data, err := os.ReadFile(d.PIDFile())
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	return pid, isProcessAlive(pid)
+}
+
+// PIDFile returns where the daemon's PID is recorded
+func (d *Daemon) PIDFile() string {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "/tmp/tarish-" + d.name + "-daemon.pid"
+	}
+	return filepath.Join(dir, d.name+"-daemon.pid")
+}
+
+// LogPath returns the daemon's log file
+func (d *Daemon) LogPath() string {
+	return filepath.Join(LogDir(), d.name+"-daemon.log")
+}
+
+// LogDir returns the directory the daemons log to
+func LogDir() string {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "/tmp"
+	}
+	return filepath.Join(dir, "log")
+}
+
+func (d *Daemon) savePID(pid int) error {
+	path := d.PIDFile()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(pid)), 0644)
+}
+
+func isProcessAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	d := New("watchdog")
+	dir := filepath.Join(home, ".local", "share", "tarish")
+	if got, want := d.PIDFile(), filepath.Join(dir, "watchdog-daemon.pid"); got != want {
+		t.Errorf("PIDFile() = %s, want %s", got, want)
+	}
+	if got, want := d.LogPath(), filepath.Join(dir, "log", "watchdog-daemon.log"); got != want {
+		t.Errorf("LogPath() = %s, want %s", got, want)
+	}
+}
+
+func TestStopKillsRecordedProcess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	d := New("test")
+	if _, running := d.IsRunning(); running {
+		t.Fatal("IsRunning without a PID file")
+	}
+
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	if err := d.savePID(cmd.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	if pid, running := d.IsRunning(); !running || pid != cmd.Process.Pid {
+		t.Fatalf("IsRunning() = %d, %v, want %d, true", pid, running, cmd.Process.Pid)
+	}
+
+	d.Stop()
+	if err := <-exited; err == nil || !strings.Contains(err.Error(), "terminated") {
+		t.Errorf("process exit = %v, want terminated by SIGTERM", err)
+	}
+	if _, running := d.IsRunning(); running {
+		t.Error("IsRunning after Stop")
+	}
+}
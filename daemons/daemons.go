@@ -0,0 +1,27 @@
+// Package daemons acts on all of tarish's background daemons at once, for
+// 'tarish stop' and uninstall.
+package daemons
+
+import (
+	"tarish/activity"
+	"tarish/agent"
+	"tarish/schedule"
+	"tarish/update"
+	"tarish/watchdog"
+)
+
+// StopAll stops every background daemon. The watchdog goes first so it
+// can't restart an xmrig that is about to be stopped or removed.
+func StopAll() {
+	watchdog.StopDaemon()
+
+	// Stop pause-on-activity and schedule daemons
+	activity.StopDaemon()
+	schedule.StopDaemon()
+
+	// Stop agent daemon
+	agent.StopDaemon()
+
+	// Stop auto-update daemon
+	update.StopDaemon()
+}
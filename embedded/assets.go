@@ -1,16 +1,29 @@
 package embedded
 
 import (
-	"embed"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/semver"
 )
 
 // Assets is the embedded filesystem, set from main package
-var Assets embed.FS
+var Assets fs.FS
+
+// checksumsFile maps embedded binary paths (bin/<version>/xmrig_...) to
+// their SHA-256, generated by build.sh
+const checksumsFile = "bin/checksums.json"
 
 // GetSharePath returns the default share path based on user permissions
 func GetSharePath() string {
@@ -21,25 +34,69 @@ func GetSharePath() string {
 	return filepath.Join(home, ".local", "share", "tarish")
 }
 
-// ExtractAssets extracts all embedded assets to the share directory
-func ExtractAssets(destPath string) error {
+// ExtractAssets extracts all embedded assets to the share directory.
+// Files already on disk with identical contents are left alone unless
+// force is set.
+func ExtractAssets(destPath string, force bool) error {
 	if destPath == "" {
 		destPath = GetSharePath()
 	}
 
 	// Extract bin directory
-	if err := extractDir("bin", destPath); err != nil {
+	if err := extractDir("bin", destPath, force); err != nil {
 		return fmt.Errorf("failed to extract bin: %w", err)
 	}
 
 	// Extract configs directory
-	if err := extractDir("configs", destPath); err != nil {
+	if err := extractDir("configs", destPath, force); err != nil {
 		return fmt.Errorf("failed to extract configs: %w", err)
 	}
 
 	return nil
 }
 
+// ListAssets returns the paths (relative to the share directory) of every
+// file ExtractAssets would write
+func ListAssets() ([]string, error) {
+	var paths []string
+	for _, dir := range []string{"bin", "configs"} {
+		err := fs.WalkDir(Assets, dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && d.Name() != ".DS_Store" && path != checksumsFile {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// EmbeddedXmrigVersion returns the bundled xmrig versions (the bin/<version>
+// directory names), newest first, without extracting anything to disk.
+func EmbeddedXmrigVersion() ([]string, error) {
+	entries, err := fs.ReadDir(Assets, "bin")
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			versions = append(versions, entry.Name())
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare("v"+strings.TrimPrefix(versions[i], "v"), "v"+strings.TrimPrefix(versions[j], "v")) > 0
+	})
+	return versions, nil
+}
+
 // ExtractXmrigBinary extracts only the xmrig binary for the current platform
 func ExtractXmrigBinary(destPath string) (string, error) {
 	if destPath == "" {
@@ -83,7 +140,7 @@ func ExtractXmrigBinary(destPath string) (string, error) {
 
 	// Extract the binary
 	destFile := filepath.Join(destDir, binaryName)
-	if err := extractFile(foundPath, destFile); err != nil {
+	if err := extractFile(foundPath, destFile, false); err != nil {
 		return "", err
 	}
 
@@ -101,18 +158,18 @@ func ExtractConfigs(destPath string) error {
 		destPath = GetSharePath()
 	}
 
-	return extractDir("configs", destPath)
+	return extractDir("configs", destPath, false)
 }
 
 // GetEmbeddedConfig reads a config file directly from embedded assets
 func GetEmbeddedConfig(name string) ([]byte, error) {
 	path := filepath.Join("configs", name)
-	return Assets.ReadFile(path)
+	return fs.ReadFile(Assets, path)
 }
 
 // ListEmbeddedConfigs returns all embedded config file names
 func ListEmbeddedConfigs() ([]string, error) {
-	entries, err := Assets.ReadDir("configs")
+	entries, err := fs.ReadDir(Assets, "configs")
 	if err != nil {
 		return nil, err
 	}
@@ -137,14 +194,14 @@ func GetPlatformName() string {
 }
 
 // extractDir extracts an embedded directory to destination
-func extractDir(srcDir, destBase string) error {
+func extractDir(srcDir, destBase string, force bool) error {
 	return fs.WalkDir(Assets, srcDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip .DS_Store files
-		if d.Name() == ".DS_Store" {
+		// Skip .DS_Store files and the checksum manifest
+		if d.Name() == ".DS_Store" || path == checksumsFile {
 			return nil
 		}
 
@@ -154,18 +211,23 @@ func extractDir(srcDir, destBase string) error {
 			return os.MkdirAll(destPath, 0755)
 		}
 
-		return extractFile(path, destPath)
+		return extractFile(path, destPath, force)
 	})
 }
 
-// extractFile extracts a single file from embedded assets
-func extractFile(srcPath, destPath string) error {
+// extractFile extracts a single file from embedded assets, skipping the
+// write when destPath already holds the same contents (unless force)
+func extractFile(srcPath, destPath string, force bool) error {
 	// Read from embedded
-	data, err := Assets.ReadFile(srcPath)
+	data, err := fs.ReadFile(Assets, srcPath)
 	if err != nil {
 		return err
 	}
 
+	if !force && sameContents(destPath, data) {
+		return nil
+	}
+
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
 		return err
@@ -176,5 +238,78 @@ func extractFile(srcPath, destPath string) error {
 		return err
 	}
 
+	// Verify what actually landed on disk (catches short writes, disk full)
+	if err := verifyChecksum(srcPath, destPath); err != nil {
+		os.Remove(destPath)
+		return err
+	}
+
 	return nil
 }
+
+// sameContents reports whether the file at path holds exactly data. Size
+// is checked first so differing files are usually rejected without a read;
+// embedded files have no mtime to compare.
+func sameContents(path string, data []byte) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != int64(len(data)) {
+		return false
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(existing, data)
+}
+
+// verifyChecksum compares destPath against the embedded checksum for
+// srcPath. Files without a checksum entry (configs) are not verified.
+func verifyChecksum(srcPath, destPath string) error {
+	sums, err := loadChecksums()
+	if err != nil {
+		return err
+	}
+	want, ok := sums[filepath.ToSlash(srcPath)]
+	if !ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(destPath)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", destPath, got, want)
+	}
+	return nil
+}
+
+var (
+	checksumsOnce sync.Once
+	checksums     map[string]string
+	checksumsErr  error
+)
+
+// loadChecksums parses the embedded checksum manifest once per process.
+// A build without a manifest means no verification; one that can't be
+// read or parsed is an error, so a broken build never skips the check.
+func loadChecksums() (map[string]string, error) {
+	checksumsOnce.Do(func() { checksums, checksumsErr = readChecksums() })
+	return checksums, checksumsErr
+}
+
+func readChecksums() (map[string]string, error) {
+	data, err := fs.ReadFile(Assets, checksumsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", checksumsFile, err)
+	}
+	var sums map[string]string
+	if err := json.Unmarshal(data, &sums); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", checksumsFile, err)
+	}
+	return sums, nil
+}
@@ -0,0 +1,127 @@
+package embedded
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func fakeAssets(t *testing.T, binary, checksummed []byte) {
+	t.Helper()
+	sum := sha256.Sum256(checksummed)
+	old := Assets
+	Assets = fstest.MapFS{
+		"bin/6.25.0/xmrig_linux_amd64": {Data: binary},
+		"bin/checksums.json": {Data: []byte(`{"bin/6.25.0/xmrig_linux_amd64": "` +
+			hex.EncodeToString(sum[:]) + `"}`)},
+		"configs/generic.json": {Data: []byte(`{}`)},
+	}
+	resetChecksums(t)
+	t.Cleanup(func() { Assets = old })
+}
+
+// resetChecksums makes the next lookup parse the current Assets again
+func resetChecksums(t *testing.T) {
+	checksumsOnce = sync.Once{}
+	t.Cleanup(func() { checksumsOnce = sync.Once{} })
+}
+
+func TestExtractAssetsVerifiesChecksum(t *testing.T) {
+	binary := []byte("xmrig binary")
+	fakeAssets(t, binary, binary)
+
+	dest := t.TempDir()
+	if err := ExtractAssets(dest, false); err != nil {
+		t.Fatalf("ExtractAssets: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "bin", "6.25.0", "xmrig_linux_amd64"))
+	if err != nil || string(got) != string(binary) {
+		t.Fatalf("extracted binary = %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "bin", "checksums.json")); !os.IsNotExist(err) {
+		t.Error("checksums.json should not be extracted")
+	}
+}
+
+func TestExtractAssetsRejectsCorruptBinary(t *testing.T) {
+	fakeAssets(t, []byte("truncated"), []byte("xmrig binary"))
+
+	dest := t.TempDir()
+	err := ExtractAssets(dest, false)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("ExtractAssets error = %v, want checksum mismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "bin", "6.25.0", "xmrig_linux_amd64")); !os.IsNotExist(err) {
+		t.Error("corrupt binary should have been removed")
+	}
+}
+
+func TestExtractAssetsSkipsUnchangedFiles(t *testing.T) {
+	binary := []byte("xmrig binary")
+	fakeAssets(t, binary, binary)
+
+	dest := t.TempDir()
+	if err := ExtractAssets(dest, false); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dest, "bin", "6.25.0", "xmrig_linux_amd64")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+	mtime := func() time.Time {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.ModTime()
+	}
+
+	// Identical file: not rewritten
+	if err := ExtractAssets(dest, false); err != nil {
+		t.Fatal(err)
+	}
+	if !mtime().Equal(past) {
+		t.Error("unchanged file was rewritten without force")
+	}
+
+	// force: rewritten anyway
+	if err := ExtractAssets(dest, true); err != nil {
+		t.Fatal(err)
+	}
+	if mtime().Equal(past) {
+		t.Error("force did not rewrite the file")
+	}
+
+	// Modified on disk: restored
+	os.WriteFile(path, []byte("xmrig binarY"), 0644)
+	if err := ExtractAssets(dest, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(binary) {
+		t.Errorf("modified file not restored, got %q", got)
+	}
+}
+
+func TestExtractAssetsRejectsBadManifest(t *testing.T) {
+	old := Assets
+	Assets = fstest.MapFS{
+		"bin/6.25.0/xmrig_linux_amd64": {Data: []byte("xmrig binary")},
+		"bin/checksums.json":           {Data: []byte(`{not json`)},
+		"configs/generic.json":         {Data: []byte(`{}`)},
+	}
+	resetChecksums(t)
+	t.Cleanup(func() { Assets = old })
+
+	err := ExtractAssets(t.TempDir(), false)
+	if err == nil || !strings.Contains(err.Error(), "invalid bin/checksums.json") {
+		t.Fatalf("ExtractAssets error = %v, want invalid manifest", err)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"runtime"
 	"strings"
 
+	"tarish/daemons"
 	"tarish/embedded"
+	"tarish/service"
 )
 
 const (
@@ -35,8 +37,17 @@ func getInstallPaths() (string, string, error) {
 	return binPath, sharePath, nil
 }
 
+// InstallOptions controls how Install behaves
+type InstallOptions struct {
+	// DryRun only prints every directory, file and permission change
+	DryRun bool
+	// Force re-extracts every embedded asset even if the file on disk is
+	// identical (e.g. to repair a corrupted install)
+	Force bool
+}
+
 // Install installs tarish to the system
-func Install() error {
+func Install(opts InstallOptions) error {
 	binPath, sharePath, err := getInstallPaths()
 	if err != nil {
 		return err
@@ -47,7 +58,11 @@ func Install() error {
 	if isRoot {
 		mode = "System"
 	}
-	fmt.Printf("Installing tarish (%s-wide)...\n", mode)
+	if opts.DryRun {
+		fmt.Printf("Dry run: installing tarish (%s-wide) would:\n", mode)
+	} else {
+		fmt.Printf("Installing tarish (%s-wide)...\n", mode)
+	}
 
 	// Get current executable path
 	execPath, err := os.Executable()
@@ -61,6 +76,10 @@ func Install() error {
 		return fmt.Errorf("failed to resolve path: %w", err)
 	}
 
+	if opts.DryRun {
+		return printInstallPlan(execPath, binPath, sharePath, isRoot)
+	}
+
 	// Create bin directory if it doesn't exist
 	if err := os.MkdirAll(binPath, 0755); err != nil {
 		return fmt.Errorf("failed to create bin directory: %w", err)
@@ -87,7 +106,7 @@ func Install() error {
 
 	// Extract embedded assets (xmrig binaries and configs)
 	fmt.Println("  Extracting embedded assets...")
-	if err := embedded.ExtractAssets(sharePath); err != nil {
+	if err := embedded.ExtractAssets(sharePath, opts.Force); err != nil {
 		return fmt.Errorf("failed to extract assets: %w", err)
 	}
 
@@ -148,8 +167,56 @@ func Install() error {
 	return nil
 }
 
+// printInstallPlan lists what Install would do without touching the filesystem
+func printInstallPlan(execPath, binPath, sharePath string, isRoot bool) error {
+	fmt.Printf("  mkdir  %s (0755)\n", binPath)
+	fmt.Printf("  mkdir  %s (0755)\n", sharePath)
+
+	destBinary := filepath.Join(binPath, binaryName)
+	if execPath != destBinary {
+		fmt.Printf("  copy   %s -> %s\n", execPath, destBinary)
+	} else {
+		fmt.Printf("  keep   %s (already installed)\n", destBinary)
+	}
+	fmt.Printf("  chmod  %s (0755)\n", destBinary)
+
+	assets, err := embedded.ListAssets()
+	if err != nil {
+		return fmt.Errorf("failed to list embedded assets: %w", err)
+	}
+	for _, asset := range assets {
+		dest := filepath.Join(sharePath, asset)
+		fmt.Printf("  write  %s (0644)\n", dest)
+		if strings.HasPrefix(asset, "bin/") {
+			fmt.Printf("  chmod  %s (0755)\n", dest)
+		}
+	}
+
+	logPerm := "0755"
+	if isRoot {
+		logPerm = "0777"
+	}
+	fmt.Printf("  mkdir  %s (%s)\n", filepath.Join(sharePath, "log"), logPerm)
+
+	if home, _ := os.UserHomeDir(); home != "" {
+		fmt.Printf("  mkdir  %s (0755)\n", filepath.Join(home, ".tarish"))
+	}
+
+	fmt.Println("\nNothing was changed (dry run)")
+	return nil
+}
+
+// UninstallOptions selects what Uninstall leaves in place
+type UninstallOptions struct {
+	// KeepConfigs preserves <share>/configs (including custom configs)
+	KeepConfigs bool
+	// KeepData preserves ~/.tarish and the settings, logs and state files
+	// in the share directory
+	KeepData bool
+}
+
 // Uninstall removes tarish from the system
-func Uninstall() error {
+func Uninstall(opts UninstallOptions) error {
 	binPath, sharePath, err := getInstallPaths()
 	if err != nil {
 		return err
@@ -159,6 +226,7 @@ func Uninstall() error {
 
 	// Stop any running processes first
 	fmt.Println("  Stopping running processes...")
+	daemons.StopAll()
 	stopXmrig()
 
 	// Disable service if enabled
@@ -174,16 +242,79 @@ func Uninstall() error {
 	}
 
 	// Remove share directory
-	if err := os.RemoveAll(sharePath); err != nil && !os.IsNotExist(err) {
-		fmt.Printf("  Warning: failed to remove share directory: %v\n", err)
+	var kept []string
+	if !opts.KeepConfigs && !opts.KeepData {
+		if err := os.RemoveAll(sharePath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("  Warning: failed to remove share directory: %v\n", err)
+		} else {
+			fmt.Printf("  Removed %s\n", sharePath)
+		}
 	} else {
-		fmt.Printf("  Removed %s\n", sharePath)
+		kept = removeShareContents(sharePath, opts)
+	}
+
+	// Remove data directory
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		dataDir := filepath.Join(home, ".tarish")
+		if _, err := os.Stat(dataDir); err == nil {
+			if opts.KeepData {
+				kept = append(kept, dataDir)
+			} else if err := os.RemoveAll(dataDir); err != nil {
+				fmt.Printf("  Warning: failed to remove data directory: %v\n", err)
+			} else {
+				fmt.Printf("  Removed %s\n", dataDir)
+			}
+		}
 	}
 
 	fmt.Println("\nUninstallation complete!")
+	if len(kept) > 0 {
+		fmt.Println("Preserved:")
+		for _, path := range kept {
+			fmt.Printf("  %s\n", path)
+		}
+	}
 	return nil
 }
 
+// removeShareContents removes the share directory entry by entry, skipping
+// configs and/or data as requested. Returns the paths that were kept.
+func removeShareContents(sharePath string, opts UninstallOptions) []string {
+	entries, err := os.ReadDir(sharePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("  Warning: failed to read share directory: %v\n", err)
+		}
+		return nil
+	}
+
+	var kept []string
+	for _, e := range entries {
+		path := filepath.Join(sharePath, e.Name())
+		switch {
+		case e.Name() == "bin":
+			// xmrig binaries are always removed
+		case e.Name() == "configs":
+			if opts.KeepConfigs {
+				kept = append(kept, path)
+				continue
+			}
+		case opts.KeepData:
+			// settings, logs, PID and queue files
+			kept = append(kept, path)
+			continue
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			fmt.Printf("  Warning: failed to remove %s: %v\n", path, err)
+		} else {
+			fmt.Printf("  Removed %s\n", path)
+		}
+	}
+	return kept
+}
+
 // Helper: check if path is in PATH
 func contains(pathEnv, target string) bool {
 	for _, p := range filepath.SplitList(pathEnv) {
@@ -220,11 +351,15 @@ func stopXmrig() {
 	}
 }
 
-// disableService disables the auto-start service
+// disableService removes the auto-start service so a reboot doesn't try to
+// launch a binary that no longer exists. Does nothing if none is installed.
 func disableService() {
-	// This functionality is now handled in service/service.go
-	// But we keep basic cleanup here just in case
-	// Proper disable should be done via 'tarish service disable' before uninstall
+	if !service.IsInstalled() {
+		return
+	}
+	if err := service.Disable(); err != nil {
+		fmt.Printf("  Warning: failed to disable service: %v\n", err)
+	}
 }
 
 // execCmd runs a command silently
@@ -0,0 +1,54 @@
+// Package logging builds the slog loggers used by tarish's background
+// daemons. Level and format come from tarish.json (log_level, log_format).
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"tarish/config"
+)
+
+// New returns a logger writing to w that tags every line with
+// component=<component> (e.g. "agent", "update-daemon"). An invalid
+// log_level falls back to info; log_format "json" selects JSON lines,
+// anything else key=value text.
+func New(w io.Writer, component string) *slog.Logger {
+	cfg := config.Load()
+	level, err := ParseLevel(cfg.LogLevel)
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	return newLogger(w, component, level, cfg.LogFormat)
+}
+
+func newLogger(w io.Writer, component string, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(handler).With("component", component)
+}
+
+// ParseLevel parses debug, info, warn or error (case-insensitive).
+// An empty string is info.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelInfo, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewLoggerFormats(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&buf, "agent", slog.LevelInfo, "json")
+	logger.Debug("hidden")
+	logger.Info("report ok", "hashrate", 1234.5)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1 (debug should be filtered): %q", len(lines), buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("not JSON: %v", err)
+	}
+	if entry["component"] != "agent" || entry["msg"] != "report ok" || entry["level"] != "INFO" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+
+	buf.Reset()
+	newLogger(&buf, "update-daemon", slog.LevelDebug, "").Debug("checking")
+	if out := buf.String(); !strings.Contains(out, "component=update-daemon") || !strings.Contains(out, "level=DEBUG") {
+		t.Errorf("text output = %q", out)
+	}
+}
@@ -3,17 +3,31 @@ package main
 import (
 	"bufio"
 	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"tarish/activity"
 	"tarish/agent"
+	"tarish/antisleep"
 	"tarish/config"
 	"tarish/cpu"
+	"tarish/daemons"
 	"tarish/embedded"
 	"tarish/install"
+	"tarish/optimize"
+	"tarish/power"
+	"tarish/schedule"
 	"tarish/service"
 	"tarish/update"
+	"tarish/watchdog"
 	"tarish/xmrig"
 )
 
@@ -30,6 +44,9 @@ func main() {
 	// Set version for update package
 	update.Version = Version
 
+	// --verbose is global: accept it anywhere and hide it from the handlers
+	os.Args, xmrig.Verbose = stripVerboseFlag(os.Args)
+
 	if len(os.Args) < 2 {
 		printHelp()
 		os.Exit(0)
@@ -47,6 +64,29 @@ func main() {
 		agent.Version = Version
 		agent.RunDaemon()
 		return
+	case "_schedule-daemon":
+		// Hidden internal command: pauses mining outside scheduled windows.
+		schedule.RunDaemon()
+		return
+	case "_activity-daemon":
+		// Hidden internal command: pauses mining while the user is active.
+		resumeAfter := activity.DefaultResumeAfter
+		if len(os.Args) > 2 {
+			if secs, err := strconv.Atoi(os.Args[2]); err == nil && secs > 0 {
+				resumeAfter = time.Duration(secs) * time.Second
+			}
+		}
+		activity.RunDaemon(resumeAfter)
+		return
+	case "_watchdog-daemon":
+		// Hidden internal command: restarts xmrig when it stalls at 0 H/s.
+		opts, err := watchdog.ParseDaemonArgs(os.Args[2:])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		watchdog.RunDaemon(opts)
+		return
 	}
 
 	// If auto-update is enabled, apply updates opportunistically on any
@@ -70,10 +110,22 @@ func main() {
 		handleUninstall()
 	case "update", "u":
 		handleUpdate()
+	case "autoupdate":
+		handleAutoUpdate()
+	case "schedule":
+		handleSchedule()
+	case "tune":
+		handleTune()
+	case "optimize":
+		handleOptimize()
 	case "start", "st":
 		handleStart()
 	case "stop", "sp":
 		handleStop()
+	case "pause":
+		handlePause()
+	case "resume":
+		handleResume()
 	case "status":
 		handleStatus()
 	case "service":
@@ -82,6 +134,24 @@ func main() {
 		handleTLS()
 	case "server":
 		handleServer()
+	case "name":
+		handleName()
+	case "tag":
+		handleTag()
+	case "worker-id":
+		handleWorkerID()
+	case "agent":
+		handleAgent()
+	case "config":
+		handleConfig()
+	case "export-config":
+		handleExportConfig()
+	case "import-config":
+		handleImportConfig()
+	case "api":
+		handleAPI()
+	case "doctor":
+		handleDoctor()
 	case "help", "h", "-h", "--help":
 		printHelp()
 	case "version", "v", "-v", "--version":
@@ -96,13 +166,33 @@ func main() {
 }
 
 func handleInstall() {
-	if err := install.Install(); err != nil {
+	var opts install.InstallOptions
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--dry-run", "-n":
+			opts.DryRun = true
+		case "--force", "-f":
+			opts.Force = true
+		}
+	}
+
+	if err := install.Install(opts); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func handleUninstall() {
+	var opts install.UninstallOptions
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--keep-configs":
+			opts.KeepConfigs = true
+		case "--keep-data":
+			opts.KeepData = true
+		}
+	}
+
 	fmt.Print("Are you sure you want to uninstall tarish? [y/N]: ")
 	reader := bufio.NewReader(os.Stdin)
 	response, _ := reader.ReadString('\n')
@@ -113,7 +203,7 @@ func handleUninstall() {
 		return
 	}
 
-	if err := install.Uninstall(); err != nil {
+	if err := install.Uninstall(opts); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -125,43 +215,33 @@ func handleUpdate() {
 		sub := strings.ToLower(os.Args[2])
 		switch sub {
 		case "enable":
-			if err := config.SetAutoUpdate(true); err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
-			}
-			fmt.Printf("Auto-update enabled (check every %dh)\n", config.DefaultCheckIntervalHrs)
-			// Start daemon immediately so it begins checking
-			if err := update.StartDaemon(); err != nil {
-				fmt.Printf("Warning: failed to start auto-update daemon: %v\n", err)
-			} else {
-				fmt.Println("Auto-update daemon started")
-			}
+			enableAutoUpdate()
 			return
 		case "disable":
-			update.StopDaemon()
-			if err := config.SetAutoUpdate(false); err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
-			}
-			fmt.Println("Auto-update disabled (daemon stopped)")
+			disableAutoUpdate()
 			return
 		case "status":
-			fmt.Printf("Auto-update: %s\n", config.FormatStatus())
-			if _, running := update.IsDaemonRunning(); running {
-				fmt.Println("Daemon:      running")
-			} else if config.IsAutoUpdateEnabled() {
-				fmt.Println("Daemon:      not running (will start on next 'tarish start')")
-			}
-			avail, latest, err := update.CheckForUpdates()
-			if err == nil && avail {
-				fmt.Printf("Update available: %s -> %s\n", update.GetCurrentVersion(), latest)
-			} else if err == nil {
-				fmt.Println("You are running the latest version")
-			}
+			printAutoUpdateStatus()
 			return
 		}
 	}
 
+	// tarish update --version <tag>: install a pinned release
+	for i := 2; i < len(os.Args); i++ {
+		if os.Args[i] != "--version" {
+			continue
+		}
+		if i+1 >= len(os.Args) {
+			fmt.Println("Usage: tarish update --version <version>")
+			os.Exit(1)
+		}
+		if err := update.UpdateToVersion(os.Args[i+1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Default: perform manual update
 	if err := update.Update(); err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -169,13 +249,388 @@ func handleUpdate() {
 	}
 }
 
+func handleOptimize() {
+	// tarish optimize [--apply|--revert]
+	green := "\033[32m"
+	red := "\033[31m"
+	gray := "\033[90m"
+	reset := "\033[0m"
+
+	apply, revert := false, false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--apply":
+			apply = true
+		case "--revert":
+			revert = true
+		}
+	}
+	if apply && revert {
+		fmt.Println("Error: --apply and --revert cannot be combined")
+		os.Exit(1)
+	}
+
+	cpuInfo, err := cpu.Detect()
+	if err != nil {
+		fmt.Printf("Error detecting CPU: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case apply:
+		if err := optimize.Apply(cpuInfo.Cores); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Kernel settings applied (undo with 'sudo tarish optimize --revert')")
+	case revert:
+		if err := optimize.Revert(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Kernel settings reverted")
+	}
+
+	checks, err := optimize.Status(cpuInfo.Cores)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n  %-18s %-26s %s\n", "Setting", "Current", "Recommended")
+	allOK := true
+	for _, c := range checks {
+		color := green
+		if !c.OK {
+			color = red
+			allOK = false
+		}
+		fmt.Printf("  %-18s %s%-26s%s %s\n", c.Name, color, c.Current, reset, c.Recommended)
+	}
+	fmt.Printf("\n  %sxmrig applies its RandomX MSR mod at start when it runs as root%s\n", gray, reset)
+	if !allOK && !apply {
+		fmt.Printf("  %sRun 'sudo tarish optimize --apply' to configure, then restart mining%s\n", gray, reset)
+	}
+}
+
+func handleTune() {
+	// tarish tune --threads-hint <pct> | --auto
+	hint := -1
+	auto := false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		value := ""
+		switch arg := args[i]; {
+		case arg == "--auto":
+			auto = true
+			continue
+		case arg == "--threads-hint" && i+1 < len(args):
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--threads-hint="):
+			value = strings.TrimPrefix(arg, "--threads-hint=")
+		default:
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || n < 0 || n > 100 {
+			fmt.Printf("Error: invalid threads hint %q (expected 0-100)\n", value)
+			os.Exit(1)
+		}
+		hint = n
+	}
+	if auto && hint >= 0 {
+		fmt.Println("Error: use either --auto or --threads-hint, not both")
+		os.Exit(1)
+	}
+	if auto {
+		cpuInfo, err := cpu.Detect()
+		if err != nil {
+			fmt.Printf("Error detecting CPU: %v\n", err)
+			os.Exit(1)
+		}
+		threads := cpu.SuggestThreads(cpuInfo)
+		hint = threadsHint(threads, cpuInfo.Threads)
+		fmt.Printf("Recommended threads: %d of %d\n", threads, cpuInfo.Threads)
+	}
+	if hint < 0 {
+		fmt.Println("Usage: tarish tune --threads-hint <0-100>")
+		fmt.Println("  Percentage of CPU threads xmrig may use; lower runs cooler and quieter")
+		fmt.Println("       tarish tune --auto")
+		fmt.Println("  Use the thread count recommended for this CPU's cores and L3 cache")
+		os.Exit(1)
+	}
+
+	configPath, _, err := xmrig.GetConfigForCurrentSystem()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	result, err := xmrig.SetMaxThreadsHint(configPath, hint)
+	if err != nil {
+		fmt.Printf("Error: %v (try sudo)\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Set cpu.max-threads-hint to %d%% in %s\n", hint, configPath)
+	printThreadsHintResult(result)
+
+	// Apply it to the running miner too, the same way server overrides are
+	if _, running := xmrig.IsRunning(); !running {
+		fmt.Println("  Takes effect on the next 'tarish start'")
+		return
+	}
+	if _, err := xmrig.SetLiveMaxThreadsHint(configPath, hint); err != nil {
+		fmt.Printf("Warning: could not apply to the running miner: %v\n", err)
+		fmt.Println("  Restart mining for it to take effect: tarish start --force")
+		return
+	}
+	fmt.Println("  Applied to the running miner")
+}
+
+// printThreadsHintResult explains a hint applied to an explicit cpu.rx
+// list, which xmrig would otherwise ignore the hint for
+func printThreadsHintResult(result *xmrig.ThreadsHintResult) {
+	if result.Of == 0 {
+		return
+	}
+	if result.Threads == result.Was {
+		fmt.Printf("  The profile already runs %d of its %d cpu.rx threads: nothing changed\n", result.Threads, result.Of)
+		return
+	}
+	fmt.Printf("  The profile lists cpu.rx threads explicitly: kept %d of %d\n", result.Threads, result.Of)
+}
+
+func handleSchedule() {
+	// tarish schedule <list|add <HH:MM-HH:MM>|remove <HH:MM-HH:MM>|clear>
+	sub := "list"
+	if len(os.Args) >= 3 {
+		sub = strings.ToLower(os.Args[2])
+	}
+
+	switch sub {
+	case "list", "status":
+		windows := config.GetSchedule()
+		if len(windows) == 0 {
+			fmt.Println("No mining schedule: mining runs at any time")
+			fmt.Println("  Add a window with: tarish schedule add 23:00-07:00")
+			return
+		}
+		fmt.Println("Mining windows (local time):")
+		for _, w := range windows {
+			fmt.Printf("  %s\n", w)
+		}
+		if _, running := schedule.IsDaemonRunning(); running {
+			fmt.Println("Schedule daemon: running")
+		} else {
+			fmt.Println("Schedule daemon: not running (starts with 'tarish start')")
+		}
+	case "add":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: tarish schedule add <HH:MM-HH:MM>")
+			os.Exit(1)
+		}
+		w, err := schedule.Add(os.Args[3])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added mining window %s\n", w)
+		// The daemon re-reads the schedule each cycle; start it if mining
+		// is already running without one
+		if _, running := xmrig.IsRunning(); running {
+			if err := schedule.StartDaemon(); err != nil {
+				fmt.Printf("Warning: failed to start schedule daemon: %v\n", err)
+			}
+		}
+	case "remove", "rm":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: tarish schedule remove <HH:MM-HH:MM>")
+			os.Exit(1)
+		}
+		if err := schedule.Remove(os.Args[3]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed mining window %s\n", os.Args[3])
+	case "clear":
+		if err := schedule.Clear(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// The daemon notices the empty schedule, resumes mining and exits
+		fmt.Println("Mining schedule cleared")
+	default:
+		fmt.Printf("Unknown schedule command: %s\n", sub)
+		fmt.Println("Usage: tarish schedule <list|add <HH:MM-HH:MM>|remove <HH:MM-HH:MM>|clear>")
+		os.Exit(1)
+	}
+}
+
+func handleAutoUpdate() {
+	// tarish autoupdate <on|off|status|interval <hours>>
+	sub := "status"
+	if len(os.Args) >= 3 {
+		sub = strings.ToLower(os.Args[2])
+	}
+
+	switch sub {
+	case "on", "enable":
+		enableAutoUpdate()
+	case "off", "disable":
+		disableAutoUpdate()
+	case "status":
+		printAutoUpdateStatus()
+	case "interval":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: tarish autoupdate interval <hours>")
+			os.Exit(1)
+		}
+		hours, err := strconv.Atoi(os.Args[3])
+		if err != nil || hours <= 0 {
+			fmt.Printf("Error: invalid interval %q (expected a positive number of hours)\n", os.Args[3])
+			os.Exit(1)
+		}
+		if err := config.SetCheckInterval(hours); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// The daemon re-reads the interval each cycle, no restart needed
+		fmt.Printf("Update check interval set to %dh\n", hours)
+	default:
+		fmt.Printf("Unknown autoupdate command: %s\n", sub)
+		fmt.Println("Usage: tarish autoupdate <on|off|status|interval <hours>>")
+		os.Exit(1)
+	}
+}
+
+func enableAutoUpdate() {
+	if err := config.SetAutoUpdate(true); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Auto-update %s\n", config.FormatStatus())
+	// Start daemon immediately so it begins checking
+	if err := update.StartDaemon(); err != nil {
+		fmt.Printf("Warning: failed to start auto-update daemon: %v\n", err)
+	} else {
+		fmt.Println("Auto-update daemon started")
+	}
+}
+
+func disableAutoUpdate() {
+	update.StopDaemon()
+	if err := config.SetAutoUpdate(false); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Auto-update disabled (daemon stopped)")
+}
+
+func printAutoUpdateStatus() {
+	fmt.Printf("Auto-update: %s\n", config.FormatStatus())
+	if _, running := update.IsDaemonRunning(); running {
+		fmt.Println("Daemon:      running")
+	} else if config.IsAutoUpdateEnabled() {
+		fmt.Println("Daemon:      not running (will start on next 'tarish start')")
+	}
+	avail, latest, err := update.CheckForUpdates()
+	if err == nil && avail {
+		fmt.Printf("Update available: %s -> %s\n", update.GetCurrentVersion(), latest)
+	} else if err == nil {
+		fmt.Println("You are running the latest version")
+	}
+}
+
+// startValueFlags are the start options that take a value; a trailing one
+// without it is a usage error
+var startValueFlags = map[string]bool{
+	"--cpus":            true,
+	"--idle-seconds":    true,
+	"--watchdog-window": true,
+	"--sleep-mode":      true,
+	"--xmrig-version":   true,
+}
+
 func handleStart() {
-	// Check for --force flag
+	// Check for --force and --cpus flags
 	force := false
-	for _, arg := range os.Args[2:] {
-		if arg == "--force" || arg == "-f" {
+	strictWallet := false
+	pauseOnActivity := false
+	idleSeconds := int(activity.DefaultResumeAfter.Seconds())
+	useWatchdog := false
+	watchdogSeconds := int(watchdog.DefaultWindow.Seconds())
+	cpus := ""
+	sleepMode := antisleep.ModeFull
+	xmrigVersion := ""
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case i+1 == len(args) && startValueFlags[arg]:
+			fmt.Printf("Error: %s needs a value\n", arg)
+			os.Exit(1)
+		case arg == "--force" || arg == "-f":
 			force = true
-			break
+		case arg == "--strict-wallet":
+			strictWallet = true
+		case arg == "--pause-on-activity":
+			pauseOnActivity = true
+		case arg == "--idle-seconds" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 10 {
+				fmt.Println("Error: --idle-seconds must be a number of seconds (at least 10)")
+				os.Exit(1)
+			}
+			idleSeconds = n
+		case arg == "--watchdog":
+			useWatchdog = true
+		case arg == "--watchdog-window" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < int(watchdog.MinWindow.Seconds()) {
+				fmt.Printf("Error: --watchdog-window must be a number of seconds (at least %d)\n", int(watchdog.MinWindow.Seconds()))
+				os.Exit(1)
+			}
+			watchdogSeconds = n
+			useWatchdog = true
+		case arg == "--cpus" && i+1 < len(args):
+			i++
+			cpus = args[i]
+		case strings.HasPrefix(arg, "--cpus="):
+			cpus = strings.TrimPrefix(arg, "--cpus=")
+			if cpus == "" {
+				fmt.Println("Error: --cpus needs a value")
+				os.Exit(1)
+			}
+		case arg == "--sleep-mode" && i+1 < len(args):
+			i++
+			sleepMode = parseSleepMode(args[i])
+		case strings.HasPrefix(arg, "--sleep-mode="):
+			sleepMode = parseSleepMode(strings.TrimPrefix(arg, "--sleep-mode="))
+		case arg == "--xmrig-version" && i+1 < len(args):
+			i++
+			xmrigVersion = args[i]
+		case strings.HasPrefix(arg, "--xmrig-version="):
+			xmrigVersion = strings.TrimPrefix(arg, "--xmrig-version=")
+		}
+	}
+	if cpus != "" {
+		if _, err := xmrig.ParseCPUList(cpus); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Mining drains a laptop battery fast; ask first unless --force
+	if onBattery, err := power.OnBattery(); err == nil && onBattery && !force {
+		fmt.Println("Warning: running on battery power, mining will drain it quickly")
+		fmt.Print("Start mining anyway? [y/N]: ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+
+		if response != "y" && response != "yes" {
+			fmt.Println("Start cancelled (plug in, or use --force to skip this check)")
+			return
 		}
 	}
 
@@ -208,9 +663,13 @@ func handleStart() {
 
 	// Find config
 	configsPath := xmrig.GetInstalledConfigPath()
-	configPath, err := xmrig.SelectConfig(cpuInfo, configsPath)
+	selection, err := xmrig.SelectConfigDetailed(cpuInfo, configsPath)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
+		var notFound *xmrig.ConfigNotFoundError
+		if errors.As(err, &notFound) {
+			fmt.Printf("  Tried: %s\n", notFound.Tried())
+		}
 		fmt.Println("\nAvailable configs:")
 		configs, _ := xmrig.ListAvailableConfigs()
 		for _, c := range configs {
@@ -218,10 +677,39 @@ func handleStart() {
 		}
 		os.Exit(1)
 	}
+	configPath := selection.Path
 	fmt.Printf("  Config: %s\n", configPath)
+	if selection.Fallback {
+		fmt.Printf("  Warning: no tuned config for %s, using a fallback config\n", cpuInfo.Family)
+	}
+	if err := xmrig.SaveSelectedConfigName(configPath, selection.Fallback); err != nil {
+		fmt.Printf("Warning: failed to record selected config: %v\n", err)
+	}
 
-	// Find binary
-	binaryInfo, err := xmrig.GetInstalledBinaryPath()
+	// A mistyped wallet mines to nowhere; usernames are legitimate on some
+	// pools though, so this only blocks the start with --strict-wallet
+	if cfg, err := xmrig.LoadConfig(configPath); err == nil {
+		walletErrs := xmrig.ValidateWallets(cfg)
+		for _, werr := range walletErrs {
+			if strictWallet {
+				fmt.Printf("Error: %v\n", werr)
+			} else {
+				fmt.Printf("  Warning: %v\n", werr)
+			}
+		}
+		if strictWallet && len(walletErrs) > 0 {
+			fmt.Println("Refusing to start with --strict-wallet; fix the pool user in the config")
+			os.Exit(1)
+		}
+	}
+
+	// Find binary (a specific version if --xmrig-version was given)
+	var binaryInfo *xmrig.BinaryInfo
+	if xmrigVersion != "" {
+		binaryInfo, err = xmrig.GetInstalledBinaryVersion(xmrigVersion)
+	} else {
+		binaryInfo, err = xmrig.GetInstalledBinaryPath()
+	}
 	if err != nil {
 		fmt.Printf("Error finding xmrig binary: %v\n", err)
 		os.Exit(1)
@@ -237,6 +725,11 @@ func handleStart() {
 
 	// Prepare runtime config with api.id and worker-id
 	runtimeConfigPath, err := xmrig.PrepareRuntimeConfig(configPath, cpuInfo)
+	var tmplErr *xmrig.TemplateError
+	if errors.As(err, &tmplErr) {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Printf("Warning: Failed to prepare runtime config, using original: %v\n", err)
 		runtimeConfigPath = configPath
@@ -246,7 +739,8 @@ func handleStart() {
 
 	// Start xmrig
 	fmt.Println("\nStarting xmrig...")
-	if err := xmrig.Start(binaryInfo.Path, runtimeConfigPath, force); err != nil {
+	startOpts := xmrig.StartOptions{Force: force, CPUs: cpus, SleepMode: sleepMode}
+	if err := xmrig.StartWithOptions(binaryInfo.Path, runtimeConfigPath, startOpts); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -256,6 +750,45 @@ func handleStart() {
 		fmt.Printf("Warning: failed to start agent daemon: %v\n", err)
 	}
 
+	// Enforce the mining schedule, if one is configured
+	if len(config.GetSchedule()) > 0 {
+		if err := schedule.StartDaemon(); err != nil {
+			fmt.Printf("Warning: failed to start schedule daemon: %v\n", err)
+		} else {
+			fmt.Printf("Mining schedule: %s\n", strings.Join(config.GetSchedule(), ", "))
+		}
+	}
+
+	// Pause while the user is at the machine; a plain start turns it off
+	if pauseOnActivity {
+		resumeAfter := time.Duration(idleSeconds) * time.Second
+		if err := activity.StartDaemon(resumeAfter); err != nil {
+			fmt.Printf("Warning: failed to start pause-on-activity: %v\n", err)
+		} else {
+			fmt.Printf("Pause-on-activity enabled (resumes after %v idle)\n", resumeAfter)
+		}
+	} else {
+		activity.StopDaemon()
+	}
+
+	// Restart xmrig if it stalls at 0 H/s; a plain start turns it off
+	if useWatchdog {
+		wdOpts := watchdog.Options{
+			Window:     time.Duration(watchdogSeconds) * time.Second,
+			BinaryPath: binaryInfo.Path,
+			ConfigPath: runtimeConfigPath,
+			CPUs:       cpus,
+			SleepMode:  sleepMode,
+		}
+		if err := watchdog.StartDaemon(wdOpts); err != nil {
+			fmt.Printf("Warning: failed to start watchdog: %v\n", err)
+		} else {
+			fmt.Printf("Watchdog enabled (restarts xmrig after %v at 0 H/s)\n", wdOpts.Window)
+		}
+	} else {
+		watchdog.StopDaemon()
+	}
+
 	// Start auto-update daemon if enabled
 	if config.IsAutoUpdateEnabled() {
 		if err := update.StartDaemon(); err != nil {
@@ -266,12 +799,18 @@ func handleStart() {
 	}
 }
 
-func handleStop() {
-	// Stop agent daemon
-	agent.StopDaemon()
+// parseSleepMode parses --sleep-mode, exiting on a bad value
+func parseSleepMode(name string) antisleep.Mode {
+	mode, err := antisleep.ParseMode(name)
+	if err != nil {
+		fmt.Printf("Error: --sleep-mode: %v\n", err)
+		os.Exit(1)
+	}
+	return mode
+}
 
-	// Stop auto-update daemon
-	update.StopDaemon()
+func handleStop() {
+	daemons.StopAll()
 
 	if err := xmrig.Stop(); err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -279,7 +818,48 @@ func handleStop() {
 	}
 }
 
+func handlePause() {
+	if _, running := xmrig.IsRunning(); !running {
+		fmt.Println("xmrig is not running")
+		os.Exit(1)
+	}
+	if err := xmrig.PauseFor(xmrig.PauseManual); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Mining paused (xmrig stays connected; 'tarish resume' to continue)")
+}
+
+func handleResume() {
+	if _, running := xmrig.IsRunning(); !running {
+		fmt.Println("xmrig is not running (use 'tarish start')")
+		os.Exit(1)
+	}
+	held, err := xmrig.ResumeFor(xmrig.PauseManual)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(held) > 0 {
+		fmt.Printf("Mining stays paused by: %s\n", xmrig.JoinPauseReasons(held))
+		return
+	}
+	fmt.Println("Mining resumed")
+}
+
 func handleStatus() {
+	// tarish status --remote <miner-id>: show a miner's state from the server
+	for i, arg := range os.Args[2:] {
+		if arg == "--remote" || arg == "-r" {
+			if i+3 >= len(os.Args) {
+				fmt.Println("Usage: tarish status --remote <miner-id>")
+				os.Exit(1)
+			}
+			handleRemoteStatus(os.Args[i+3])
+			return
+		}
+	}
+
 	// ANSI color codes
 	cyan := "\033[36m"
 	yellow := "\033[33m"
@@ -365,9 +945,66 @@ func handleStatus() {
 	fmt.Println()
 }
 
-func handleService() {
+func handleRemoteStatus(minerID string) {
+	// ANSI color codes
+	cyan := "\033[36m"
+	yellow := "\033[33m"
+	green := "\033[32m"
+	red := "\033[31m"
+	gray := "\033[90m"
+	bold := "\033[1m"
+	reset := "\033[0m"
+
+	miner, err := agent.FetchRemoteMiner(minerID)
+	if err != nil {
+		fmt.Printf("%sError: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n%s%s=== Tarish Status (%s) ===%s\n\n", bold, cyan, miner.ID, reset)
+
+	statusColor := red
+	switch miner.Status {
+	case "online":
+		statusColor = green
+	case "stale":
+		statusColor = yellow
+	}
+	fmt.Printf("  %sStatus:           %s%s%s%s%s %s(last seen %s ago)%s\n",
+		yellow, reset, bold, statusColor, strings.ToUpper(miner.Status), reset,
+		gray, xmrig.FormatDuration(time.Since(miner.LastSeen)), reset)
+	if miner.Name != "" {
+		fmt.Printf("  %sName:             %s%s%s%s\n", yellow, reset, bold, miner.Name, reset)
+	}
+	fmt.Printf("  %sHost:             %s%s%s%s %s(%s)%s\n",
+		yellow, reset, cyan, miner.Hostname, reset, gray, miner.IP, reset)
+	fmt.Printf("  %sCPU:              %s%s %s(%s, %d cores, %s/%s)%s\n",
+		yellow, reset, miner.CPUModel, gray, miner.CPUFamily, miner.Cores, miner.OS, miner.Arch, reset)
+
+	if miner.XmrigVersion != "" {
+		fmt.Printf("  %sVersion:          %s%s%s%s %s(tarish %s)%s\n",
+			yellow, reset, cyan, miner.XmrigVersion, reset, gray, miner.TarishVersion, reset)
+	}
+
+	if miner.UptimeSeconds > 0 {
+		fmt.Printf("  %sUptime:           %s%s%s%s\n",
+			yellow, reset, green, xmrig.FormatDuration(time.Duration(miner.UptimeSeconds)*time.Second), reset)
+	}
+
+	if miner.Hashrate != nil {
+		fmt.Printf("  %sHashrate:         %s%s%s%.2f H/s%s %s(10s)%s | %s%.2f H/s%s %s(60s)%s | %s%.2f H/s%s %s(max)%s\n",
+			yellow, reset,
+			bold, green, miner.Hashrate.Current, reset, gray, reset,
+			green, miner.Hashrate.Average, reset, gray, reset,
+			green, miner.Hashrate.Max, reset, gray, reset)
+	}
+
+	fmt.Println()
+}
+
+func handleService() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: tarish service <enable|disable|status>")
+		fmt.Println("Usage: tarish service <enable|disable|start|stop|restart|status>")
 		os.Exit(1)
 	}
 
@@ -375,15 +1012,35 @@ func handleService() {
 
 	switch subcommand {
 	case "enable":
-		if err := service.Enable(); err != nil {
+		opts, err := parseServiceOptions(os.Args[3:])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := service.EnableWithOptions(opts); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-	case "disable", "stop":
+	case "disable":
 		if err := service.Disable(); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
+	case "start":
+		if err := service.Start(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "stop":
+		if err := service.Stop(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "restart":
+		if err := service.Restart(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case "status":
 		enabled, err := service.IsEnabled()
 		if err != nil {
@@ -397,11 +1054,44 @@ func handleService() {
 		}
 	default:
 		fmt.Printf("Unknown service command: %s\n", subcommand)
-		fmt.Println("Usage: tarish service <enable|disable|status>")
+		fmt.Println("Usage: tarish service <enable|disable|start|stop|restart|status>")
 		os.Exit(1)
 	}
 }
 
+// parseServiceOptions reads the resource-limit flags for 'service enable'
+func parseServiceOptions(args []string) (service.Options, error) {
+	var opts service.Options
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--cpu-quota", "--nice":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, fmt.Errorf("%s requires a value", name)
+				}
+				i++
+				value = args[i]
+			}
+			if name == "--cpu-quota" {
+				opts.CPUQuota = value
+			} else {
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return opts, fmt.Errorf("invalid nice value %q", value)
+				}
+				opts.Nice = n
+			}
+		case "--idle-io":
+			opts.IdleIO = true
+		default:
+			return opts, fmt.Errorf("unknown option: %s", arg)
+		}
+	}
+	return opts, nil
+}
+
 func handleTLS() {
 	if len(os.Args) < 3 {
 		fmt.Printf("TLS xmrig-proxy: %s\n", config.FormatTLSStatus())
@@ -435,6 +1125,483 @@ func handleTLS() {
 	}
 }
 
+func handleConfig() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: tarish config <edit|render|set|validate>")
+		os.Exit(1)
+	}
+
+	sub := strings.ToLower(os.Args[2])
+	switch sub {
+	case "edit":
+		handleConfigEdit()
+	case "render":
+		handleConfigRender()
+	case "set":
+		handleConfigSet()
+	case "validate":
+		handleConfigValidate()
+	default:
+		fmt.Printf("Unknown config command: %s\n", sub)
+		fmt.Println("Usage: tarish config <edit|render|set|validate>")
+		os.Exit(1)
+	}
+}
+
+func handleConfigRender() {
+	// tarish config render [template] [--wallet W] [--worker W] [--pool P] [-o file]
+	vars := xmrig.DefaultTemplateVars()
+	templatePath, outputPath := "", ""
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			templatePath = arg
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				fmt.Printf("Error: %s needs a value\n", arg)
+				os.Exit(1)
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "--wallet":
+			vars.Wallet = value
+		case "--worker":
+			vars.Worker = value
+		case "--pool":
+			vars.Pool = value
+		case "-o", "--output":
+			outputPath = value
+		default:
+			fmt.Printf("Unknown option: %s\n", name)
+			fmt.Println("Usage: tarish config render [template] [--wallet W] [--worker W] [--pool P] [-o file]")
+			os.Exit(1)
+		}
+	}
+
+	if templatePath == "" {
+		var err error
+		templatePath, _, err = xmrig.GetConfigForCurrentSystem()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	rendered, err := xmrig.RenderConfigTemplate(templatePath, vars)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if outputPath == "" {
+		os.Stdout.Write(rendered)
+		return
+	}
+	if err := os.WriteFile(outputPath, rendered, 0644); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Rendered %s to %s\n", templatePath, outputPath)
+}
+
+func handleConfigSet() {
+	// tarish config set <wallet|worker|pool> [value]
+	if len(os.Args) < 4 {
+		vars := xmrig.DefaultTemplateVars()
+		fmt.Printf("wallet: %s\n", vars.Wallet)
+		fmt.Printf("worker: %s\n", vars.Worker)
+		fmt.Printf("pool:   %s\n", vars.Pool)
+		fmt.Println("\nUsage: tarish config set <wallet|worker|pool> [value]")
+		fmt.Println("  Values for ${WALLET}, ${WORKER} and ${POOL} in config templates; no value clears it")
+		return
+	}
+
+	name := strings.ToLower(os.Args[3])
+	value := ""
+	if len(os.Args) > 4 {
+		value = os.Args[4]
+	}
+	if err := config.SetTemplateValue(name, value); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if value == "" {
+		fmt.Printf("Cleared %s\n", name)
+		return
+	}
+	fmt.Printf("Set %s to %s\n", name, value)
+	if name == "wallet" {
+		if err := xmrig.ValidateWallet(value); err != nil {
+			fmt.Printf("  Warning: %v\n", err)
+		}
+	}
+}
+
+// configBundle is what export-config writes and import-config reads
+type configBundle struct {
+	Version         int             `json:"version"`
+	ExportedAt      string          `json:"exported_at"`
+	Tarish          *config.Config  `json:"tarish"`
+	XmrigConfigName string          `json:"xmrig_config_name,omitempty"`
+	XmrigConfig     json.RawMessage `json:"xmrig_config,omitempty"`
+}
+
+const configBundleVersion = 1
+
+func handleExportConfig() {
+	// tarish export-config [-o file] [--redact]
+	outputPath, redact := "", false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--redact":
+			redact = true
+		case "-o", "--output":
+			if i+1 >= len(args) {
+				fmt.Printf("Error: %s needs a value\n", args[i])
+				os.Exit(1)
+			}
+			i++
+			outputPath = args[i]
+		default:
+			fmt.Printf("Unknown option: %s\n", args[i])
+			fmt.Println("Usage: tarish export-config [-o file] [--redact]")
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := config.Export(redact)
+	if err != nil {
+		fmt.Printf("Error: %v (use --redact to leave the agent key out)\n", err)
+		os.Exit(1)
+	}
+	bundle := configBundle{
+		Version:    configBundleVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Tarish:     cfg,
+	}
+	// Config selection reports on-demand extraction on stderr, so it stays
+	// out of a bundle going to stdout
+	name, data, err := xmrig.ExportSelectedConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: xmrig config not included: %v\n", err)
+	} else if !json.Valid(data) {
+		fmt.Fprintf(os.Stderr, "Warning: xmrig config %s not included: not valid JSON\n", name)
+	} else {
+		bundle.XmrigConfigName = name
+		bundle.XmrigConfig = data
+	}
+
+	out, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	out = append(out, '\n')
+
+	if outputPath == "" {
+		os.Stdout.Write(out)
+		return
+	}
+	// 0600: the bundle holds the agent key unless --redact
+	if err := os.WriteFile(outputPath, out, 0600); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported tarish config to %s\n", outputPath)
+	if bundle.XmrigConfigName != "" {
+		fmt.Printf("  xmrig config: %s\n", bundle.XmrigConfigName)
+	}
+	if !redact && cfg.ServerAgentKey != "" {
+		fmt.Println("  Includes the agent key in plain text; keep the file private")
+	}
+}
+
+func handleImportConfig() {
+	// tarish import-config <file|->
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: tarish import-config <file|->")
+		fmt.Println("  Restore a bundle written by 'tarish export-config' (- reads stdin)")
+		os.Exit(1)
+	}
+
+	var data []byte
+	var err error
+	if os.Args[2] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(os.Args[2])
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var bundle configBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		fmt.Printf("Error: not a tarish config export: %v\n", err)
+		os.Exit(1)
+	}
+	if bundle.Version != configBundleVersion || bundle.Tarish == nil {
+		fmt.Printf("Error: unsupported config export (version %d)\n", bundle.Version)
+		os.Exit(1)
+	}
+
+	// The xmrig config is validated before anything is written, so a bad
+	// bundle leaves this machine untouched
+	xmrigPath := ""
+	if len(bundle.XmrigConfig) > 0 {
+		xmrigPath, err = xmrig.ImportConfig(bundle.XmrigConfigName, bundle.XmrigConfig)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := config.Import(bundle.Tarish); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Imported tarish config exported %s\n", bundle.ExportedAt)
+	if bundle.Tarish.ServerURL != "" {
+		fmt.Printf("  Server: %s\n", bundle.Tarish.ServerURL)
+		if bundle.Tarish.ServerAgentKey == "" {
+			fmt.Println("  Agent key was redacted; set it with 'tarish server agent-key <key>'")
+		}
+	}
+	if xmrigPath != "" {
+		fmt.Printf("  xmrig config: %s\n", xmrigPath)
+		if selected, _, err := xmrig.GetConfigForCurrentSystem(); err == nil && filepath.Base(selected) != bundle.XmrigConfigName {
+			fmt.Printf("  Warning: this machine selects %s, so the imported config won't be used here\n", filepath.Base(selected))
+		}
+	}
+	fmt.Println("Restart mining to apply: tarish start --force")
+}
+
+func handleConfigEdit() {
+	configPath, _, err := xmrig.GetConfigForCurrentSystem()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+		if _, err := exec.LookPath(editor); err != nil {
+			editor = "nano"
+		}
+	}
+
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	backupPath := configPath + ".bak"
+	if err := os.WriteFile(backupPath, original, 0644); err != nil {
+		fmt.Printf("Error: cannot write backup (try sudo): %v\n", err)
+		os.Exit(1)
+	}
+
+	// $EDITOR may carry arguments (e.g. "code --wait")
+	editorArgs := strings.Fields(editor)
+	cmd := exec.Command(editorArgs[0], append(editorArgs[1:], configPath)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("Error: editor exited with error: %v\n", err)
+		fmt.Printf("Backup kept at %s\n", backupPath)
+		os.Exit(1)
+	}
+
+	cfg, err := xmrig.LoadConfig(configPath)
+	if err == nil {
+		err = xmrig.ValidateConfig(cfg)
+	}
+	if err == nil {
+		os.Remove(backupPath)
+		fmt.Printf("Config %s is valid\n", configPath)
+		for _, werr := range xmrig.ValidateWallets(cfg) {
+			fmt.Printf("  Warning: %v\n", werr)
+		}
+		fmt.Println("  Restart mining for changes to take effect: tarish start --force")
+		return
+	}
+
+	fmt.Printf("Error: %v\n", err)
+	fmt.Print("Revert to the previous version? [Y/n]: ")
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+
+	if response == "n" || response == "no" {
+		fmt.Printf("Keeping edited config; backup kept at %s\n", backupPath)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(configPath, original, 0644); err != nil {
+		fmt.Printf("Error: failed to revert (backup at %s): %v\n", backupPath, err)
+		os.Exit(1)
+	}
+	os.Remove(backupPath)
+	fmt.Println("Config reverted")
+	os.Exit(1)
+}
+
+// handleConfigValidate checks xmrig config files anywhere on disk, for
+// linting generated configs in CI. Exits 1 if any is invalid.
+func handleConfigValidate() {
+	paths := os.Args[3:]
+	if len(paths) == 0 {
+		fmt.Println("Usage: tarish config validate <file>...")
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, path := range paths {
+		cfg, err := xmrig.ValidateConfigFile(path)
+		if err != nil {
+			fmt.Printf("%s: %v\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: valid\n", path)
+		data, _ := os.ReadFile(path)
+		if xmrig.IsTemplate(data) {
+			continue // ${WALLET} isn't a wallet yet
+		}
+		for _, werr := range xmrig.ValidateWallets(cfg) {
+			fmt.Printf("  Warning: %v\n", werr)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func handleAPI() {
+	if len(os.Args) < 3 {
+		fmt.Printf("xmrig API bind: %s\n", config.FormatAPIBindStatus())
+		fmt.Println("\nUsage: tarish api <local|lan|rotate-token|status>")
+		return
+	}
+
+	sub := strings.ToLower(os.Args[2])
+	switch sub {
+	case "local":
+		if err := config.SetAPILANBind(false); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("xmrig API will bind to 127.0.0.1 only")
+		fmt.Println("  Restart mining for changes to take effect: tarish start --force")
+	case "lan":
+		if err := config.SetAPILANBind(true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("xmrig API will use the host from the selected config")
+		fmt.Println("  Warning: anyone who can reach the API port and knows the token can push configs")
+		fmt.Println("  Restart mining for changes to take effect: tarish start --force")
+	case "rotate-token":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: tarish api rotate-token <enable|disable>")
+			os.Exit(1)
+		}
+		mode := strings.ToLower(os.Args[3])
+		if mode != "enable" && mode != "disable" {
+			fmt.Println("Usage: tarish api rotate-token <enable|disable>")
+			os.Exit(1)
+		}
+		enabled := mode == "enable"
+		if err := config.SetAPITokenRotation(enabled); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if enabled {
+			fmt.Println("xmrig API access-token will be regenerated on every start")
+		} else {
+			fmt.Println("xmrig API access-token will be taken from the selected config")
+		}
+		fmt.Println("  Restart mining for changes to take effect: tarish start --force")
+	case "status":
+		fmt.Printf("xmrig API bind: %s\n", config.FormatAPIBindStatus())
+		if config.IsAPITokenRotationEnabled() {
+			fmt.Println("Token rotation: enabled")
+		} else {
+			fmt.Println("Token rotation: disabled")
+		}
+	default:
+		fmt.Printf("Unknown api command: %s\n", sub)
+		fmt.Println("Usage: tarish api <local|lan|rotate-token|status>")
+		os.Exit(1)
+	}
+}
+
+func handleDoctor() {
+	yellow := "\033[33m"
+	green := "\033[32m"
+	red := "\033[31m"
+	bold := "\033[1m"
+	reset := "\033[0m"
+
+	problems := 0
+	warn := func(format string, args ...interface{}) {
+		problems++
+		fmt.Printf("  %s%s! %s%s\n", bold, red, fmt.Sprintf(format, args...), reset)
+	}
+	ok := func(format string, args ...interface{}) {
+		fmt.Printf("  %s✓%s %s\n", green, reset, fmt.Sprintf(format, args...))
+	}
+
+	fmt.Printf("\n%s=== Tarish Doctor ===%s\n\n", bold, reset)
+
+	// xmrig HTTP API exposure: the selected config is what ships on disk,
+	// the runtime config is what xmrig was actually started with.
+	fmt.Printf("%sxmrig API%s\n", yellow, reset)
+	if configPath, _, err := xmrig.GetConfigForCurrentSystem(); err == nil {
+		if cfg, err := xmrig.LoadConfig(configPath); err == nil && cfg.HTTP != nil && cfg.HTTP.Enabled {
+			if xmrig.IsPublicHTTPHost(cfg.HTTP.Host) {
+				if config.IsAPILANBindEnabled() {
+					warn("%s binds the API to %s:%d and LAN bind is enabled ('tarish api local' to restrict)",
+						filepath.Base(configPath), cfg.HTTP.Host, cfg.HTTP.Port)
+				} else {
+					ok("%s binds the API to %s, overridden to 127.0.0.1 at start",
+						filepath.Base(configPath), cfg.HTTP.Host)
+				}
+			} else {
+				ok("%s binds the API to %s", filepath.Base(configPath), cfg.HTTP.Host)
+			}
+		}
+	}
+
+	if cfg, err := xmrig.LoadConfig(xmrig.GetRuntimeConfigPath()); err == nil && cfg.HTTP != nil && cfg.HTTP.Enabled {
+		if xmrig.IsPublicHTTPHost(cfg.HTTP.Host) {
+			warn("running miner's API is reachable from the network on %s:%d", cfg.HTTP.Host, cfg.HTTP.Port)
+		} else {
+			ok("running miner's API is bound to %s:%d", cfg.HTTP.Host, cfg.HTTP.Port)
+		}
+		if cfg.HTTP.AccessToken == "" {
+			warn("running miner's API has no access-token set")
+		}
+	}
+
+	fmt.Println()
+	if problems == 0 {
+		fmt.Printf("%sNo problems found%s\n\n", green, reset)
+	} else {
+		fmt.Printf("%s%d problem(s) found%s\n\n", red, problems, reset)
+		os.Exit(1)
+	}
+}
+
 func handleServer() {
 	if len(os.Args) < 3 {
 		url := config.GetServerURL()
@@ -443,26 +1610,70 @@ func handleServer() {
 		} else {
 			fmt.Printf("Server URL: %s\n", url)
 		}
-		fmt.Println("\nUsage: tarish server <set|agent-key|status>")
-		fmt.Println("  tarish server set <url>          Set server URL")
-		fmt.Println("  tarish server agent-key <key>    Set agent key for server auth")
-		fmt.Println("  tarish server status             Show server config")
+		fmt.Println("\nUsage: tarish server <set|unset|show|agent-key|encrypt-key>")
+		fmt.Println("  tarish server set <url> [--key <k>]  Set server URL (and agent key), start the agent")
+		fmt.Println("  tarish server unset                  Remove server URL and agent key, stop the agent")
+		fmt.Println("  tarish server show                   Show server config and connection")
+		fmt.Println("  tarish server agent-key <key>        Set agent key for server auth")
+		fmt.Println("  tarish server encrypt-key            Encrypt the agent key at rest (decrypt-key to undo)")
 		return
 	}
 
 	sub := strings.ToLower(os.Args[2])
 	switch sub {
 	case "set":
-		if len(os.Args) < 4 {
-			fmt.Println("Usage: tarish server set <url>")
+		// tarish server set <url> [--key <agentkey>]
+		rawURL, key := "", ""
+		args := os.Args[3:]
+		for i := 0; i < len(args); i++ {
+			switch arg := args[i]; {
+			case arg == "--key" && i+1 < len(args):
+				i++
+				key = args[i]
+			case strings.HasPrefix(arg, "--key="):
+				key = strings.TrimPrefix(arg, "--key=")
+			case !strings.HasPrefix(arg, "-") && rawURL == "":
+				rawURL = arg
+			default:
+				fmt.Printf("Unknown option: %s\n", arg)
+				os.Exit(1)
+			}
+		}
+		if rawURL == "" {
+			fmt.Println("Usage: tarish server set <url> [--key <agentkey>]")
+			os.Exit(1)
+		}
+		serverURL, err := config.NormalizeServerURL(rawURL)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := config.SetServerURL(serverURL); err != nil {
+			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		url := os.Args[3]
-		if err := config.SetServerURL(url); err != nil {
+		fmt.Printf("Server URL set to: %s\n", serverURL)
+		if key != "" {
+			if err := config.SetServerAgentKey(key); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("Agent key set")
+		}
+
+		// Restart the agent: its config poll loop keeps the URL it started with
+		agent.StopDaemon()
+		agent.Version = Version
+		if err := agent.StartDaemon(); err != nil {
+			fmt.Printf("Warning: could not start agent: %v\n", err)
+		}
+	case "unset":
+		agent.StopDaemon()
+		if err := config.ClearServer(); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Server URL set to: %s\n", url)
+		fmt.Println("Server URL and agent key removed; agent stopped")
 	case "agent-key", "key":
 		if len(os.Args) < 4 {
 			fmt.Println("Usage: tarish server agent-key <key>")
@@ -474,18 +1685,59 @@ func handleServer() {
 			os.Exit(1)
 		}
 		fmt.Println("Agent key set")
-	case "status":
+		if config.IsEncryptionEnabled() {
+			fmt.Println("  Stored encrypted")
+		}
+	case "encrypt-key":
+		if err := config.EnableEncryption(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Agent key encrypted at rest with a machine-bound secret")
+		fmt.Println("  Moving the config to another machine requires setting the key again")
+	case "decrypt-key":
+		if err := config.DisableEncryption(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Agent key stored as plaintext")
+	case "show", "status":
 		url := config.GetServerURL()
-		key := config.GetServerAgentKey()
+		key, keyErr := config.LoadServerAgentKey()
 		if url == "" {
 			fmt.Println("Server URL: (not configured)")
 		} else {
 			fmt.Printf("Server URL: %s\n", url)
 		}
-		if key == "" {
+		encrypted := ""
+		if config.IsEncryptionEnabled() {
+			encrypted = " (encrypted)"
+		}
+		if keyErr != nil {
+			fmt.Printf("Agent Key:  unreadable - %v\n", keyErr)
+		} else if key == "" {
 			fmt.Println("Agent Key:  (not set)")
 		} else {
-			fmt.Printf("Agent Key:  %s...%s\n", key[:3], key[len(key)-3:])
+			fmt.Printf("Agent Key:  %s...%s%s\n", key[:3], key[len(key)-3:], encrypted)
+		}
+		if url == "" {
+			return
+		}
+
+		if pid, running := agent.IsDaemonRunning(); running {
+			fmt.Printf("Agent:      running (pid %d)\n", pid)
+		} else {
+			fmt.Println("Agent:      not running (starts with 'tarish start')")
+		}
+		if n := agent.QueuedReports(); n > 0 {
+			fmt.Printf("Queued:     %d reports waiting for delivery\n", n)
+		}
+
+		if rtt, err := agent.PingServer(); err != nil {
+			fmt.Printf("Connection: FAILED - %v\n", err)
+			os.Exit(1)
+		} else {
+			fmt.Printf("Connection: OK (%v)\n", rtt.Round(time.Millisecond))
 		}
 	default:
 		fmt.Printf("Unknown server command: %s\n", sub)
@@ -493,7 +1745,299 @@ func handleServer() {
 	}
 }
 
+// handleAgent inspects and controls the agent reporting daemon.
+func handleAgent() {
+	sub := "status"
+	if len(os.Args) >= 3 {
+		sub = strings.ToLower(os.Args[2])
+	}
+
+	switch sub {
+	case "start":
+		if _, running := agent.IsDaemonRunning(); running {
+			fmt.Println("Agent is already running")
+			return
+		}
+		agent.Version = Version
+		if err := agent.StartDaemon(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "stop":
+		if _, running := agent.IsDaemonRunning(); !running {
+			fmt.Println("Agent is not running")
+			return
+		}
+		agent.StopDaemon()
+		fmt.Println("Agent stopped")
+	case "status":
+		if pid, running := agent.IsDaemonRunning(); running {
+			fmt.Printf("Agent:      running (pid %d)\n", pid)
+		} else {
+			fmt.Println("Agent:      not running")
+		}
+		if url := config.GetServerURL(); url != "" {
+			fmt.Printf("Server URL: %s\n", url)
+		} else {
+			fmt.Println("Server URL: (not configured)")
+		}
+		fmt.Printf("Miner ID:   %s\n", agent.MinerID())
+		if n := agent.QueuedReports(); n > 0 {
+			fmt.Printf("Queued:     %d reports waiting for delivery\n", n)
+		}
+		fmt.Printf("Log:        %s\n", agent.DaemonLogPath())
+	case "log", "logs":
+		handleAgentLog(os.Args[3:])
+	default:
+		fmt.Printf("Unknown agent command: %s\n", sub)
+		fmt.Println("Usage: tarish agent <start|stop|status|log>")
+		os.Exit(1)
+	}
+}
+
+// handleAgentLog prints the tail of the agent log; -f keeps following it.
+func handleAgentLog(args []string) {
+	lines := 50
+	follow := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-f", "--follow":
+			follow = true
+		case "-n":
+			if i+1 < len(args) {
+				i++
+				if n, err := strconv.Atoi(args[i]); err == nil && n > 0 {
+					lines = n
+				}
+			}
+		}
+	}
+
+	path := agent.DaemonLogPath()
+	tail, err := xmrig.TailFile(path, lines)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Printf("No agent log yet (%s)\n", path)
+			return
+		}
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, line := range tail {
+		fmt.Println(line)
+	}
+	if !follow {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	file.Seek(0, io.SeekEnd)
+	for {
+		if _, err := io.Copy(os.Stdout, file); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// handleName shows or sets the friendly miner name reported to the dashboard.
+func handleName() {
+	if len(os.Args) < 3 {
+		name := config.GetMinerName()
+		if name == "" {
+			fmt.Println("Miner name: (not set)")
+		} else {
+			fmt.Printf("Miner name: %s\n", name)
+		}
+		fmt.Println("\nUsage: tarish name <label>")
+		return
+	}
+
+	name := strings.TrimSpace(strings.Join(os.Args[2:], " "))
+	if name == "" {
+		fmt.Println("Usage: tarish name <label>")
+		os.Exit(1)
+	}
+	if err := config.SetMinerName(name); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Miner name set to: %s\n", name)
+	if config.GetServerURL() != "" {
+		fmt.Println("The dashboard will show it after the next agent report.")
+	}
+}
+
+// handleTag shows, sets or clears the tag reported to the dashboard. The
+// server compares configs between rigs of the same CPU family and tag.
+func handleTag() {
+	if len(os.Args) < 3 {
+		tag := config.GetMinerTag()
+		if tag == "" {
+			fmt.Println("Miner tag: (not set)")
+		} else {
+			fmt.Printf("Miner tag: %s\n", tag)
+		}
+		fmt.Println("\nUsage: tarish tag <tag>|--clear")
+		return
+	}
+
+	tag := strings.TrimSpace(strings.Join(os.Args[2:], " "))
+	if tag == "--clear" {
+		tag = ""
+	} else if tag == "" {
+		fmt.Println("Usage: tarish tag <tag>|--clear")
+		os.Exit(1)
+	}
+	if err := config.SetMinerTag(tag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if tag == "" {
+		fmt.Println("Miner tag cleared")
+	} else {
+		fmt.Printf("Miner tag set to: %s\n", tag)
+	}
+	if config.GetServerURL() != "" {
+		fmt.Println("The dashboard will show it after the next agent report.")
+	}
+}
+
+// threadsHint converts a thread count to the max-threads-hint percentage
+// that gives it. xmrig rounds threads*hint/100 down, so round up here.
+func threadsHint(threads, total int) int {
+	if total <= 0 || threads >= total {
+		return 100
+	}
+	return (threads*100 + total - 1) / total
+}
+
+// handleWorkerID shows or sets how xmrig's api.worker-id is chosen at start.
+func handleWorkerID() {
+	if len(os.Args) < 3 {
+		strategy, _ := config.GetWorkerIDStrategy()
+		fmt.Printf("Worker-id: %s (%s)\n", xmrig.WorkerID(), strategy)
+		fmt.Println("\nUsage: tarish worker-id hostname|ip|<value>")
+		return
+	}
+
+	strategy, value := strings.ToLower(os.Args[2]), ""
+	switch strategy {
+	case config.WorkerIDHostname, config.WorkerIDIP:
+	case config.WorkerIDCustom:
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: tarish worker-id custom <value>")
+			os.Exit(1)
+		}
+		value = os.Args[3]
+	default:
+		// Anything else is the worker-id itself
+		strategy, value = config.WorkerIDCustom, os.Args[2]
+	}
+	if err := config.SetWorkerIDStrategy(strategy, value); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Worker-id set to: %s (%s)\n", xmrig.WorkerID(), strategy)
+	if _, running := xmrig.IsRunning(); running {
+		fmt.Println("Takes effect on the next 'tarish start'.")
+	}
+}
+
+// infoJSON is the machine-readable form of 'tarish info --json'
+type infoJSON struct {
+	CPU struct {
+		Model    string   `json:"model"`
+		Family   string   `json:"family"`
+		Cores    int      `json:"cores"`
+		Physical int      `json:"physical_cores"`
+		Threads  int      `json:"threads"`
+		L3KB     int      `json:"l3_cache_kb,omitempty"`
+		Suggest  int      `json:"recommended_threads"`
+		OS       string   `json:"os"`
+		Arch     string   `json:"arch"`
+		Features []string `json:"features"`
+	} `json:"cpu"`
+	Config           string         `json:"config,omitempty"`
+	Xmrig            *infoXmrigJSON `json:"xmrig,omitempty"`
+	XmrigVersions    []string       `json:"xmrig_versions"`
+	Installed        bool           `json:"installed"`
+	InstallPath      string         `json:"install_path,omitempty"`
+	AvailableConfigs []string       `json:"available_configs"`
+}
+
+type infoXmrigJSON struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+func handleInfoJSON() {
+	cpuInfo, err := cpu.Detect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error detecting CPU: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(collectInfo(cpuInfo), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+// collectInfo gathers what 'info --json' reports. Lookups that extract
+// embedded assets on demand say so on stderr, never in the document.
+func collectInfo(cpuInfo *cpu.Info) *infoJSON {
+	info := &infoJSON{}
+	info.CPU.Model = cpuInfo.RawModel
+	info.CPU.Family = cpuInfo.Family
+	info.CPU.Cores = cpuInfo.Cores
+	info.CPU.Physical = cpuInfo.PhysicalCores
+	info.CPU.Threads = cpuInfo.Threads
+	info.CPU.L3KB = cpuInfo.L3CacheKB
+	info.CPU.Suggest = cpu.SuggestThreads(cpuInfo)
+	info.CPU.OS = cpuInfo.OS
+	info.CPU.Arch = cpuInfo.Arch
+	info.CPU.Features = cpuInfo.Features
+
+	if configPath, err := xmrig.SelectConfig(cpuInfo, xmrig.GetInstalledConfigPath()); err == nil {
+		info.Config = configPath
+	}
+	if binaryInfo, err := xmrig.GetInstalledBinaryPath(); err == nil {
+		info.Xmrig = &infoXmrigJSON{Path: binaryInfo.Path, Version: binaryInfo.Version}
+	}
+	info.XmrigVersions = xmrig.ListBinaryVersions()
+	info.Installed = install.IsInstalled()
+	if info.Installed {
+		info.InstallPath = install.GetInstallPath()
+	}
+	info.AvailableConfigs, _ = xmrig.ListAvailableConfigs()
+
+	// Keep arrays as [] rather than null for consumers
+	if info.XmrigVersions == nil {
+		info.XmrigVersions = []string{}
+	}
+	if info.AvailableConfigs == nil {
+		info.AvailableConfigs = []string{}
+	}
+	return info
+}
+
 func handleInfo() {
+	for _, arg := range os.Args[2:] {
+		if arg == "--json" {
+			handleInfoJSON()
+			return
+		}
+	}
+
 	// Print system info
 	fmt.Println("=== System Information ===")
 	fmt.Println()
@@ -506,8 +2050,22 @@ func handleInfo() {
 
 	fmt.Printf("CPU Model:  %s\n", cpuInfo.RawModel)
 	fmt.Printf("CPU Family: %s\n", cpuInfo.Family)
-	fmt.Printf("Cores:      %d\n", cpuInfo.Cores)
+	smt := "off"
+	if cpuInfo.SMT() {
+		smt = "on"
+	}
+	fmt.Printf("Cores:      %d physical\n", cpuInfo.PhysicalCores)
+	fmt.Printf("Threads:    %d (SMT %s)\n", cpuInfo.Threads, smt)
+	if cpuInfo.L3CacheKB > 0 {
+		fmt.Printf("L3 cache:   %d MB\n", cpuInfo.L3CacheKB/1024)
+	}
+	fmt.Printf("Recommended threads: %d (apply with 'tarish tune --auto')\n", cpu.SuggestThreads(cpuInfo))
 	fmt.Printf("OS/Arch:    %s/%s\n", cpuInfo.OS, cpuInfo.Arch)
+	if len(cpuInfo.Features) > 0 {
+		fmt.Printf("Features:   %s\n", strings.Join(cpuInfo.Features, " "))
+	} else {
+		fmt.Printf("Features:   (unknown)\n")
+	}
 	fmt.Println()
 
 	// Show expected config
@@ -515,6 +2073,10 @@ func handleInfo() {
 	configPath, err := xmrig.SelectConfig(cpuInfo, configsPath)
 	if err != nil {
 		fmt.Printf("Config:     (no matching config found)\n")
+		var notFound *xmrig.ConfigNotFoundError
+		if errors.As(err, &notFound) {
+			fmt.Printf("            tried: %s\n", notFound.Tried())
+		}
 	} else {
 		fmt.Printf("Config:     %s\n", configPath)
 	}
@@ -526,6 +2088,12 @@ func handleInfo() {
 	} else {
 		fmt.Printf("XMRig:      %s (v%s)\n", binaryInfo.Path, binaryInfo.Version)
 	}
+	if bundled, err := embedded.EmbeddedXmrigVersion(); err == nil && len(bundled) > 0 {
+		fmt.Printf("Bundled:    xmrig %s\n", strings.Join(bundled, ", "))
+	}
+	if versions := xmrig.ListBinaryVersions(); len(versions) > 0 {
+		fmt.Printf("Available:  xmrig %s (select with 'tarish start --xmrig-version <v>')\n", strings.Join(versions, ", "))
+	}
 
 	// Show installation status
 	fmt.Println()
@@ -548,6 +2116,20 @@ func handleInfo() {
 	}
 }
 
+// stripVerboseFlag removes --verbose from args, reporting whether it was present
+func stripVerboseFlag(args []string) ([]string, bool) {
+	verbose := false
+	kept := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--verbose" {
+			verbose = true
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept, verbose
+}
+
 func printHelp() {
 	// ANSI color codes
 	cyan := "\033[36m"
@@ -565,33 +2147,86 @@ func printHelp() {
 
 %sCOMMANDS:%s
     %sinstall, i%s       Install tarish to /usr/local/bin
+                     %sUse --dry-run to list changes without writing%s
+                     %sUse --force to re-extract unchanged xmrig binaries and configs%s
     %suninstall, un%s    Uninstall tarish from the system
+                     %sUse --keep-configs / --keep-data to preserve files%s
     %supdate, u%s        Update tarish to latest version
+                     %sUse --version <v> to install a specific release%s
     %supdate enable%s    Enable auto-update on start
     %supdate disable%s   Disable auto-update
     %supdate status%s    Show auto-update status
+    %sautoupdate on|off%s  Enable or disable auto-update
+    %sautoupdate interval <h>%s  Set the update check interval in hours
+    %sschedule add <HH:MM-HH:MM>%s  Only mine inside this window (e.g. 23:00-07:00)
+    %sschedule remove|clear|list%s  Edit or show the mining schedule
 
     %sstart, st%s        Start mining with auto-detected config
                      %sUse --force to kill existing process%s
+                     %sUse --cpus <list> to pin to cores (e.g. 0-3,6)%s
+                     %sUse --xmrig-version <v> to run a specific xmrig%s
+                     %sUse --strict-wallet to refuse pool users that are not Monero addresses%s
+                     %sUse --pause-on-activity to pause while the machine is in use%s
+                     %s(--idle-seconds <n> idle before resuming, default 120)%s
+                     %sUse --watchdog to restart xmrig if it stalls at 0 H/s%s
+                     %s(--watchdog-window <n> seconds at 0 H/s first, default 300)%s
+                     %sUse --sleep-mode lid-only to keep mining with the lid closed but allow idle sleep%s
     %sstop, sp%s         Stop all xmrig processes
+    %spause%s            Pause hashing, keeping xmrig and its pool connection up
+    %sresume%s           Resume hashing after pause
     %sstatus%s           Show mining status and statistics
+                     %sUse --remote <miner-id> to query the server%s
+
+    %sconfig edit%s      Edit the active xmrig config in $EDITOR
+    %sconfig render [tmpl]%s  Fill ${WALLET}/${WORKER}/${POOL} in a config template
+                     %sValues from flags (--wallet, --worker, --pool) or config set; -o to write a file%s
+                     %sAlso ${ENV:NAME} to read e.g. the wallet from the environment at start%s
+    %sconfig set <key> [val]%s  Set the wallet, worker or pool used by templates
+    %sconfig validate <file>%s  Check an xmrig config anywhere on disk; exits 1 if invalid (for CI)
+    %sexport-config%s    Bundle tarish, xmrig and server settings as JSON (-o file)
+                     %sUse --redact to leave the agent key out%s
+    %simport-config <file>%s  Restore a bundle from export-config (- for stdin)
+    %stune --threads-hint <pct>%s  Limit xmrig to a share of CPU threads (0-100)
+                     %sUse --auto for the thread count recommended by tarish info%s
+    %soptimize%s         Check huge pages and MSR setup for RandomX (Linux)
+                     %sUse --apply (root) to configure, --revert to undo%s
 
     %sservice enable%s   Enable auto-start on boot
+                     %sUse --cpu-quota 50%%, --nice 10, --idle-io to limit mining%s
     %sservice disable%s  Disable auto-start on boot
+    %sservice restart%s  Restart the service now (also: start, stop)
     %sservice status%s   Show auto-start status
 
     %stls%s              Show TLS xmrig-proxy status
     %stls enable%s       Enable TLS to xmrig-proxy (default)
     %stls disable%s      Disable TLS, use plain stratum
 
-    %sserver set <url>%s       Set dashboard server URL
+    %sserver set <url>%s       Set dashboard server URL and start the agent
+                     %sUse --key <agentkey> to set the agent key too%s
+    %sserver unset%s           Remove the server config and stop the agent
     %sserver agent-key <key>%s Set agent key for server auth
-    %sserver status%s          Show dashboard server config
+    %sserver encrypt-key%s     Encrypt the agent key at rest (decrypt-key to undo)
+    %sserver show%s            Show dashboard server config
+    %sname <label>%s           Set a friendly miner name for the dashboard
+    %stag <tag>|--clear%s      Group rigs meant to run the same config on the dashboard
+    %sworker-id <s>%s          Set the xmrig worker-id: hostname, ip or a fixed value
+
+    %sagent status%s     Show the dashboard reporting agent (also: start, stop)
+    %sagent log%s        Show the agent log (-n <lines>, -f to follow)
+
+    %sapi local%s        Bind xmrig API to 127.0.0.1 (default)
+    %sapi lan%s          Keep the API host from the selected config
+    %sapi rotate-token enable%s  New API token on every start
+    %sdoctor%s           Check for common setup problems
 
     %sinfo%s             Show system and configuration info
+                     %sUse --json for machine-readable output%s
     %shelp, h%s          Show this help message
     %sversion, v%s       Show version information
 
+%sOPTIONS:%s
+    %s--verbose%s        Print diagnostic detail (config candidates, binaries, API calls)
+
 %sEXAMPLES:%s
     %starish start%s           Start mining
     %starish start --force%s   Force restart mining
@@ -607,17 +2242,59 @@ func printHelp() {
 		yellow, reset,
 		yellow, reset,
 		green, reset,
+		gray, reset,
+		gray, reset,
 		green, reset,
+		gray, reset,
 		green, reset,
+		gray, reset,
 		green, reset,
 		green, reset,
 		green, reset,
 		green, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		gray, reset,
+		gray, reset,
+		gray, reset,
+		gray, reset,
+		gray, reset,
+		gray, reset,
+		gray, reset,
+		gray, reset,
 		gray, reset,
 		green, reset,
 		green, reset,
 		green, reset,
 		green, reset,
+		gray, reset,
+		green, reset,
+		green, reset,
+		gray, reset,
+		gray, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		gray, reset,
+		green, reset,
+		green, reset,
+		gray, reset,
+		green, reset,
+		gray, reset,
+		green, reset,
+		gray, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		green, reset,
+		gray, reset,
+		green, reset,
+		green, reset,
 		green, reset,
 		green, reset,
 		green, reset,
@@ -628,6 +2305,13 @@ func printHelp() {
 		green, reset,
 		green, reset,
 		green, reset,
+		green, reset,
+		green, reset,
+		gray, reset,
+		green, reset,
+		green, reset,
+		yellow, reset,
+		green, reset,
 		yellow, reset,
 		cyan, reset,
 		cyan, reset,
@@ -0,0 +1,302 @@
+// Package optimize checks and configures the Linux kernel settings that
+// RandomX hashrate depends on: 2MB huge pages, 1GB huge pages and the MSR
+// module xmrig uses for its RandomX MSR mod. Changes are recorded so they
+// can be reverted.
+package optimize
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"tarish/config"
+)
+
+// Roots of the kernel interfaces, replaced in tests
+var (
+	procRoot = "/proc"
+	sysRoot  = "/sys"
+)
+
+// modprobe loads or (with -r) unloads a kernel module; replaced in tests
+var modprobe = func(args ...string) error {
+	out, err := exec.Command("modprobe", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("modprobe %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// randomxDatasetPages is the 2MB pages the RandomX dataset and cache need
+// (2080 MiB + 256 MiB); each mining thread needs one more for its scratchpad
+const randomxDatasetPages = 1168
+
+// oneGBPagesPerNode matches xmrig's enable_1gb_pages.sh
+const oneGBPagesPerNode = 3
+
+// Check is one setting's current and recommended state
+type Check struct {
+	Name        string
+	Current     string
+	Recommended string
+	OK          bool
+}
+
+// state records the values Apply replaced, so Revert can restore them
+type state struct {
+	NrHugepages int            `json:"nr_hugepages"`
+	OneGBPages  map[string]int `json:"one_gb_pages,omitempty"` // node dir -> previous count
+	LoadedMSR   bool           `json:"loaded_msr,omitempty"`
+}
+
+// RecommendedHugepages returns the 2MB page count for a miner using threads
+func RecommendedHugepages(threads int) int {
+	if threads < 1 {
+		threads = 1
+	}
+	return randomxDatasetPages + threads
+}
+
+// Status reports the current settings against the recommendations
+func Status(threads int) ([]Check, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("optimize is only supported on Linux")
+	}
+
+	var checks []Check
+
+	want := RecommendedHugepages(threads)
+	have, err := readInt(hugepagesPath())
+	if err != nil {
+		return nil, fmt.Errorf("cannot read vm.nr_hugepages: %w", err)
+	}
+	checks = append(checks, Check{
+		Name:        "vm.nr_hugepages",
+		Current:     strconv.Itoa(have),
+		Recommended: strconv.Itoa(want),
+		OK:          have >= want,
+	})
+
+	if supports1GBPages() {
+		nodes := oneGBNodeFiles()
+		total := 0
+		for _, f := range nodes {
+			n, _ := readInt(f)
+			total += n
+		}
+		wantTotal := oneGBPagesPerNode * len(nodes)
+		checks = append(checks, Check{
+			Name:        "1GB huge pages",
+			Current:     strconv.Itoa(total),
+			Recommended: strconv.Itoa(wantTotal),
+			OK:          len(nodes) > 0 && total >= wantTotal,
+		})
+	} else {
+		checks = append(checks, Check{Name: "1GB huge pages", Current: "not supported by this CPU", Recommended: "-", OK: true})
+	}
+
+	msrLoaded := msrModuleLoaded()
+	checks = append(checks, Check{
+		Name:        "msr module",
+		Current:     loadedLabel(msrLoaded),
+		Recommended: "loaded",
+		OK:          msrLoaded,
+	})
+
+	return checks, nil
+}
+
+// Apply raises huge page counts and loads the msr module where they fall
+// short of the recommendations. Settings that are already sufficient are
+// left alone, so running it twice changes nothing. Requires root.
+func Apply(threads int) error {
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	st, hasState, err := loadState()
+	if err != nil {
+		return err
+	}
+	if !hasState {
+		st = &state{OneGBPages: map[string]int{}}
+		if st.NrHugepages, err = readInt(hugepagesPath()); err != nil {
+			return fmt.Errorf("cannot read vm.nr_hugepages: %w", err)
+		}
+	}
+
+	want := RecommendedHugepages(threads)
+	if have, _ := readInt(hugepagesPath()); have < want {
+		if err := writeInt(hugepagesPath(), want); err != nil {
+			return fmt.Errorf("set vm.nr_hugepages: %w", err)
+		}
+	}
+
+	if supports1GBPages() {
+		for _, f := range oneGBNodeFiles() {
+			have, err := readInt(f)
+			if err != nil || have >= oneGBPagesPerNode {
+				continue
+			}
+			if _, recorded := st.OneGBPages[f]; !recorded {
+				st.OneGBPages[f] = have
+			}
+			if err := writeInt(f, oneGBPagesPerNode); err != nil {
+				return fmt.Errorf("set 1GB pages: %w", err)
+			}
+		}
+	}
+
+	if !msrModuleLoaded() {
+		if err := modprobe("msr", "allow_writes=on"); err != nil {
+			return err
+		}
+		st.LoadedMSR = true
+	}
+
+	return saveState(st)
+}
+
+// Revert restores the values recorded by Apply and unloads the msr module
+// if Apply loaded it. Requires root.
+func Revert() error {
+	if err := requireRoot(); err != nil {
+		return err
+	}
+
+	st, hasState, err := loadState()
+	if err != nil {
+		return err
+	}
+	if !hasState {
+		return fmt.Errorf("nothing to revert: 'tarish optimize --apply' has not changed anything")
+	}
+
+	if err := writeInt(hugepagesPath(), st.NrHugepages); err != nil {
+		return fmt.Errorf("restore vm.nr_hugepages: %w", err)
+	}
+	for f, n := range st.OneGBPages {
+		if err := writeInt(f, n); err != nil {
+			return fmt.Errorf("restore 1GB pages: %w", err)
+		}
+	}
+	if st.LoadedMSR && msrModuleLoaded() {
+		if err := modprobe("-r", "msr"); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(statePath())
+}
+
+// ---------- internal helpers ----------
+
+func hugepagesPath() string {
+	return filepath.Join(procRoot, "sys", "vm", "nr_hugepages")
+}
+
+// oneGBNodeFiles returns each NUMA node's 1GB nr_hugepages file
+func oneGBNodeFiles() []string {
+	matches, _ := filepath.Glob(filepath.Join(sysRoot, "devices", "system", "node", "node*", "hugepages", "hugepages-1048576kB", "nr_hugepages"))
+	return matches
+}
+
+// supports1GBPages checks /proc/cpuinfo for the pdpe1gb flag
+func supports1GBPages() bool {
+	data, err := os.ReadFile(filepath.Join(procRoot, "cpuinfo"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "flags") {
+			return strings.Contains(" "+line+" ", " pdpe1gb ")
+		}
+	}
+	return false
+}
+
+// MSRModuleLoaded reports whether the msr kernel module is loaded, which
+// xmrig needs to apply its RandomX MSR mod
+func MSRModuleLoaded() bool {
+	return msrModuleLoaded()
+}
+
+func msrModuleLoaded() bool {
+	_, err := os.Stat(filepath.Join(sysRoot, "module", "msr"))
+	return err == nil
+}
+
+func loadedLabel(loaded bool) string {
+	if loaded {
+		return "loaded"
+	}
+	return "not loaded"
+}
+
+func requireRoot() error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("optimize is only supported on Linux")
+	}
+	if geteuid() != 0 {
+		return fmt.Errorf("changing kernel settings requires root (try sudo)")
+	}
+	return nil
+}
+
+// geteuid is replaced in tests
+var geteuid = os.Geteuid
+
+func statePath() string {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "/tmp/tarish-optimize-state.json"
+	}
+	return filepath.Join(dir, "optimize-state.json")
+}
+
+func loadState() (*state, bool, error) {
+	data, err := os.ReadFile(statePath())
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, false, fmt.Errorf("corrupt optimize state %s: %w", statePath(), err)
+	}
+	if st.OneGBPages == nil {
+		st.OneGBPages = map[string]int{}
+	}
+	return &st, true, nil
+}
+
+func saveState(st *state) error {
+	path := statePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func readInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+func writeInt(path string, n int) error {
+	return os.WriteFile(path, []byte(strconv.Itoa(n)+"\n"), 0644)
+}
@@ -0,0 +1,123 @@
+package optimize
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeKernel points procRoot/sysRoot at a temp tree with the given
+// nr_hugepages, one NUMA node with 1GB pages, and no msr module.
+func fakeKernel(t *testing.T, hugepages string) (msrCalls *[]string) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("optimize is Linux-only")
+	}
+
+	root := t.TempDir()
+	t.Setenv("HOME", root)
+
+	origProc, origSys, origModprobe, origEuid := procRoot, sysRoot, modprobe, geteuid
+	t.Cleanup(func() { procRoot, sysRoot, modprobe, geteuid = origProc, origSys, origModprobe, origEuid })
+
+	procRoot = filepath.Join(root, "proc")
+	sysRoot = filepath.Join(root, "sys")
+	geteuid = func() int { return 0 }
+
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(hugepagesPath(), hugepages+"\n")
+	write(filepath.Join(procRoot, "cpuinfo"), "processor\t: 0\nflags\t\t: fpu sse2 pdpe1gb aes\n")
+	write(filepath.Join(sysRoot, "devices", "system", "node", "node0", "hugepages", "hugepages-1048576kB", "nr_hugepages"), "0\n")
+
+	var calls []string
+	modprobe = func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		msrDir := filepath.Join(sysRoot, "module", "msr")
+		if args[0] == "-r" {
+			return os.RemoveAll(msrDir)
+		}
+		return os.MkdirAll(msrDir, 0755)
+	}
+	return &calls
+}
+
+func TestApplyAndRevert(t *testing.T) {
+	calls := fakeKernel(t, "16")
+
+	checks, err := Status(8)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, c := range checks {
+		if c.OK {
+			t.Errorf("%s reported OK before Apply", c.Name)
+		}
+	}
+
+	if err := Apply(8); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	// A second run must not record the already-raised values as originals
+	if err := Apply(8); err != nil {
+		t.Fatalf("second Apply: %v", err)
+	}
+
+	checks, _ = Status(8)
+	for _, c := range checks {
+		if !c.OK {
+			t.Errorf("%s not OK after Apply: %s (want %s)", c.Name, c.Current, c.Recommended)
+		}
+	}
+	if n, _ := readInt(hugepagesPath()); n != RecommendedHugepages(8) {
+		t.Errorf("nr_hugepages = %d, want %d", n, RecommendedHugepages(8))
+	}
+
+	if err := Revert(); err != nil {
+		t.Fatalf("Revert: %v", err)
+	}
+	if n, _ := readInt(hugepagesPath()); n != 16 {
+		t.Errorf("nr_hugepages after Revert = %d, want 16", n)
+	}
+	if n, _ := readInt(oneGBNodeFiles()[0]); n != 0 {
+		t.Errorf("1GB pages after Revert = %d, want 0", n)
+	}
+	if msrModuleLoaded() {
+		t.Error("msr module still loaded after Revert")
+	}
+	if got := strings.Join(*calls, ";"); got != "msr allow_writes=on;-r msr" {
+		t.Errorf("modprobe calls = %q", got)
+	}
+
+	if err := Revert(); err == nil {
+		t.Error("second Revert should report nothing to revert")
+	}
+}
+
+func TestApplyLeavesSufficientSettings(t *testing.T) {
+	fakeKernel(t, "4096")
+
+	if err := Apply(8); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if n, _ := readInt(hugepagesPath()); n != 4096 {
+		t.Errorf("nr_hugepages = %d, want the existing 4096 kept", n)
+	}
+}
+
+func TestApplyRequiresRoot(t *testing.T) {
+	fakeKernel(t, "0")
+	geteuid = func() int { return 1000 }
+
+	if err := Apply(4); err == nil {
+		t.Error("Apply succeeded without root")
+	}
+}
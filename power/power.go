@@ -0,0 +1,73 @@
+package power
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// powerSupplyDir is where Linux exposes AC adapters and batteries
+var powerSupplyDir = "/sys/class/power_supply"
+
+// OnBattery reports whether the machine is currently running on battery.
+// Machines without a battery (desktops, servers) report false.
+func OnBattery() (bool, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return onBatteryDarwin()
+	case "linux":
+		return onBatteryLinux()
+	default:
+		return false, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+}
+
+// onBatteryDarwin parses `pmset -g batt`, whose first line is e.g.
+// "Now drawing from 'Battery Power'" or "Now drawing from 'AC Power'".
+func onBatteryDarwin() (bool, error) {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false, fmt.Errorf("pmset failed: %w", err)
+	}
+	return strings.Contains(string(out), "'Battery Power'"), nil
+}
+
+// onBatteryLinux checks the power_supply class: any online mains/USB
+// adapter means AC; otherwise a present battery means we're on battery.
+func onBatteryLinux() (bool, error) {
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	hasBattery := false
+	for _, e := range entries {
+		dir := filepath.Join(powerSupplyDir, e.Name())
+		switch readAttr(dir, "type") {
+		case "Mains", "USB", "USB_C", "USB_PD":
+			if readAttr(dir, "online") == "1" {
+				return false, nil
+			}
+		case "Battery":
+			// Peripheral batteries (mice, headsets) report scope=Device
+			if readAttr(dir, "scope") != "Device" {
+				hasBattery = true
+			}
+		}
+	}
+	return hasBattery, nil
+}
+
+func readAttr(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,61 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSupply(t *testing.T, root, name string, attrs map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range attrs {
+		if err := os.WriteFile(filepath.Join(dir, k), []byte(v+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOnBatteryLinux(t *testing.T) {
+	orig := powerSupplyDir
+	defer func() { powerSupplyDir = orig }()
+
+	tests := []struct {
+		name     string
+		supplies map[string]map[string]string
+		want     bool
+	}{
+		{"desktop", nil, false},
+		{"laptop on AC", map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "1"},
+			"BAT0": {"type": "Battery"},
+		}, false},
+		{"laptop on battery", map[string]map[string]string{
+			"AC":   {"type": "Mains", "online": "0"},
+			"BAT0": {"type": "Battery"},
+		}, true},
+		{"desktop with wireless mouse", map[string]map[string]string{
+			"hidpp_battery_0": {"type": "Battery", "scope": "Device"},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			powerSupplyDir = t.TempDir()
+			for name, attrs := range tt.supplies {
+				writeSupply(t, powerSupplyDir, name, attrs)
+			}
+
+			got, err := onBatteryLinux()
+			if err != nil {
+				t.Fatalf("onBatteryLinux() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("onBatteryLinux() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Package proc inspects processes tarish started in an earlier invocation,
+// so a PID read back from a file is checked before it is trusted: after a
+// reboot, or once the PID is reused, it may name an unrelated program.
+package proc
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Alive reports whether a process with the given PID exists
+func Alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Unix, FindProcess always succeeds, so we need to send signal 0
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// Name returns the command name of a process
+// (/proc/<pid>/comm on Linux, ps on macOS)
+func Name(pid int) (string, error) {
+	var name string
+	if runtime.GOOS == "linux" {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+		if err != nil {
+			return "", err
+		}
+		name = string(data)
+	} else {
+		out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "comm=").Output()
+		if err != nil {
+			return "", err
+		}
+		name = string(out)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("no command name for pid %d", pid)
+	}
+	return name, nil
+}
+
+// Children returns the PIDs of a process's direct children. Only Linux
+// exposes them (/proc/<pid>/task/<tid>/children, per thread, since any
+// thread may have forked); elsewhere it is nil.
+func Children(pid int) []int {
+	tasks, _ := filepath.Glob(fmt.Sprintf("/proc/%d/task/*/children", pid))
+	var children []int
+	for _, path := range tasks {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, field := range strings.Fields(string(data)) {
+			if child, err := strconv.Atoi(field); err == nil {
+				children = append(children, child)
+			}
+		}
+	}
+	return children
+}
+
+var (
+	bootOnce sync.Once
+	bootAt   time.Time
+	bootErr  error
+)
+
+// BootTime returns when the machine booted, from btime in /proc/stat
+// on Linux or kern.boottime on macOS. It is read once per process.
+func BootTime() (time.Time, error) {
+	bootOnce.Do(func() { bootAt, bootErr = readBootTime() })
+	return bootAt, bootErr
+}
+
+func readBootTime() (time.Time, error) {
+	if runtime.GOOS == "linux" {
+		data, err := os.ReadFile("/proc/stat")
+		if err != nil {
+			return time.Time{}, err
+		}
+		return parseBootTime(string(data), `(?m)^btime (\d+)$`)
+	}
+	out, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+	// { sec = 1700000000, usec = 0 } Tue Nov 14 22:13:20 2023
+	return parseBootTime(string(out), `sec = (\d+)`)
+}
+
+// parseBootTime extracts Unix seconds matched by pattern's first group
+func parseBootTime(text, pattern string) (time.Time, error) {
+	m := regexp.MustCompile(pattern).FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("boot time not found")
+	}
+	secs, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, 0), nil
+}
@@ -0,0 +1,48 @@
+package proc
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseBootTime(t *testing.T) {
+	stat := "cpu  1 2 3\nintr 12345\nbtime 1700000000\nprocesses 42\n"
+	if got, err := parseBootTime(stat, `(?m)^btime (\d+)$`); err != nil || got.Unix() != 1700000000 {
+		t.Errorf("parseBootTime(/proc/stat) = %v, %v", got, err)
+	}
+	sysctl := "{ sec = 1700000000, usec = 123 } Tue Nov 14 22:13:20 2023\n"
+	if got, err := parseBootTime(sysctl, `sec = (\d+)`); err != nil || got.Unix() != 1700000000 {
+		t.Errorf("parseBootTime(kern.boottime) = %v, %v", got, err)
+	}
+	if _, err := parseBootTime("nothing here", `sec = (\d+)`); err == nil {
+		t.Error("parseBootTime accepted text without a boot time")
+	}
+}
+
+func TestNameAndChildren(t *testing.T) {
+	if name, err := Name(os.Getpid()); err != nil || !strings.HasPrefix(name, "proc.test") {
+		t.Errorf("Name(self) = %q, %v", name, err)
+	}
+
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { cmd.Process.Kill(); cmd.Wait() }()
+	if !Alive(cmd.Process.Pid) {
+		t.Error("Alive(sleep) = false")
+	}
+	if runtime.GOOS != "linux" {
+		return
+	}
+	found := false
+	for _, child := range Children(os.Getpid()) {
+		found = found || child == cmd.Process.Pid
+	}
+	if !found {
+		t.Errorf("Children(self) = %v, want it to include %d", Children(os.Getpid()), cmd.Process.Pid)
+	}
+}
@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"tarish/daemon"
+	"tarish/logging"
+	"tarish/xmrig"
+)
+
+// checkInterval is how often the daemon re-reads the schedule and compares
+// it with the clock; windows have minute resolution
+const checkInterval = 20 * time.Second
+
+// pauseMining and resumeMining are swapped out in tests. Resuming only
+// drops the schedule reason; a manual or activity pause keeps mining paused.
+var (
+	pauseMining  = func() error { return xmrig.PauseFor(xmrig.PauseSchedule) }
+	resumeMining = func() ([]xmrig.PauseReason, error) { return xmrig.ResumeFor(xmrig.PauseSchedule) }
+)
+
+// RunDaemon pauses xmrig outside the scheduled windows and resumes it
+// inside them. The schedule is re-read every cycle so 'tarish schedule'
+// edits apply without a restart; once it is empty the daemon resumes
+// mining and exits. Invoked via the hidden "_schedule-daemon" command.
+func RunDaemon() {
+	logger := logging.New(os.Stdout, "schedule")
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+
+	logger.Info("started", "pid", os.Getpid())
+
+	for {
+		// pause.json, not a local flag: an xmrig restart clears it
+		paused := xmrig.IsPausedFor(xmrig.PauseSchedule)
+		windows, err := Load()
+		if err != nil {
+			logger.Error("invalid schedule, exiting", "err", err)
+			return
+		}
+		if len(windows) == 0 {
+			if paused {
+				apply(logger, true, true)
+			}
+			logger.Info("schedule cleared, exiting")
+			return
+		}
+		apply(logger, paused, Allowed(windows, time.Now()))
+
+		select {
+		case <-sig:
+			// Leave the miner running once nothing enforces the schedule
+			if xmrig.IsPausedFor(xmrig.PauseSchedule) {
+				apply(logger, true, true)
+			}
+			logger.Info("received signal, shutting down")
+			return
+		case <-time.After(checkInterval):
+		}
+	}
+}
+
+// apply pauses or resumes mining to match allowed and returns whether
+// mining is now paused. Failures leave the state unchanged so the next
+// cycle retries.
+func apply(logger *slog.Logger, paused, allowed bool) bool {
+	switch {
+	case allowed && paused:
+		held, err := resumeMining()
+		if err != nil {
+			logger.Warn("failed to resume", "err", err)
+			return true
+		}
+		if len(held) > 0 {
+			logger.Info("inside mining window, mining stays paused", "paused_by", held)
+		} else {
+			logger.Info("inside mining window, resumed")
+		}
+		return false
+	case !allowed && !paused:
+		if err := pauseMining(); err != nil {
+			logger.Warn("failed to pause", "err", err)
+			return false
+		}
+		logger.Info("outside mining windows, paused")
+		return true
+	}
+	return paused
+}
+
+// daemonProc is the background "_schedule-daemon" process
+var daemonProc = daemon.New("schedule")
+
+// StartDaemon spawns the schedule daemon as a background process.
+// No-op if the daemon is already running.
+func StartDaemon() error {
+	if _, running := IsDaemonRunning(); running {
+		return nil
+	}
+
+	_, err := daemonProc.Start()
+	return err
+}
+
+// StopDaemon sends SIGTERM to the schedule daemon (if running).
+func StopDaemon() {
+	daemonProc.Stop()
+}
+
+// IsDaemonRunning reports the PID and whether the schedule daemon is alive.
+func IsDaemonRunning() (int, bool) {
+	return daemonProc.IsRunning()
+}
@@ -0,0 +1,148 @@
+// Package schedule restricts mining to configured time windows. Windows
+// are stored in tarish.json as "HH:MM-HH:MM" strings in local time; a
+// window whose end is before its start runs overnight (23:00-07:00).
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tarish/config"
+)
+
+// Window is a daily time range, in minutes since local midnight
+type Window struct {
+	Start int
+	End   int
+}
+
+// ParseWindow parses "HH:MM-HH:MM"
+func ParseWindow(s string) (Window, error) {
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q (want HH:MM-HH:MM)", s)
+	}
+	start, err := parseClock(startStr)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("invalid window %q: start and end are the same", s)
+	}
+	return Window{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// String formats the window as "HH:MM-HH:MM"
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// Contains reports whether t (in its own location) falls in the window.
+// The start is inclusive and the end exclusive.
+func (w Window) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return m >= w.Start && m < w.End
+	}
+	// Overnight: 23:00-07:00 is [23:00, 24:00) plus [00:00, 07:00)
+	return m >= w.Start || m < w.End
+}
+
+// ParseWindows parses every window, failing on the first invalid one
+func ParseWindows(specs []string) ([]Window, error) {
+	windows := make([]Window, 0, len(specs))
+	for _, s := range specs {
+		w, err := ParseWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// Allowed reports whether mining is allowed at t. No windows means no
+// restriction.
+func Allowed(windows []Window, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Load parses the windows stored in tarish.json
+func Load() ([]Window, error) {
+	return ParseWindows(config.GetSchedule())
+}
+
+// Add validates spec and appends it to the stored schedule. Adding a
+// window that is already present is a no-op.
+func Add(spec string) (Window, error) {
+	w, err := ParseWindow(spec)
+	if err != nil {
+		return Window{}, err
+	}
+	windows, err := Load()
+	if err != nil {
+		return Window{}, err
+	}
+	for _, existing := range windows {
+		if existing == w {
+			return w, nil
+		}
+	}
+	return w, save(append(windows, w))
+}
+
+// Remove deletes spec from the stored schedule
+func Remove(spec string) error {
+	w, err := ParseWindow(spec)
+	if err != nil {
+		return err
+	}
+	windows, err := Load()
+	if err != nil {
+		return err
+	}
+	kept := windows[:0]
+	for _, existing := range windows {
+		if existing != w {
+			kept = append(kept, existing)
+		}
+	}
+	if len(kept) == len(windows) {
+		return fmt.Errorf("window %s is not in the schedule", w)
+	}
+	return save(kept)
+}
+
+// Clear removes all windows, so mining is no longer restricted
+func Clear() error {
+	return config.SetSchedule(nil)
+}
+
+func save(windows []Window) error {
+	specs := make([]string, len(windows))
+	for i, w := range windows {
+		specs[i] = w.String()
+	}
+	return config.SetSchedule(specs)
+}
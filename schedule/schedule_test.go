@@ -0,0 +1,132 @@
+package schedule
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"tarish/xmrig"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"23:00-07:00", "23:00-07:00", false},
+		{"9:30-17:00", "09:30-17:00", false},
+		{" 00:00 - 06:15 ", "00:00-06:15", false},
+		{"23:00", "", true},
+		{"25:00-07:00", "", true},
+		{"08:00-08:00", "", true},
+		{"night", "", true},
+	}
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWindow(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && w.String() != tt.want {
+			t.Errorf("ParseWindow(%q) = %s, want %s", tt.in, w, tt.want)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		tm, err := time.Parse("15:04", hhmm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	overnight, _ := ParseWindow("23:00-07:00")
+	lunch, _ := ParseWindow("12:00-13:00")
+	windows := []Window{overnight, lunch}
+
+	tests := []struct {
+		at   string
+		want bool
+	}{
+		{"23:00", true},
+		{"02:30", true},
+		{"06:59", true},
+		{"07:00", false},
+		{"12:30", true},
+		{"13:00", false},
+		{"22:59", false},
+	}
+	for _, tt := range tests {
+		if got := Allowed(windows, at(tt.at)); got != tt.want {
+			t.Errorf("Allowed at %s = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+
+	if !Allowed(nil, at("15:00")) {
+		t.Error("an empty schedule should allow mining at any time")
+	}
+}
+
+func TestAddRemove(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := Add("23:00-07:00"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := Add("23:00-7:00"); err != nil { // same window, different spelling
+		t.Fatalf("Add duplicate: %v", err)
+	}
+	if _, err := Add("12:00-13:00"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	windows, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("got %d windows, want 2: %v", len(windows), windows)
+	}
+
+	if err := Remove("23:00-07:00"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := Remove("23:00-07:00"); err == nil {
+		t.Error("removing a missing window should fail")
+	}
+	if windows, _ := Load(); len(windows) != 1 || windows[0].String() != "12:00-13:00" {
+		t.Errorf("after Remove: %v", windows)
+	}
+
+	if err := Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if windows, _ := Load(); len(windows) != 0 {
+		t.Errorf("after Clear: %v", windows)
+	}
+}
+
+func TestApply(t *testing.T) {
+	origPause, origResume := pauseMining, resumeMining
+	defer func() { pauseMining, resumeMining = origPause, origResume }()
+
+	var calls []string
+	pauseMining = func() error { calls = append(calls, "pause"); return nil }
+	resumeMining = func() ([]xmrig.PauseReason, error) { calls = append(calls, "resume"); return nil, nil }
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	paused := apply(logger, false, true)  // inside a window: nothing to do
+	paused = apply(logger, paused, false) // window ends: pause
+	paused = apply(logger, paused, false) // still outside: no-op
+	paused = apply(logger, paused, true)  // window starts: resume
+
+	if paused {
+		t.Error("paused = true inside a window")
+	}
+	if len(calls) != 2 || calls[0] != "pause" || calls[1] != "resume" {
+		t.Errorf("calls = %v, want [pause resume]", calls)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"tarish-server/models"
+	"tarish-server/store"
+)
+
+const alertScanInterval = time.Minute
+
+// OfflineAlert is the JSON body POSTed to the alert webhook.
+type OfflineAlert struct {
+	Event     string    `json:"event"`
+	MinerID   string    `json:"miner_id"`
+	WorkerID  string    `json:"worker_id"`
+	Hostname  string    `json:"hostname"`
+	IP        string    `json:"ip"`
+	CPUModel  string    `json:"cpu_model"`
+	LastSeen  time.Time `json:"last_seen"`
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// offlineAlerter watches miner status and fires a webhook when a miner
+// transitions to offline. State is kept in memory only.
+type offlineAlerter struct {
+	store    store.Store
+	webhook  string
+	cooldown time.Duration
+	client   *http.Client
+
+	lastStatus map[string]string
+	lastFired  map[string]time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{} // closed when run returns
+}
+
+func newOfflineAlerter(s store.Store, webhook string, cooldown time.Duration) *offlineAlerter {
+	return &offlineAlerter{
+		store:      s,
+		webhook:    webhook,
+		cooldown:   cooldown,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		lastStatus: make(map[string]string),
+		lastFired:  make(map[string]time.Time),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// run scans miners until Close. The first scan only records current status
+// so miners that were already offline at startup don't trigger alerts.
+func (a *offlineAlerter) run() {
+	defer close(a.done)
+	a.scan(false)
+	ticker := time.NewTicker(alertScanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.scan(true)
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// Close stops run and waits for it to return, so no scan reaches the
+// store after it is closed
+func (a *offlineAlerter) Close() {
+	a.stopOnce.Do(func() { close(a.stop) })
+	<-a.done
+}
+
+func (a *offlineAlerter) scan(fire bool) {
+	miners, err := a.store.GetMiners()
+	if err != nil {
+		log.Printf("Warning: alert scan failed: %v", err)
+		return
+	}
+
+	// Forget deleted and pruned miners
+	current := make(map[string]bool, len(miners))
+	for _, m := range miners {
+		current[m.ID] = true
+	}
+	for id := range a.lastStatus {
+		if !current[id] {
+			delete(a.lastStatus, id)
+			delete(a.lastFired, id)
+		}
+	}
+
+	now := time.Now()
+	for _, m := range miners {
+		prev, seen := a.lastStatus[m.ID]
+		a.lastStatus[m.ID] = m.Status
+
+		if !fire || !seen || m.Status != "offline" || prev == "offline" {
+			continue
+		}
+		if last, ok := a.lastFired[m.ID]; ok && now.Sub(last) < a.cooldown {
+			continue
+		}
+
+		if err := a.send(m, now); err != nil {
+			log.Printf("Warning: offline alert for %s failed: %v", m.ID, err)
+			continue
+		}
+		a.lastFired[m.ID] = now
+		log.Printf("[alert] %s went offline (last seen %s)", m.ID, m.LastSeen.Format(time.RFC3339))
+	}
+}
+
+func (a *offlineAlerter) send(m *models.Miner, now time.Time) error {
+	body, err := json.Marshal(OfflineAlert{
+		Event:     "miner_offline",
+		MinerID:   m.ID,
+		WorkerID:  m.WorkerID,
+		Hostname:  m.Hostname,
+		IP:        m.IP,
+		CPUModel:  m.CPUModel,
+		LastSeen:  m.LastSeen,
+		Status:    m.Status,
+		Timestamp: now.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.Post(a.webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tarish-server/models"
+	"tarish-server/store"
+)
+
+func TestOfflineAlerterForgetsDeletedMiners(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer webhook.Close()
+
+	for _, id := range []string{"m1", "m2"} {
+		if err := st.UpsertMiner(&models.AgentReport{MinerID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := newOfflineAlerter(st, webhook.URL, time.Hour)
+	a.scan(false)
+	a.lastFired["m2"] = time.Now()
+
+	if _, err := st.DeleteMiner("m2"); err != nil {
+		t.Fatal(err)
+	}
+	a.scan(true)
+	if _, ok := a.lastStatus["m2"]; ok {
+		t.Error("lastStatus still tracks a deleted miner")
+	}
+	if _, ok := a.lastFired["m2"]; ok {
+		t.Error("lastFired still tracks a deleted miner")
+	}
+	if _, ok := a.lastStatus["m1"]; !ok {
+		t.Error("lastStatus dropped a miner that still exists")
+	}
+}
+
+func TestOfflineAlerterClose(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+
+	a := newOfflineAlerter(st, "http://127.0.0.1:1", time.Hour)
+	go a.run()
+
+	closed := make(chan struct{})
+	go func() {
+		a.Close()
+		a.Close() // safe to repeat
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not stop run")
+	}
+}
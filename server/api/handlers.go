@@ -1,34 +1,82 @@
 package api
 
 import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"tarish-server/models"
+	"tarish-server/proxy"
+	"tarish-server/store"
+	"tarish/config"
+	"tarish/xmrig/validate"
 )
 
+// errUnsupportedEncoding is returned by reportBody for a Content-Encoding
+// other than gzip
+var errUnsupportedEncoding = errors.New("unsupported Content-Encoding")
+
+// maxReportBody caps a decompressed report body; a full report with the
+// live config is a few KB, a batch of them a few hundred
+const maxReportBody = 16 << 20
+
+// reportBody returns an agent report's body, gunzipped if the agent sent
+// it with Content-Encoding: gzip
+func reportBody(r *http.Request) (io.Reader, error) {
+	switch enc := r.Header.Get("Content-Encoding"); enc {
+	case "", "identity":
+		return io.LimitReader(r.Body, maxReportBody), nil
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		return io.LimitReader(zr, maxReportBody), nil
+	default:
+		return nil, fmt.Errorf("%w %q", errUnsupportedEncoding, enc)
+	}
+}
+
 func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	body, err := reportBody(r)
+	if errors.Is(err, errUnsupportedEncoding) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
 	var report models.AgentReport
-	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+	if err := json.NewDecoder(body).Decode(&report); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if report.MinerID == "" && report.WorkerID == "" {
-		http.Error(w, "miner_id or worker_id required", http.StatusBadRequest)
+	if key := reportMinerID(&report); key != "" && !s.reportLimit.allow(key) {
+		http.Error(w, "too many reports", http.StatusTooManyRequests)
 		return
 	}
 
-	if err := s.store.UpsertMiner(&report); err != nil {
-		http.Error(w, "failed to store report", http.StatusInternalServerError)
+	id, err := s.ingestReport(&report)
+	if errors.Is(err, store.ErrMissingID) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	id := report.MinerID
-	if id == "" {
-		id = report.WorkerID
+	if err != nil {
+		http.Error(w, "failed to store report", http.StatusInternalServerError)
+		return
 	}
 
 	response := models.ReportResponse{OK: true}
@@ -38,10 +86,81 @@ func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
 		response.ConfigOverride = override
 		log.Printf("[report] dispatching config override to %s", id)
 	}
+	if settings, err := s.store.GetTarishOverride(id); err == nil && settings != nil {
+		response.TarishOverride = settings
+		log.Printf("[report] dispatching tarish settings to %s", id)
+	}
+	response.FetchConfig = s.liveConfigs.pending(id)
 
 	writeJSON(w, response)
 }
 
+// handleReportBatch ingests reports an agent buffered while the server was
+// unreachable. Invalid entries are skipped and counted as rejected. Each
+// report takes a token from its miner's rate limit like a single report;
+// once the limit is hit the rest of the batch is left unprocessed and
+// counted as limited, so the agent keeps it queued for a later flush.
+func (s *Server) handleReportBatch(w http.ResponseWriter, r *http.Request) {
+	body, err := reportBody(r)
+	if errors.Is(err, errUnsupportedEncoding) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	var reports []models.AgentReport
+	if err := json.NewDecoder(body).Decode(&reports); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response := models.BatchReportResponse{OK: true}
+	for i := range reports {
+		if key := reportMinerID(&reports[i]); key != "" && !s.reportLimit.allow(key) {
+			response.Limited = len(reports) - i
+			break
+		}
+		_, err := s.ingestReport(&reports[i])
+		if errors.Is(err, store.ErrMissingID) {
+			response.Rejected++
+			continue
+		}
+		if err != nil {
+			http.Error(w, "failed to store report", http.StatusInternalServerError)
+			return
+		}
+		response.Accepted++
+	}
+
+	if len(reports) > 0 {
+		log.Printf("[report] batch: %d accepted, %d rejected, %d rate-limited", response.Accepted, response.Rejected, response.Limited)
+	}
+	writeJSON(w, response)
+}
+
+// ingestReport stores a single agent report, returning the miner's ID.
+// Reports without an ID fail with store.ErrMissingID.
+func (s *Server) ingestReport(report *models.AgentReport) (string, error) {
+	if err := s.store.UpsertMiner(report); err != nil {
+		return "", err
+	}
+	return reportMinerID(report), nil
+}
+
+func reportMinerID(report *models.AgentReport) string {
+	if report.MinerID != "" {
+		return report.MinerID
+	}
+	return report.WorkerID
+}
+
+// handlePing lets agents check that the URL and agent key are correct.
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
 func (s *Server) handleGetMiners(w http.ResponseWriter, r *http.Request) {
 	miners, err := s.store.GetMiners()
 	if err != nil {
@@ -72,12 +191,12 @@ func (s *Server) handleGetMiner(w http.ResponseWriter, r *http.Request) {
 	// If there's a pending override, show it as the miner's config so the
 	// dashboard reflects the desired state immediately.
 	pending, err := s.store.GetConfigOverride(id)
-	if err == nil && pending != nil {
+	if err == nil && pending != nil && !models.IsConfigRevert(pending) {
 		miner.Config = pending
 	} else if miner.Config != nil {
 		// Override already applied — xmrig's live API strips some fields
 		// (e.g. max-threads-hint). Backfill them from the last override.
-		if last, err := s.store.GetLastOverride(id); err == nil && last != nil {
+		if last, err := s.store.GetLastOverride(id); err == nil && last != nil && !models.IsConfigRevert(last) {
 			backfillCPUFields(miner.Config, last)
 		}
 	}
@@ -85,6 +204,27 @@ func (s *Server) handleGetMiner(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, miner)
 }
 
+func (s *Server) handleDeleteMiner(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	history, err := s.store.DeleteMiner(id)
+	if errors.Is(err, store.ErrMinerNotFound) {
+		http.Error(w, "miner not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to delete miner", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[miners] deleted %s (%d history rows)", id, history)
+	writeJSON(w, map[string]interface{}{"ok": true, "history_deleted": history})
+}
+
 func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -97,8 +237,13 @@ func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
+	// Catch typos here rather than have the miner's xmrig reject the push
+	if err := validate.Config(override); err != nil {
+		http.Error(w, "invalid config: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := s.store.SetConfigOverride(id, override); err != nil {
+	if err := s.store.SetConfigOverride(id, override, s.changedBy(r)); err != nil {
 		http.Error(w, "failed to set config", http.StatusInternalServerError)
 		return
 	}
@@ -107,6 +252,83 @@ func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]interface{}{"ok": true})
 }
 
+// handleRevertConfig queues a revert marker in place of an override; the
+// agent answers it by reloading the selected config file into xmrig
+func (s *Server) handleRevertConfig(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	if miner, err := s.store.GetMiner(id); err != nil || miner == nil {
+		http.Error(w, "miner not found", http.StatusNotFound)
+		return
+	}
+
+	revert := map[string]interface{}{models.ConfigRevertKey: true}
+	if err := s.store.SetConfigOverride(id, revert, s.changedBy(r)); err != nil {
+		http.Error(w, "failed to queue config revert", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[config] queued revert to on-disk config for %s", id)
+	writeJSON(w, map[string]interface{}{"ok": true})
+}
+
+// Bounds for ?limit= on the override history
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
+func (s *Server) handleGetConfigHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxHistoryLimit)
+	}
+
+	entries, err := s.store.GetConfigOverrideHistory(id, limit)
+	if err != nil {
+		http.Error(w, "failed to get config history", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []*models.ConfigOverrideLogEntry{}
+	}
+	writeJSON(w, entries)
+}
+
+// changedBy is who made a change, for the config history: the admin user
+// when the dashboard is behind basic auth (adminMiddleware has checked the
+// credentials by now), else the address the request came from
+func (s *Server) changedBy(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok && s.adminUser != "" && user != "" {
+		return user
+	}
+	return clientAddr(r)
+}
+
+// clientAddr is the address a request came from
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func (s *Server) handleAckConfig(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -136,8 +358,38 @@ func (s *Server) handleGetPendingConfig(w http.ResponseWriter, r *http.Request)
 	if err == nil && override != nil {
 		response.ConfigOverride = override
 	}
+	if settings, err := s.store.GetTarishOverride(id); err == nil && settings != nil {
+		response.TarishOverride = settings
+	}
+	response.FetchConfig = s.liveConfigs.pending(id)
 
-	writeJSON(w, response)
+	// Agents poll this every few seconds and nearly always get the same
+	// answer; the ETag lets them skip the body when nothing changed
+	body, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Server) handleDeleteConfig(w http.ResponseWriter, r *http.Request) {
@@ -155,6 +407,84 @@ func (s *Server) handleDeleteConfig(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]interface{}{"ok": true})
 }
 
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := s.store.GetTarishSettings(id)
+	if err != nil {
+		http.Error(w, "failed to get settings", http.StatusInternalServerError)
+		return
+	}
+	if settings == nil {
+		settings = &models.TarishSettings{MinerID: id, Settings: map[string]interface{}{}}
+	}
+
+	writeJSON(w, settings)
+}
+
+func (s *Server) handleSetSettings(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	var settings map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	// The agent never acks settings it can't apply, so a bad one would be
+	// pushed to it forever
+	if err := config.ValidateRemoteSettings(settings); err != nil {
+		http.Error(w, "invalid settings: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.SetTarishOverride(id, settings); err != nil {
+		http.Error(w, "failed to set settings", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[settings] stored tarish settings for %s", id)
+	writeJSON(w, map[string]interface{}{"ok": true})
+}
+
+func (s *Server) handleAckSettings(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.MarkTarishOverrideApplied(id); err != nil {
+		http.Error(w, "failed to ack settings", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[settings] tarish settings acknowledged by %s", id)
+	writeJSON(w, map[string]interface{}{"ok": true})
+}
+
+func (s *Server) handleDeleteSettings(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.DeleteTarishOverride(id); err != nil {
+		http.Error(w, "failed to delete settings", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"ok": true})
+}
+
 func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
 	overview, err := s.store.GetOverview()
 	if err != nil {
@@ -165,20 +495,37 @@ func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, overview)
 }
 
-func (s *Server) handleHashrateHistory(w http.ResponseWriter, r *http.Request) {
-	minerID := r.URL.Query().Get("miner_id")
-	hoursStr := r.URL.Query().Get("hours")
+func (s *Server) handleConfigDrift(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.store.GetConfigDrift()
+	if err != nil {
+		http.Error(w, "failed to get config drift", http.StatusInternalServerError)
+		return
+	}
 
-	hours := 24
-	if hoursStr != "" {
-		if h, err := time.ParseDuration(hoursStr + "h"); err == nil {
-			hours = int(h.Hours())
-		}
+	if groups == nil {
+		groups = []*models.ConfigDriftGroup{}
 	}
 
-	since := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+	writeJSON(w, groups)
+}
 
-	history, err := s.store.GetHashrateHistory(minerID, since)
+func (s *Server) handleHashrateHistory(w http.ResponseWriter, r *http.Request) {
+	minerID := r.URL.Query().Get("miner_id")
+	since := historySince(r)
+
+	// ?bucket=1m|5m|1h|1d averages samples per bucket instead of returning raw points
+	var history []*models.HashrateHistory
+	var err error
+	if b := r.URL.Query().Get("bucket"); b != "" {
+		bucket, perr := parseBucket(b)
+		if perr != nil {
+			http.Error(w, perr.Error(), http.StatusBadRequest)
+			return
+		}
+		history, err = s.store.GetHashrateRollup(minerID, since, bucket)
+	} else {
+		history, err = s.store.GetHashrateHistory(minerID, since)
+	}
 	if err != nil {
 		http.Error(w, "failed to get history", http.StatusInternalServerError)
 		return
@@ -191,6 +538,67 @@ func (s *Server) handleHashrateHistory(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, history)
 }
 
+// handleHashrateHistoryCSV streams the same samples as the JSON history
+// endpoint as CSV, a page of rows at a time.
+func (s *Server) handleHashrateHistoryCSV(w http.ResponseWriter, r *http.Request) {
+	minerID := r.URL.Query().Get("miner_id")
+	since := historySince(r)
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="hashrate-history.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"miner_id", "timestamp", "current", "average", "max"})
+
+	err := s.store.EachHashrateSample(minerID, since, func(h *models.HashrateHistory) error {
+		return cw.Write([]string{
+			h.MinerID,
+			h.Timestamp.Format(time.RFC3339),
+			strconv.FormatFloat(h.Current, 'f', -1, 64),
+			strconv.FormatFloat(h.Average, 'f', -1, 64),
+			strconv.FormatFloat(h.Max, 'f', -1, 64),
+		})
+	})
+	cw.Flush()
+	if err != nil {
+		// Headers (and maybe rows) are already out; all we can do is log
+		log.Printf("[history] csv export failed: %v", err)
+	}
+}
+
+// historySince reads the ?hours= window (default 24) of a history request
+func historySince(r *http.Request) time.Time {
+	hours := 24
+	if hoursStr := r.URL.Query().Get("hours"); hoursStr != "" {
+		if h, err := time.ParseDuration(hoursStr + "h"); err == nil {
+			hours = int(h.Hours())
+		}
+	}
+	return time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+}
+
+// parseBucket parses a rollup bucket size: a Go duration ("5m", "1h") or
+// whole days ("1d"). Buckets shorter than a minute are rejected.
+func parseBucket(v string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid bucket %q", v)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, fmt.Errorf("invalid bucket %q", v)
+		}
+	}
+	if d < time.Minute {
+		return 0, fmt.Errorf("bucket must be at least 1m, got %q", v)
+	}
+	return d, nil
+}
+
 func (s *Server) handleProxySummary(w http.ResponseWriter, r *http.Request) {
 	if s.proxyClient == nil {
 		http.Error(w, "proxy not configured", http.StatusServiceUnavailable)
@@ -199,7 +607,7 @@ func (s *Server) handleProxySummary(w http.ResponseWriter, r *http.Request) {
 
 	summary, err := s.proxyClient.GetSummary()
 	if err != nil {
-		http.Error(w, "failed to get proxy summary: "+err.Error(), http.StatusBadGateway)
+		http.Error(w, "failed to get proxy summary: "+err.Error(), proxyErrorStatus(err))
 		return
 	}
 
@@ -214,13 +622,89 @@ func (s *Server) handleProxyWorkers(w http.ResponseWriter, r *http.Request) {
 
 	workers, err := s.proxyClient.GetWorkers()
 	if err != nil {
-		http.Error(w, "failed to get proxy workers: "+err.Error(), http.StatusBadGateway)
+		http.Error(w, "failed to get proxy workers: "+err.Error(), proxyErrorStatus(err))
 		return
 	}
 
 	writeJSON(w, workers)
 }
 
+func (s *Server) handleProxyHistory(w http.ResponseWriter, r *http.Request) {
+	hours := 24
+	if hoursStr := r.URL.Query().Get("hours"); hoursStr != "" {
+		if h, err := time.ParseDuration(hoursStr + "h"); err == nil {
+			hours = int(h.Hours())
+		}
+	}
+
+	since := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+
+	history, err := s.store.GetProxyHistory(since)
+	if err != nil {
+		http.Error(w, "failed to get proxy history", http.StatusInternalServerError)
+		return
+	}
+
+	if history == nil {
+		history = []*models.ProxyHistory{}
+	}
+
+	writeJSON(w, history)
+}
+
+func (s *Server) handleProxyReconcile(w http.ResponseWriter, r *http.Request) {
+	if s.proxyClient == nil {
+		http.Error(w, "proxy not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	workers, err := s.proxyClient.GetWorkers()
+	if err != nil {
+		http.Error(w, "failed to get proxy workers: "+err.Error(), proxyErrorStatus(err))
+		return
+	}
+
+	miners, err := s.store.GetMiners()
+	if err != nil {
+		http.Error(w, "failed to get miners", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, reconcileProxy(miners, workers))
+}
+
+// handleSetProxyToken updates the xmrig-proxy API token without a restart.
+// It needs the agent key configured: refused outright without one, and
+// without admin credentials the request must carry it.
+func (s *Server) handleSetProxyToken(w http.ResponseWriter, r *http.Request) {
+	if s.proxyClient == nil {
+		http.Error(w, "proxy not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	s.proxyClient.SetAccessToken(body.Token)
+	log.Printf("[proxy] access token updated")
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+// proxyErrorStatus maps proxy client errors to a response status: 503 when
+// the proxy can't be reached, 502 when it answered badly.
+func proxyErrorStatus(err error) int {
+	var unreachable *proxy.UnreachableError
+	if errors.As(err, &unreachable) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
 // backfillCPUFields copies fields that xmrig's live API strips (like
 // max-threads-hint) from the last override into the live config.
 func backfillCPUFields(live, override map[string]interface{}) {
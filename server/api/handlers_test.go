@@ -0,0 +1,309 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tarish-server/models"
+	"tarish-server/proxy"
+	"tarish-server/store"
+)
+
+func TestSetConfigRejectsInvalidOverride(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	srv := NewServer(st, nil, "")
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/miners/m1/config", strings.NewReader(body))
+		req.SetPathValue("id", "m1")
+		rec := httptest.NewRecorder()
+		srv.handleSetConfig(rec, req)
+		return rec
+	}
+
+	rec := put(`{"pools": [{"url": "pool:3333", "user": "4abc"}], "donate-level": 150}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "donate-level 150 out of range") {
+		t.Fatalf("invalid override: got %d %q, want 400 naming donate-level", rec.Code, rec.Body.String())
+	}
+	if override, _ := st.GetConfigOverride("m1"); override != nil {
+		t.Fatalf("invalid override was stored: %v", override)
+	}
+
+	if rec := put(`{"pools": [{"url": "pool:3333", "user": "4abc"}], "donate-level": 1}`); rec.Code != http.StatusOK {
+		t.Fatalf("valid override: got %d %q, want 200", rec.Code, rec.Body.String())
+	}
+	if override, _ := st.GetConfigOverride("m1"); override == nil {
+		t.Fatal("valid override was not stored")
+	}
+}
+
+func TestSetSettingsValidates(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	srv := NewServer(st, nil, "")
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/miners/m1/settings", strings.NewReader(body))
+		req.SetPathValue("id", "m1")
+		rec := httptest.NewRecorder()
+		srv.handleSetSettings(rec, req)
+		return rec
+	}
+
+	for body, want := range map[string]string{
+		`{"auto_updates": true}`:            `unknown setting "auto_updates"`,
+		`{"auto_update": "yes"}`:            "auto_update: expected true or false",
+		`{"check_interval_hours": 1.5}`:     "check_interval_hours",
+		`{"donate_level_floor": 101}`:       "donate_level_floor",
+		`{"tls-xmrig-proxy": null}`:         "tls-xmrig-proxy",
+		`{"donate_level_floor": 1, "x": 1}`: `unknown setting "x"`,
+	} {
+		rec := put(body)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("%s: got %d %q, want 400 mentioning %s", body, rec.Code, rec.Body.String(), want)
+		}
+	}
+	if settings, _ := st.GetTarishOverride("m1"); settings != nil {
+		t.Fatalf("invalid settings were stored: %v", settings)
+	}
+
+	if rec := put(`{"auto_update": true, "check_interval_hours": 6, "donate_level_floor": 1}`); rec.Code != http.StatusOK {
+		t.Fatalf("valid settings: got %d %q, want 200", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRevertConfigQueuesMarker(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	srv := NewServer(st, nil, "")
+
+	revert := func() int {
+		req := httptest.NewRequest("POST", "/api/miners/m1/config/revert", nil)
+		req.SetPathValue("id", "m1")
+		rec := httptest.NewRecorder()
+		srv.handleRevertConfig(rec, req)
+		return rec.Code
+	}
+
+	if code := revert(); code != http.StatusNotFound {
+		t.Fatalf("revert of unknown miner = %d, want 404", code)
+	}
+
+	st.UpsertMiner(&models.AgentReport{MinerID: "m1", Config: map[string]interface{}{"donate-level": 1.0}})
+	if code := revert(); code != http.StatusOK {
+		t.Fatalf("revert = %d, want 200", code)
+	}
+	pending, _ := st.GetConfigOverride("m1")
+	if !models.IsConfigRevert(pending) {
+		t.Fatalf("pending override = %v, want the revert marker", pending)
+	}
+
+	// the dashboard keeps showing the reported config, not the marker
+	req := httptest.NewRequest("GET", "/api/miners/m1", nil)
+	req.SetPathValue("id", "m1")
+	rec := httptest.NewRecorder()
+	srv.handleGetMiner(rec, req)
+	if strings.Contains(rec.Body.String(), models.ConfigRevertKey) {
+		t.Errorf("GetMiner exposed the revert marker as config: %s", rec.Body.String())
+	}
+}
+
+func TestReportAcceptsGzipBody(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	srv := NewServer(st, nil, "")
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"miner_id": "m1", "hostname": "rig1"}`))
+	zw.Close()
+
+	req := httptest.NewRequest("POST", "/api/report", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	srv.handleReport(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("gzip report = %d %q, want 200", rec.Code, rec.Body.String())
+	}
+	if m, err := st.GetMiner("m1"); err != nil || m.Hostname != "rig1" {
+		t.Fatalf("GetMiner = %+v, %v; want the decompressed report stored", m, err)
+	}
+
+	req = httptest.NewRequest("POST", "/api/report", strings.NewReader(`{}`))
+	req.Header.Set("Content-Encoding", "br")
+	rec = httptest.NewRecorder()
+	srv.handleReport(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("br report = %d, want 415", rec.Code)
+	}
+}
+
+func TestReportBatchIsRateLimited(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	srv := NewServer(st, nil, "")
+
+	// five reports against a burst of three: the last two wait
+	batch := `[{"miner_id": "m1"}, {"hostname": "no id"}, {"miner_id": "m1"}, {"miner_id": "m1"}, {"miner_id": "m1"}, {"miner_id": "m1"}]`
+	rec := httptest.NewRecorder()
+	srv.handleReportBatch(rec, httptest.NewRequest("POST", "/api/report/batch", strings.NewReader(batch)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("batch = %d %q, want 200", rec.Code, rec.Body.String())
+	}
+	var resp models.BatchReportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Accepted != 3 || resp.Rejected != 1 || resp.Limited != 2 {
+		t.Errorf("batch response = %+v, want 3 accepted, 1 rejected, 2 limited", resp)
+	}
+
+	// the limiter is shared with single reports
+	rec = httptest.NewRecorder()
+	srv.handleReport(rec, httptest.NewRequest("POST", "/api/report", strings.NewReader(`{"miner_id": "m1"}`)))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("report after a full batch = %d, want 429", rec.Code)
+	}
+}
+
+func TestPendingConfigETag(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	srv := NewServer(st, nil, "")
+
+	poll := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/miners/m1/config/pending", nil)
+		req.SetPathValue("id", "m1")
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		srv.handleGetPendingConfig(rec, req)
+		return rec
+	}
+
+	rec := poll("")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first poll = %d with ETag %q, want 200 and an ETag", rec.Code, etag)
+	}
+	if rec := poll(etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("unchanged poll = %d %q, want an empty 304", rec.Code, rec.Body.String())
+	}
+
+	st.SetConfigOverride("m1", map[string]interface{}{"donate-level": 1.0}, "")
+	rec = poll(etag)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "config_override") {
+		t.Fatalf("poll after override = %d %q, want 200 with the override", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Error("ETag did not change with the pending override")
+	}
+}
+
+func TestProxyErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unreachable", &proxy.UnreachableError{Err: errors.New("connection refused")}, http.StatusServiceUnavailable},
+		{"wrapped unreachable", fmt.Errorf("summary: %w", &proxy.UnreachableError{Err: errors.New("timeout")}), http.StatusServiceUnavailable},
+		{"5xx", &proxy.StatusError{StatusCode: 500}, http.StatusBadGateway},
+		{"4xx", &proxy.StatusError{StatusCode: 401}, http.StatusBadGateway},
+		{"bad body", errors.New("parse summary: unexpected EOF"), http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		if got := proxyErrorStatus(tt.err); got != tt.want {
+			t.Errorf("%s: proxyErrorStatus(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestProxySummaryErrors(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close() // nothing listens at its URL any more
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"proxy down", down.URL, http.StatusServiceUnavailable},
+		{"proxy error", failing.URL, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		pc := proxy.NewClientWithOptions(tt.url, "", proxy.Options{NoRetry: true})
+		rec := httptest.NewRecorder()
+		NewServer(nil, pc, "").handleProxySummary(rec, httptest.NewRequest("GET", "/api/proxy/summary", nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: GET /api/proxy/summary = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestSetConfigRecordsAdminUser(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	st.UpsertMiner(&models.AgentReport{MinerID: "m1"})
+
+	setBy := func(s *Server, auth func(*http.Request)) string {
+		body := `{"pools": [{"url": "pool:3333", "user": "4abc"}], "donate-level": 1}`
+		req := httptest.NewRequest("PUT", "/api/miners/m1/config", strings.NewReader(body))
+		if auth != nil {
+			auth(req)
+		}
+		rec := httptest.NewRecorder()
+		s.Routes().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("PUT config = %d %q", rec.Code, rec.Body.String())
+		}
+		history, err := st.GetConfigOverrideHistory("m1", 1)
+		if err != nil || len(history) == 0 {
+			t.Fatalf("GetConfigOverrideHistory = %v, %v", history, err)
+		}
+		return history[0].SetBy
+	}
+
+	admin := NewServerWithOptions(st, nil, "", Options{AdminUser: "alice", AdminPass: "hunter2"})
+	if got := setBy(admin, func(r *http.Request) { r.SetBasicAuth("alice", "hunter2") }); got != "alice" {
+		t.Errorf("set_by with basic auth = %q, want alice", got)
+	}
+	if got := setBy(NewServer(st, nil, ""), nil); got != "192.0.2.1" {
+		t.Errorf("set_by without admin auth = %q, want the client address", got)
+	}
+}
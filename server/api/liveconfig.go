@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"tarish-server/models"
+)
+
+// The server can't call agents, so a raw-config fetch is relayed through
+// their polling: GET /api/miners/{id}/raw-config flags the miner, the next
+// pending-config poll or report (every 30s) tells the agent fetch_config,
+// the agent POSTs xmrig's live config to /api/miners/{id}/config/report,
+// and the waiting GET returns it. The config poll runs every 3s after
+// activity but backs off to 30s on an idle agent, so expect anything from
+// a few seconds to one 30s interval plus two round-trips.
+
+// rawConfigWait bounds how long GET raw-config waits for the agent. It
+// outlasts the agent's longest gap between polls (maxConfigPollInterval
+// and the report heartbeat, both 30s) so an idle agent still answers.
+var rawConfigWait = 40 * time.Second
+
+// liveConfigs holds the configs agents sent on request and the miners with
+// a fetch outstanding. It is in memory only: a fetch is a debugging aid,
+// not state worth keeping across restarts.
+type liveConfigs struct {
+	mu      sync.Mutex
+	latest  map[string]*models.RawConfigResponse
+	waiting map[string]*liveFetch
+}
+
+// liveFetch is an outstanding fetch and the requests waiting on it
+type liveFetch struct {
+	arrived chan struct{} // closed when the miner's config arrives
+	waiters int
+}
+
+func newLiveConfigs() *liveConfigs {
+	return &liveConfigs{
+		latest:  make(map[string]*models.RawConfigResponse),
+		waiting: make(map[string]*liveFetch),
+	}
+}
+
+// request flags id for a fetch and returns a channel that is closed when
+// its config arrives. Concurrent requests for one miner share the fetch.
+// The caller must call release when it stops waiting; the last waiter to
+// give up withdraws the fetch, so an agent that never answers isn't left
+// flagged forever.
+func (c *liveConfigs) request(id string) (arrived <-chan struct{}, release func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fetch, ok := c.waiting[id]
+	if !ok {
+		fetch = &liveFetch{arrived: make(chan struct{})}
+		c.waiting[id] = fetch
+	}
+	fetch.waiters++
+	return fetch.arrived, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		fetch.waiters--
+		if fetch.waiters == 0 && c.waiting[id] == fetch {
+			delete(c.waiting, id)
+		}
+	}
+}
+
+// pending reports whether id's agent should send its live config
+func (c *liveConfigs) pending(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.waiting[id]
+	return ok
+}
+
+// put records a config from id's agent and wakes its waiters
+func (c *liveConfigs) put(id string, cfg map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.latest[id] = &models.RawConfigResponse{Config: cfg, FetchedAt: time.Now().UTC()}
+	if fetch, ok := c.waiting[id]; ok {
+		close(fetch.arrived)
+		delete(c.waiting, id)
+	}
+}
+
+// get returns the freshest config id's agent sent, or nil
+func (c *liveConfigs) get(id string) *models.RawConfigResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	latest := c.latest[id]
+	if latest == nil {
+		return nil
+	}
+	resp := *latest
+	return &resp
+}
+
+// handleGetRawConfig fetches the miner's live xmrig config through its
+// agent (see above). If the agent doesn't answer within rawConfigWait, the
+// last fetched config is returned with "stale": true, or 504 if there is
+// none.
+func (s *Server) handleGetRawConfig(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	if miner, err := s.store.GetMiner(id); err != nil || miner == nil {
+		http.Error(w, "miner not found", http.StatusNotFound)
+		return
+	}
+
+	arrived, release := s.liveConfigs.request(id)
+	defer release()
+	timer := time.NewTimer(rawConfigWait)
+	defer timer.Stop()
+
+	stale := false
+	select {
+	case <-arrived:
+	case <-timer.C:
+		stale = true
+	case <-r.Context().Done():
+		return
+	}
+
+	resp := s.liveConfigs.get(id)
+	if resp == nil {
+		http.Error(w, "miner did not send its config in time (agent offline or not polling)", http.StatusGatewayTimeout)
+		return
+	}
+	resp.Stale = stale
+	writeJSON(w, resp)
+}
+
+// handleReportLiveConfig receives the live config an agent sends in answer
+// to fetch_config
+func (s *Server) handleReportLiveConfig(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+
+	var cfg map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil || cfg == nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	s.liveConfigs.put(id, cfg)
+	log.Printf("[config] received live config from %s", id)
+	writeJSON(w, map[string]interface{}{"ok": true})
+}
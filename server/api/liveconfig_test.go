@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tarish-server/models"
+	"tarish-server/store"
+)
+
+func TestRawConfigFetchedThroughAgentPoll(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+	srv := NewServer(st, nil, "")
+	st.UpsertMiner(&models.AgentReport{MinerID: "m1"})
+
+	fetch := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/miners/m1/raw-config", nil)
+		req.SetPathValue("id", "m1")
+		rec := httptest.NewRecorder()
+		srv.handleGetRawConfig(rec, req)
+		return rec
+	}
+	poll := func() models.ReportResponse {
+		req := httptest.NewRequest("GET", "/api/miners/m1/config/pending", nil)
+		req.SetPathValue("id", "m1")
+		rec := httptest.NewRecorder()
+		srv.handleGetPendingConfig(rec, req)
+		var resp models.ReportResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+
+	if poll().FetchConfig {
+		t.Fatal("fetch_config set before anyone asked")
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- fetch() }()
+
+	// play the agent: wait for the marker, then send the live config
+	deadline := time.Now().Add(5 * time.Second)
+	for !poll().FetchConfig {
+		if time.Now().After(deadline) {
+			t.Fatal("fetch_config never appeared in the pending poll")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	req := httptest.NewRequest("POST", "/api/miners/m1/config/report", strings.NewReader(`{"donate-level": 1}`))
+	req.SetPathValue("id", "m1")
+	srv.handleReportLiveConfig(httptest.NewRecorder(), req)
+
+	rec := <-done
+	var got models.RawConfigResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("raw-config = %d %q", rec.Code, rec.Body.String())
+	}
+	if got.Config["donate-level"] != 1.0 || got.Stale {
+		t.Errorf("raw-config = %+v, want the fresh live config", got)
+	}
+	if poll().FetchConfig {
+		t.Error("fetch_config still set after the agent answered")
+	}
+
+	// an agent that stops answering gets the last config back, marked stale
+	origWait := rawConfigWait
+	rawConfigWait = 10 * time.Millisecond
+	defer func() { rawConfigWait = origWait }()
+	rec = fetch()
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || !got.Stale {
+		t.Errorf("unanswered fetch = %d %q, want the previous config marked stale", rec.Code, rec.Body.String())
+	}
+	if poll().FetchConfig {
+		t.Error("fetch_config still set after the request timed out")
+	}
+}
+
+func TestLiveConfigsRelease(t *testing.T) {
+	c := newLiveConfigs()
+
+	// a fetch stays flagged while anyone waits on it
+	_, releaseA := c.request("m1")
+	_, releaseB := c.request("m1")
+	releaseA()
+	if !c.pending("m1") {
+		t.Fatal("fetch withdrawn while a request still waits on it")
+	}
+	releaseB()
+	if c.pending("m1") {
+		t.Fatal("fetch still flagged after every request gave up")
+	}
+
+	// releasing after the config arrived leaves a newer fetch alone
+	_, releaseOld := c.request("m1")
+	c.put("m1", map[string]interface{}{})
+	_, releaseNew := c.request("m1")
+	defer releaseNew()
+	releaseOld()
+	if !c.pending("m1") {
+		t.Error("an answered request withdrew a newer fetch")
+	}
+}
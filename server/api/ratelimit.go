@@ -0,0 +1,78 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// reportInterval is the sustained rate allowed per miner on /api/report.
+	reportInterval = 10 * time.Second
+	// reportBurst lets an agent that just restarted send a few reports back to back.
+	reportBurst = 3
+)
+
+// rateLimiter is a token bucket per key (miner ID).
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    float64
+	buckets  map[string]*bucket
+	lastGC   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(interval time.Duration, burst int) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		burst:    float64(burst),
+		buckets:  make(map[string]*bucket),
+		lastGC:   time.Now(),
+	}
+}
+
+// allow reports whether key may proceed, consuming a token if so.
+func (rl *rateLimiter) allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.gc(now)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) / float64(rl.interval)
+	if b.tokens > rl.burst {
+		b.tokens = rl.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// gc drops buckets that have refilled completely so miners that went away
+// don't accumulate forever. Caller holds rl.mu.
+func (rl *rateLimiter) gc(now time.Time) {
+	full := rl.interval * time.Duration(rl.burst)
+	if now.Sub(rl.lastGC) < full {
+		return
+	}
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) >= full {
+			delete(rl.buckets, key)
+		}
+	}
+	rl.lastGC = now
+}
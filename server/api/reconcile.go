@@ -0,0 +1,91 @@
+package api
+
+import (
+	"strings"
+
+	"tarish-server/models"
+	"tarish-server/proxy"
+)
+
+// reconcileProxy matches proxy workers to stored miners, first by worker
+// name (against worker/miner id, name or hostname), then by IP. Each
+// worker is matched to at most one miner.
+func reconcileProxy(miners []*models.Miner, workers []proxy.ProxyWorker) *models.ProxyReconcileResponse {
+	resp := &models.ProxyReconcileResponse{
+		Miners:           []*models.ReconciledMiner{},
+		UnmatchedWorkers: []*models.ProxyWorkerView{},
+	}
+
+	byName := make(map[string]int)
+	byIP := make(map[string][]int)
+	for i, w := range workers {
+		if w.Name != "" {
+			byName[strings.ToLower(w.Name)] = i
+		}
+		if w.IP != "" {
+			byIP[w.IP] = append(byIP[w.IP], i)
+		}
+	}
+	used := make([]bool, len(workers))
+
+	for _, m := range miners {
+		rm := &models.ReconciledMiner{
+			ID:       m.ID,
+			Name:     m.Name,
+			Hostname: m.Hostname,
+			IP:       m.IP,
+			Status:   m.Status,
+		}
+
+		idx := -1
+		for _, key := range []string{m.WorkerID, m.MinerID, m.Name, m.Hostname} {
+			if key == "" {
+				continue
+			}
+			if i, ok := byName[strings.ToLower(key)]; ok && !used[i] {
+				idx = i
+				rm.MatchedBy = "worker"
+				break
+			}
+		}
+		if idx < 0 && m.IP != "" {
+			for _, i := range byIP[m.IP] {
+				if !used[i] {
+					idx = i
+					rm.MatchedBy = "ip"
+					break
+				}
+			}
+		}
+
+		if idx >= 0 {
+			used[idx] = true
+			rm.ProxyConnected = true
+			rm.Worker = workerView(workers[idx])
+			resp.Connected++
+		} else if m.Status == "online" {
+			resp.Independent++
+		}
+		resp.Miners = append(resp.Miners, rm)
+	}
+
+	for i, w := range workers {
+		if !used[i] {
+			resp.UnmatchedWorkers = append(resp.UnmatchedWorkers, workerView(w))
+		}
+	}
+	return resp
+}
+
+func workerView(w proxy.ProxyWorker) *models.ProxyWorkerView {
+	v := &models.ProxyWorkerView{
+		Name:     w.Name,
+		IP:       w.IP,
+		Accepted: w.Accepted,
+		Rejected: w.Rejected,
+	}
+	if len(w.Hashrate) > 0 {
+		v.Hashrate = w.Hashrate[0]
+	}
+	return v
+}
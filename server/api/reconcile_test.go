@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+
+	"tarish-server/models"
+	"tarish-server/proxy"
+)
+
+func TestReconcileProxy(t *testing.T) {
+	miners := []*models.Miner{
+		{ID: "a", WorkerID: "rig-a", IP: "10.0.0.1", Status: "online"},
+		{ID: "b", Hostname: "rig-b", IP: "10.0.0.2", Status: "online"},
+		{ID: "c", IP: "10.0.0.3", Status: "online"},
+		{ID: "d", IP: "10.0.0.4", Status: "offline"},
+	}
+	workers := []proxy.ProxyWorker{
+		{Name: "RIG-A", IP: "203.0.113.9", Hashrate: []float64{1200}},
+		{Name: "x", IP: "10.0.0.2"},
+		{Name: "stranger", IP: "10.9.9.9"},
+	}
+
+	resp := reconcileProxy(miners, workers)
+
+	want := map[string]string{"a": "worker", "b": "ip", "c": "", "d": ""}
+	for _, m := range resp.Miners {
+		if m.MatchedBy != want[m.ID] {
+			t.Errorf("miner %s matched by %q, want %q", m.ID, m.MatchedBy, want[m.ID])
+		}
+		if m.ProxyConnected != (want[m.ID] != "") {
+			t.Errorf("miner %s proxy_connected = %v", m.ID, m.ProxyConnected)
+		}
+	}
+	if resp.Connected != 2 || resp.Independent != 1 {
+		t.Errorf("connected/independent = %d/%d, want 2/1", resp.Connected, resp.Independent)
+	}
+	if len(resp.UnmatchedWorkers) != 1 || resp.UnmatchedWorkers[0].Name != "stranger" {
+		t.Errorf("unmatched workers = %+v, want [stranger]", resp.UnmatchedWorkers)
+	}
+}
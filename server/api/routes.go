@@ -1,43 +1,156 @@
 package api
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"log"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"tarish-server/proxy"
 	"tarish-server/store"
 )
 
 type Server struct {
-	store       *store.Store
+	store       store.Store
 	proxyClient *proxy.Client
 	agentKey    string
+	adminUser   string
+	adminPass   string
+	corsOrigins []string
+	accessLog   bool
+	reportLimit *rateLimiter
+	liveConfigs *liveConfigs
 }
 
-func NewServer(s *store.Store, pc *proxy.Client, agentKey string) *Server {
-	return &Server{store: s, proxyClient: pc, agentKey: agentKey}
+// Options configures optional Server behaviour
+type Options struct {
+	// AdminUser and AdminPass protect the dashboard routes with HTTP basic
+	// auth. Leaving them empty keeps the dashboard open, for local-only
+	// deployments.
+	AdminUser string
+	AdminPass string
+	// CORSOrigins lists the origins allowed to call the API from a browser.
+	// Empty (or containing "*") allows any origin.
+	CORSOrigins []string
+	// AccessLog logs every API request with its status and duration
+	AccessLog bool
+}
+
+func NewServer(s store.Store, pc *proxy.Client, agentKey string) *Server {
+	return NewServerWithOptions(s, pc, agentKey, Options{})
+}
+
+// NewServerWithOptions is NewServer with optional settings
+func NewServerWithOptions(s store.Store, pc *proxy.Client, agentKey string, opts Options) *Server {
+	return &Server{
+		store:       s,
+		proxyClient: pc,
+		agentKey:    agentKey,
+		adminUser:   opts.AdminUser,
+		adminPass:   opts.AdminPass,
+		corsOrigins: opts.CORSOrigins,
+		accessLog:   opts.AccessLog,
+		reportLimit: newRateLimiter(reportInterval, reportBurst),
+		liveConfigs: newLiveConfigs(),
+	}
 }
 
 func (s *Server) Routes() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("POST /api/report", s.authMiddleware(s.handleReport))
-	mux.HandleFunc("GET /api/miners", s.handleGetMiners)
-	mux.HandleFunc("GET /api/miners/{id}", s.handleGetMiner)
-	mux.HandleFunc("PUT /api/miners/{id}/config", s.handleSetConfig)
+	mux.HandleFunc("POST /api/report/batch", s.authMiddleware(s.handleReportBatch))
+	mux.HandleFunc("GET /api/ping", s.authMiddleware(s.handlePing))
+	mux.HandleFunc("GET /api/miners", s.adminMiddleware(s.handleGetMiners))
+	mux.HandleFunc("GET /api/miners/{id}", s.adminMiddleware(s.handleGetMiner))
+	mux.HandleFunc("DELETE /api/miners/{id}", s.adminMiddleware(s.handleDeleteMiner))
+	mux.HandleFunc("PUT /api/miners/{id}/config", s.adminMiddleware(s.handleSetConfig))
+	mux.HandleFunc("POST /api/miners/{id}/config/revert", s.adminMiddleware(s.handleRevertConfig))
+	mux.HandleFunc("GET /api/miners/{id}/config/history", s.adminMiddleware(s.handleGetConfigHistory))
 	mux.HandleFunc("GET /api/miners/{id}/config/pending", s.authMiddleware(s.handleGetPendingConfig))
 	mux.HandleFunc("POST /api/miners/{id}/config/ack", s.authMiddleware(s.handleAckConfig))
-	mux.HandleFunc("DELETE /api/miners/{id}/config", s.handleDeleteConfig)
-	mux.HandleFunc("GET /api/overview", s.handleOverview)
-	mux.HandleFunc("GET /api/hashrate/history", s.handleHashrateHistory)
-	mux.HandleFunc("GET /api/proxy/summary", s.handleProxySummary)
-	mux.HandleFunc("GET /api/proxy/workers", s.handleProxyWorkers)
+	mux.HandleFunc("POST /api/miners/{id}/config/report", s.authMiddleware(s.handleReportLiveConfig))
+	mux.HandleFunc("GET /api/miners/{id}/raw-config", s.adminMiddleware(s.handleGetRawConfig))
+	mux.HandleFunc("DELETE /api/miners/{id}/config", s.adminMiddleware(s.handleDeleteConfig))
+	mux.HandleFunc("GET /api/miners/{id}/settings", s.adminMiddleware(s.handleGetSettings))
+	mux.HandleFunc("PUT /api/miners/{id}/settings", s.adminMiddleware(s.handleSetSettings))
+	mux.HandleFunc("POST /api/miners/{id}/settings/ack", s.authMiddleware(s.handleAckSettings))
+	mux.HandleFunc("DELETE /api/miners/{id}/settings", s.adminMiddleware(s.handleDeleteSettings))
+	mux.HandleFunc("GET /api/config/drift", s.adminMiddleware(s.handleConfigDrift))
+	mux.HandleFunc("GET /api/overview", s.adminMiddleware(s.handleOverview))
+	mux.HandleFunc("GET /api/hashrate/history", s.adminMiddleware(s.handleHashrateHistory))
+	mux.HandleFunc("GET /api/hashrate/history.csv", s.adminMiddleware(s.handleHashrateHistoryCSV))
+	mux.HandleFunc("GET /api/proxy/summary", s.adminMiddleware(s.handleProxySummary))
+	mux.HandleFunc("GET /api/proxy/workers", s.adminMiddleware(s.handleProxyWorkers))
+	mux.HandleFunc("GET /api/proxy/reconcile", s.adminMiddleware(s.handleProxyReconcile))
+	mux.HandleFunc("GET /api/proxy/history", s.adminMiddleware(s.handleProxyHistory))
+	mux.HandleFunc("POST /api/proxy/token", s.adminMiddleware(s.handleSetProxyToken))
+
+	handler := corsMiddleware(s.corsOrigins, mux)
+	if s.accessLog {
+		handler = accessLogMiddleware(handler)
+	}
+	return handler
+}
+
+// statusRecorder captures the status code a handler writes
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
 
-	return corsMiddleware(mux)
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
+// accessLogMiddleware logs method, path, status, duration and client
+// address for each request
+func accessLogMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK // handler wrote nothing
+		}
+		log.Printf("[access] %s %s %d %v %s", r.Method, r.URL.Path, rec.status,
+			time.Since(start).Round(time.Microsecond), clientAddr(r))
+	})
+}
+
+// corsMiddleware allows any origin when origins is empty or contains "*".
+// Otherwise the request's Origin is echoed back only if it is listed, and
+// other origins get no CORS headers, so browsers block their reads.
+func corsMiddleware(origins []string, next http.Handler) http.Handler {
+	wildcard := len(origins) == 0 || slices.Contains(origins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(origins, origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
@@ -52,13 +165,62 @@ func corsMiddleware(next http.Handler) http.Handler {
 
 func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if s.agentKey != "" {
-			token := r.Header.Get("Authorization")
-			if token != "Bearer "+s.agentKey {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
-				return
-			}
+		if s.agentKey != "" && !s.agentAuthorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
 		}
 		next(w, r)
 	}
 }
+
+// agentAuthorized reports whether r carries the agent key as a bearer
+// token, compared in constant time. Always false when no agent key is
+// configured or the header isn't a bearer token.
+func (s *Server) agentAuthorized(r *http.Request) bool {
+	if s.agentKey == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.agentKey)) == 1
+}
+
+// adminMiddleware guards dashboard routes with basic auth when admin
+// credentials are configured. Agents may still read with their key, which
+// 'tarish status --remote' relies on.
+func (s *Server) adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminUser == "" {
+			next(w, r)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); ok && s.adminAuthorized(user, pass) {
+			next(w, r)
+			return
+		}
+		if r.Method == http.MethodGet && s.agentAuthorized(r) {
+			next(w, r)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="tarish", charset="UTF-8"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}
+}
+
+// adminAuthorized compares credentials in constant time; hashing first
+// keeps the comparison from leaking their lengths
+func (s *Server) adminAuthorized(user, pass string) bool {
+	userSum, wantUser := sha256.Sum256([]byte(user)), sha256.Sum256([]byte(s.adminUser))
+	passSum, wantPass := sha256.Sum256([]byte(pass)), sha256.Sum256([]byte(s.adminPass))
+	userOK := subtle.ConstantTimeCompare(userSum[:], wantUser[:])
+	passOK := subtle.ConstantTimeCompare(passSum[:], wantPass[:])
+	return userOK&passOK == 1
+}
+
+// RequireAdmin wraps a non-API handler, such as the frontend, in the same
+// admin auth as the dashboard routes
+func (s *Server) RequireAdmin(h http.Handler) http.Handler {
+	return s.adminMiddleware(h.ServeHTTP)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tarish-server/proxy"
+	"tarish-server/store"
+)
+
+func TestAdminAuth(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+
+	routes := NewServerWithOptions(st, nil, "agent-key", Options{AdminUser: "admin", AdminPass: "hunter2"}).Routes()
+	do := func(method, path string, auth func(*http.Request)) int {
+		req := httptest.NewRequest(method, path, nil)
+		if auth != nil {
+			auth(req)
+		}
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	basic := func(user, pass string) func(*http.Request) {
+		return func(r *http.Request) { r.SetBasicAuth(user, pass) }
+	}
+	bearer := func(r *http.Request) { r.Header.Set("Authorization", "Bearer agent-key") }
+
+	tests := []struct {
+		name         string
+		method, path string
+		auth         func(*http.Request)
+		want         int
+	}{
+		{"no credentials", "GET", "/api/miners", nil, http.StatusUnauthorized},
+		{"wrong password", "GET", "/api/miners", basic("admin", "nope"), http.StatusUnauthorized},
+		{"admin", "GET", "/api/miners", basic("admin", "hunter2"), http.StatusOK},
+		{"agent may read", "GET", "/api/miners", bearer, http.StatusOK},
+		{"agent may not write", "DELETE", "/api/miners/m1", bearer, http.StatusUnauthorized},
+		{"agent routes keep the agent key", "GET", "/api/ping", bearer, http.StatusOK},
+		{"admin is not an agent", "GET", "/api/ping", basic("admin", "hunter2"), http.StatusUnauthorized},
+		{"proxy token needs admin", "POST", "/api/proxy/token", nil, http.StatusUnauthorized},
+		{"agent may not set the proxy token", "POST", "/api/proxy/token", bearer, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := do(tt.method, tt.path, tt.auth); got != tt.want {
+			t.Errorf("%s: %s %s = %d, want %d", tt.name, tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	open := NewServer(st, nil, "").Routes()
+	rec := httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest("GET", "/api/miners", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("without admin credentials configured GET /api/miners = %d, want 200", rec.Code)
+	}
+}
+
+func TestSetProxyTokenAdminOnly(t *testing.T) {
+	post := func(s *Server, auth func(*http.Request)) int {
+		req := httptest.NewRequest("POST", "/api/proxy/token", strings.NewReader(`{"token":"new"}`))
+		if auth != nil {
+			auth(req)
+		}
+		rec := httptest.NewRecorder()
+		s.Routes().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	pc := proxy.NewClient("http://127.0.0.1:1", "old")
+
+	// Like every dashboard route, it's open when no admin is configured,
+	// whether or not agents use a key
+	if got := post(NewServer(nil, pc, ""), nil); got != http.StatusOK {
+		t.Errorf("without admin or agent key = %d, want 200", got)
+	}
+	if got := post(NewServer(nil, pc, "agent-key"), nil); got != http.StatusOK {
+		t.Errorf("without admin, agent key configured = %d, want 200", got)
+	}
+
+	admin := NewServerWithOptions(nil, pc, "agent-key", Options{AdminUser: "admin", AdminPass: "hunter2"})
+	if got := post(admin, func(r *http.Request) { r.SetBasicAuth("admin", "hunter2") }); got != http.StatusOK {
+		t.Errorf("with admin credentials = %d, want 200", got)
+	}
+	if got := post(admin, func(r *http.Request) { r.Header.Set("Authorization", "Bearer agent-key") }); got != http.StatusUnauthorized {
+		t.Errorf("with only the agent key = %d, want 401", got)
+	}
+}
+
+func TestCORSAllowlist(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	origin := func(h http.Handler, from string) string {
+		req := httptest.NewRequest("GET", "/api/miners", nil)
+		req.Header.Set("Origin", from)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := origin(corsMiddleware(nil, ok), "https://evil.example"); got != "*" {
+		t.Errorf("default allow-origin = %q, want *", got)
+	}
+
+	allow := corsMiddleware([]string{"https://dash.example.com"}, ok)
+	if got := origin(allow, "https://dash.example.com"); got != "https://dash.example.com" {
+		t.Errorf("listed origin got allow-origin %q, want it echoed", got)
+	}
+	if got := origin(allow, "https://evil.example"); got != "" {
+		t.Errorf("unlisted origin got allow-origin %q, want none", got)
+	}
+}
+
+func TestAgentAuthorized(t *testing.T) {
+	s := &Server{agentKey: "agent-key"}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"Bearer agent-key", true},
+		{"Bearer agent-keyx", false},
+		{"Bearer ", false},
+		{"agent-key", false}, // missing prefix
+		{"Basic agent-key", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/ping", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		if got := s.agentAuthorized(req); got != tt.want {
+			t.Errorf("agentAuthorized(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+
+	if (&Server{}).agentAuthorized(httptest.NewRequest("GET", "/api/ping", nil)) {
+		t.Error("agentAuthorized with no agent key configured should be false")
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	h := accessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusTeapot)
+	}))
+	req := httptest.NewRequest("GET", "/api/miners", nil)
+	req.RemoteAddr = "10.0.0.5:51234"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if line := buf.String(); !strings.Contains(line, "[access] GET /api/miners 418 ") || !strings.HasSuffix(strings.TrimSpace(line), "10.0.0.5") {
+		t.Errorf("access log line = %q", line)
+	}
+}
@@ -3,3 +3,16 @@ module tarish-server
 go 1.22
 
 require github.com/mattn/go-sqlite3 v1.14.24
+
+require github.com/lib/pq v1.10.9
+
+require (
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
+
+// tarish/xmrig/validate is shared with the client; build from the repo
+require tarish v0.0.0
+
+replace tarish => ../
@@ -12,6 +12,8 @@ type Miner struct {
 	ID            string                 `json:"id"`
 	MinerID       string                 `json:"miner_id"`
 	WorkerID      string                 `json:"worker_id"`
+	Name          string                 `json:"name"`
+	Tag           string                 `json:"tag"` // groups rigs meant to run the same config
 	Hostname      string                 `json:"hostname"`
 	IP            string                 `json:"ip"`
 	CPUModel      string                 `json:"cpu_model"`
@@ -21,9 +23,17 @@ type Miner struct {
 	Arch          string                 `json:"arch"`
 	XmrigVersion  string                 `json:"xmrig_version"`
 	TarishVersion string                 `json:"tarish_version"`
+	Algo          string                 `json:"algo"`
 	UptimeSeconds int64                  `json:"uptime_seconds"`
 	Hashrate      *HashrateData          `json:"hashrate,omitempty"`
+	Accepted      int64                  `json:"accepted"` // shares since xmrig started
+	Rejected      int64                  `json:"rejected"`
 	Config        map[string]interface{} `json:"config,omitempty"`
+	ConfigHash    string                 `json:"config_hash"`
+	ConfigName    string                 `json:"config_name"`           // tarish config template, e.g. "m3.json"
+	UsingFallback bool                   `json:"using_fallback_config"` // no tuned config for the CPU family
+	Hugepages     bool                   `json:"hugepages_enabled"`     // xmrig got all the huge pages it asked for
+	MSR           bool                   `json:"msr_enabled"`           // RandomX MSR mod available
 	LastSeen      time.Time              `json:"last_seen"`
 	Status        string                 `json:"status"` // online, stale, offline
 }
@@ -35,6 +45,37 @@ type ConfigOverride struct {
 	AppliedAt *time.Time             `json:"applied_at,omitempty"`
 }
 
+// ConfigRevertKey marks a config override that tells the agent to drop
+// live edits and reload the miner's selected on-disk config
+const ConfigRevertKey = "_tarish_revert"
+
+// IsConfigRevert reports whether override is a revert request
+func IsConfigRevert(override map[string]interface{}) bool {
+	revert, _ := override[ConfigRevertKey].(bool)
+	return revert
+}
+
+// ConfigOverrideLogEntry is one config override as it was pushed. Unlike
+// the pending override, entries are never replaced, so they form an audit
+// trail of dashboard edits.
+type ConfigOverrideLogEntry struct {
+	ID        int64                  `json:"id"`
+	MinerID   string                 `json:"miner_id"`
+	Override  map[string]interface{} `json:"override"`
+	SetBy     string                 `json:"set_by,omitempty"` // client address of whoever pushed it
+	CreatedAt time.Time              `json:"created_at"`
+	AppliedAt *time.Time             `json:"applied_at,omitempty"`
+}
+
+// TarishSettings are tarish-level settings (not xmrig config) the server
+// pushes to an agent, e.g. {"auto_update": true, "donate_level_floor": 1}.
+type TarishSettings struct {
+	MinerID   string                 `json:"miner_id"`
+	Settings  map[string]interface{} `json:"settings"`
+	CreatedAt time.Time              `json:"created_at"`
+	AppliedAt *time.Time             `json:"applied_at,omitempty"`
+}
+
 type HashrateHistory struct {
 	MinerID   string    `json:"miner_id"`
 	Timestamp time.Time `json:"timestamp"`
@@ -43,17 +84,76 @@ type HashrateHistory struct {
 	Max       float64   `json:"max"`
 }
 
+// ProxyHistory is a fleet-wide hashrate sample from xmrig-proxy
+type ProxyHistory struct {
+	Timestamp time.Time `json:"timestamp"`
+	Current   float64   `json:"current"` // 10s
+	Average   float64   `json:"average"` // 60s
+	Workers   int       `json:"workers"`
+}
+
 type OverviewResponse struct {
-	TotalHashrate   float64  `json:"total_hashrate"`
-	AverageHashrate float64  `json:"average_hashrate"`
-	ActiveMiners    int      `json:"active_miners"`
-	TotalMiners     int      `json:"total_miners"`
-	TopMiners       []*Miner `json:"top_miners"`
+	TotalHashrate     float64  `json:"total_hashrate"`
+	AverageHashrate   float64  `json:"average_hashrate"`
+	ActiveMiners      int      `json:"active_miners"`
+	TotalMiners       int      `json:"total_miners"`
+	TotalAccepted     int64    `json:"total_accepted"` // lifetime shares across online miners
+	TotalRejected     int64    `json:"total_rejected"`
+	RejectRate        float64  `json:"reject_rate"`         // percent of shares rejected in the last RejectRateMinutes
+	RejectRateMinutes int      `json:"reject_rate_minutes"` // window RejectRate covers
+	TopMiners         []*Miner `json:"top_miners"`
+}
+
+// ProxyWorkerView is the subset of an xmrig-proxy worker shown in reconciliation
+type ProxyWorkerView struct {
+	Name     string  `json:"name"`
+	IP       string  `json:"ip"`
+	Hashrate float64 `json:"hashrate"` // first hashrate window reported by the proxy
+	Accepted int64   `json:"accepted"`
+	Rejected int64   `json:"rejected"`
+}
+
+type ReconciledMiner struct {
+	ID             string           `json:"id"`
+	Name           string           `json:"name"`
+	Hostname       string           `json:"hostname"`
+	IP             string           `json:"ip"`
+	Status         string           `json:"status"`
+	ProxyConnected bool             `json:"proxy_connected"`
+	MatchedBy      string           `json:"matched_by,omitempty"` // worker, ip
+	Worker         *ProxyWorkerView `json:"worker,omitempty"`
+}
+
+// ProxyReconcileResponse merges agent-reported miners with proxy workers.
+// Miners that are online but not connected to the proxy are "independent".
+type ProxyReconcileResponse struct {
+	Miners           []*ReconciledMiner `json:"miners"`
+	Connected        int                `json:"connected"`
+	Independent      int                `json:"independent"`
+	UnmatchedWorkers []*ProxyWorkerView `json:"unmatched_workers"`
+}
+
+type ConfigDriftOutlier struct {
+	ID         string `json:"id"`
+	Hostname   string `json:"hostname"`
+	ConfigHash string `json:"config_hash"`
+	Status     string `json:"status"`
+}
+
+type ConfigDriftGroup struct {
+	CPUFamily    string                `json:"cpu_family"`
+	Tag          string                `json:"tag"`
+	Miners       int                   `json:"miners"`
+	MajorityHash string                `json:"majority_hash"`
+	Hashes       map[string]int        `json:"hashes"`
+	Outliers     []*ConfigDriftOutlier `json:"outliers"`
 }
 
 type AgentReport struct {
 	MinerID       string                 `json:"miner_id"`
 	WorkerID      string                 `json:"worker_id"`
+	Name          string                 `json:"name,omitempty"`
+	Tag           string                 `json:"tag,omitempty"`
 	Hostname      string                 `json:"hostname"`
 	IP            string                 `json:"ip"`
 	CPUModel      string                 `json:"cpu_model"`
@@ -62,13 +162,44 @@ type AgentReport struct {
 	OS            string                 `json:"os"`
 	Arch          string                 `json:"arch"`
 	XmrigVersion  string                 `json:"xmrig_version"`
+	Algo          string                 `json:"algo,omitempty"`
 	UptimeSeconds int64                  `json:"uptime_seconds"`
 	Hashrate      *HashrateData          `json:"hashrate,omitempty"`
+	Accepted      int64                  `json:"accepted"`
+	Rejected      int64                  `json:"rejected"`
 	Config        map[string]interface{} `json:"config,omitempty"`
+	ConfigName    string                 `json:"config_name,omitempty"`
+	UsingFallback bool                   `json:"using_fallback_config"`
+	Hugepages     bool                   `json:"hugepages_enabled"`
+	MSR           bool                   `json:"msr_enabled"`
 	TarishVersion string                 `json:"tarish_version"`
+	Timestamp     string                 `json:"timestamp,omitempty"` // when the agent sampled it (RFC3339)
 }
 
 type ReportResponse struct {
 	OK             bool                   `json:"ok"`
 	ConfigOverride map[string]interface{} `json:"config_override,omitempty"`
+	TarishOverride map[string]interface{} `json:"tarish_override,omitempty"`
+	// FetchConfig asks the agent to POST xmrig's live config to
+	// /api/miners/{id}/config/report
+	FetchConfig bool `json:"fetch_config,omitempty"`
+}
+
+// RawConfigResponse answers GET /api/miners/{id}/raw-config
+type RawConfigResponse struct {
+	Config    map[string]interface{} `json:"config"`
+	FetchedAt time.Time              `json:"fetched_at"`
+	// Stale is set when the agent didn't answer this fetch and Config is
+	// from an earlier one
+	Stale bool `json:"stale"`
+}
+
+// BatchReportResponse answers POST /api/report/batch (buffered agent reports)
+type BatchReportResponse struct {
+	OK       bool `json:"ok"`
+	Accepted int  `json:"accepted"`
+	Rejected int  `json:"rejected"`
+	// Limited reports, from the end of the batch, weren't processed because
+	// the miner hit the report rate limit; the agent sends them again later
+	Limited int `json:"limited,omitempty"`
 }
@@ -2,16 +2,21 @@ package proxy
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type Client struct {
-	baseURL     string
+	baseURL    string
+	httpClient *http.Client
+	retry      bool
+
+	mu          sync.RWMutex
 	accessToken string
-	httpClient  *http.Client
 }
 
 type ProxySummary struct {
@@ -43,14 +48,72 @@ type WorkersResponse struct {
 	Workers []ProxyWorker `json:"workers"`
 }
 
+// DefaultTimeout is the per-request timeout used by NewClient
+const DefaultTimeout = 5 * time.Second
+
+// retryDelay is how long get waits before its single retry; swapped out
+// in tests
+var retryDelay = 500 * time.Millisecond
+
+// Options configures a Client
+type Options struct {
+	// Timeout per request attempt; zero means DefaultTimeout
+	Timeout time.Duration
+	// NoRetry disables the single retry on transient failures
+	NoRetry bool
+}
+
+// UnreachableError means the proxy could not be contacted at all
+type UnreachableError struct {
+	Err error
+}
+
+func (e *UnreachableError) Error() string {
+	return "proxy unreachable: " + e.Err.Error()
+}
+
+func (e *UnreachableError) Unwrap() error { return e.Err }
+
+// StatusError means the proxy answered with a non-200 status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("proxy returned status %d", e.StatusCode)
+}
+
 func NewClient(baseURL, accessToken string) *Client {
+	return NewClientWithOptions(baseURL, accessToken, Options{})
+}
+
+func NewClientWithOptions(baseURL, accessToken string, opts Options) *Client {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
 	return &Client{
 		baseURL:     baseURL,
 		accessToken: accessToken,
-		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		httpClient:  &http.Client{Timeout: timeout},
+		retry:       !opts.NoRetry,
 	}
 }
 
+// SetAccessToken replaces the xmrig-proxy API token, e.g. after the proxy
+// rotated it. Safe to call while requests are in flight.
+func (c *Client) SetAccessToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = token
+}
+
+func (c *Client) token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.accessToken
+}
+
 func (c *Client) GetSummary() (*ProxySummary, error) {
 	body, err := c.get("/1/summary")
 	if err != nil {
@@ -83,28 +146,48 @@ func (c *Client) GetWorkers() ([]ProxyWorker, error) {
 	return resp.Workers, nil
 }
 
+// get fetches path, retrying once if the proxy was unreachable or
+// answered with a 5xx
 func (c *Client) get(path string) ([]byte, error) {
 	if c.baseURL == "" {
 		return nil, fmt.Errorf("proxy URL not configured")
 	}
 
+	body, err := c.getOnce(path)
+	if err != nil && c.retry && isTransient(err) {
+		time.Sleep(retryDelay)
+		body, err = c.getOnce(path)
+	}
+	return body, err
+}
+
+func isTransient(err error) bool {
+	var unreachable *UnreachableError
+	if errors.As(err, &unreachable) {
+		return true
+	}
+	var status *StatusError
+	return errors.As(err, &status) && status.StatusCode >= 500
+}
+
+func (c *Client) getOnce(path string) ([]byte, error) {
 	req, err := http.NewRequest("GET", c.baseURL+path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
-	if c.accessToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if token := c.token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, &UnreachableError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("proxy returned status %d", resp.StatusCode)
+		return nil, &StatusError{StatusCode: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
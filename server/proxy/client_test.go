@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetries(t *testing.T) {
+	orig := retryDelay
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = orig }()
+
+	tests := []struct {
+		name       string
+		statuses   []int // answered in turn, the last one repeating
+		noRetry    bool
+		wantCalls  int32
+		wantStatus int // 0 for success
+	}{
+		{"success", []int{200}, false, 1, 0},
+		{"5xx then success", []int{502, 200}, false, 2, 0},
+		{"5xx twice", []int{503}, false, 2, 503},
+		{"4xx is not retried", []int{401}, false, 1, 401},
+		{"retry disabled", []int{500, 200}, true, 1, 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1)) - 1
+				status := tt.statuses[min(n, len(tt.statuses)-1)]
+				if status != 200 {
+					w.WriteHeader(status)
+					return
+				}
+				w.Write([]byte(`{"id":"proxy","workers":{"now":3,"max":5}}`))
+			}))
+			defer srv.Close()
+
+			c := NewClientWithOptions(srv.URL, "", Options{NoRetry: tt.noRetry})
+			summary, err := c.GetSummary()
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("proxy called %d times, want %d", got, tt.wantCalls)
+			}
+			if tt.wantStatus == 0 {
+				if err != nil || summary.Workers.Now != 3 {
+					t.Fatalf("GetSummary() = %+v, %v", summary, err)
+				}
+				return
+			}
+			var status *StatusError
+			if !errors.As(err, &status) || status.StatusCode != tt.wantStatus {
+				t.Errorf("GetSummary() error = %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := NewClientWithOptions(srv.URL, "", Options{Timeout: 20 * time.Millisecond, NoRetry: true})
+	_, err := c.GetWorkers()
+	var unreachable *UnreachableError
+	if !errors.As(err, &unreachable) {
+		t.Errorf("GetWorkers() error = %v, want UnreachableError", err)
+	}
+}
+
+func TestClientSendsToken(t *testing.T) {
+	var got atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(`{"workers":[]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "old")
+	c.SetAccessToken("new")
+	if _, err := c.GetWorkers(); err != nil {
+		t.Fatal(err)
+	}
+	if got.Load() != "Bearer new" {
+		t.Errorf("Authorization = %q, want the replaced token", got.Load())
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// dialect selects the SQL that differs between backends. Queries are
+// written for SQLite, with ? placeholders and RFC 3339 text timestamps,
+// and adapted for Postgres here.
+type dialect int
+
+const (
+	sqliteDialect dialect = iota
+	postgresDialect
+)
+
+// pgTypes maps the SQLite column types in the schema to Postgres ones.
+// REAL is single precision in Postgres, and timestamps stay text so both
+// backends compare and parse them the same way.
+var pgTypes = strings.NewReplacer(
+	"INTEGER PRIMARY KEY AUTOINCREMENT", "BIGSERIAL PRIMARY KEY",
+	"INTEGER", "BIGINT",
+	"REAL", "DOUBLE PRECISION",
+	"DATETIME", "TEXT",
+)
+
+// schema adapts DDL written for SQLite
+func (d dialect) schema(ddl string) string {
+	if d != postgresDialect {
+		return ddl
+	}
+	return pgTypes.Replace(ddl)
+}
+
+// rebind rewrites ? placeholders to Postgres's $1, $2, ...
+func (d dialect) rebind(query string) string {
+	if d != postgresDialect || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// epochSeconds returns an expression for a text timestamp column as whole
+// Unix seconds
+func (d dialect) epochSeconds(column string) string {
+	if d == postgresDialect {
+		return "CAST(EXTRACT(EPOCH FROM CAST(" + column + " AS TIMESTAMPTZ)) AS BIGINT)"
+	}
+	return "CAST(strftime('%s', " + column + ") AS INTEGER)"
+}
+
+// conn is a *sql.DB whose queries are rebound for its dialect
+type conn struct {
+	*sql.DB
+	dialect dialect
+}
+
+func (c *conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.Exec(c.dialect.rebind(query), args...)
+}
+
+func (c *conn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB.Query(c.dialect.rebind(query), args...)
+}
+
+func (c *conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.DB.QueryRow(c.dialect.rebind(query), args...)
+}
+
+func (c *conn) Begin() (*txn, error) {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx, dialect: c.dialect}, nil
+}
+
+// txn is conn's *sql.Tx counterpart
+type txn struct {
+	*sql.Tx
+	dialect dialect
+}
+
+func (t *txn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.Exec(t.dialect.rebind(query), args...)
+}
+
+func (t *txn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.Query(t.dialect.rebind(query), args...)
+}
+
+func (t *txn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRow(t.dialect.rebind(query), args...)
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	query := `UPDATE t SET a = ? WHERE id = (SELECT MAX(id) FROM t WHERE b = ?)`
+	if got := sqliteDialect.rebind(query); got != query {
+		t.Errorf("sqlite rebind changed the query: %s", got)
+	}
+	want := `UPDATE t SET a = $1 WHERE id = (SELECT MAX(id) FROM t WHERE b = $2)`
+	if got := postgresDialect.rebind(query); got != want {
+		t.Errorf("postgres rebind = %s, want %s", got, want)
+	}
+}
+
+func TestPostgresSchema(t *testing.T) {
+	ddl := `CREATE TABLE x (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		n INTEGER DEFAULT 0,
+		rate REAL DEFAULT 0,
+		at DATETIME NOT NULL
+	)`
+	got := postgresDialect.schema(ddl)
+	for _, want := range []string{"id BIGSERIAL PRIMARY KEY,", "n BIGINT DEFAULT 0", "rate DOUBLE PRECISION", "at TEXT NOT NULL"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("schema missing %q:\n%s", want, got)
+		}
+	}
+	if sqliteDialect.schema(ddl) != ddl {
+		t.Errorf("sqlite schema changed the DDL")
+	}
+}
+
+func TestNewPostgresRejectsOtherURLs(t *testing.T) {
+	_, err := NewPostgres("mysql://user:hunter2@db/tarish", Options{})
+	if err == nil {
+		t.Fatal("NewPostgres accepted a mysql:// URL")
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("error leaks the password: %v", err)
+	}
+}
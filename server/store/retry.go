@@ -0,0 +1,66 @@
+package store
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// busyAttempts is how many times a write transaction is tried when SQLite
+// reports the database busy or locked. _busy_timeout already waits inside
+// SQLite; this covers the cases it gives up on, e.g. a lock upgrade
+// conflict, which SQLite reports immediately. Postgres deadlocks and
+// serialization failures are retried the same way.
+const busyAttempts = 4
+
+// busyBackoff is the sleep before retry number attempt (1-based): a
+// growing base with jitter so writers that collided don't collide again
+var busyBackoff = func(attempt int) time.Duration {
+	base := time.Duration(attempt) * 20 * time.Millisecond
+	return base + time.Duration(rand.Int63n(int64(base)))
+}
+
+// withTx runs fn in a transaction and commits it, retrying the whole
+// transaction on busy errors. Any other error from fn rolls back and is
+// returned as is.
+func (s *sqlStore) withTx(fn func(tx *txn) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.runTx(fn)
+		if !isBusy(err) || attempt == busyAttempts {
+			return err
+		}
+		time.Sleep(busyBackoff(attempt))
+	}
+}
+
+func (s *sqlStore) runTx(fn func(tx *txn) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// isBusy reports whether err is SQLite's "database is busy/locked" or a
+// Postgres deadlock/serialization failure, which clear once the other
+// writer finishes
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestIsBusy(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{fmt.Errorf("upsert: %w", sqlite3.Error{Code: sqlite3.ErrLocked}), true},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{&pq.Error{Code: "40P01"}, true},
+		{&pq.Error{Code: "23505"}, false},
+		{ErrMinerNotFound, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isBusy(tt.err); got != tt.want {
+			t.Errorf("isBusy(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWithTxRetriesBusy(t *testing.T) {
+	s := newTestStore(t)
+	origBackoff := busyBackoff
+	busyBackoff = func(int) time.Duration { return 0 }
+	defer func() { busyBackoff = origBackoff }()
+
+	calls := 0
+	err := s.withTx(func(tx *txn) error {
+		calls++
+		if calls < 3 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		_, err := tx.Exec(`INSERT INTO proxy_history (timestamp) VALUES (?)`, time.Now())
+		return err
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("withTx = %v after %d calls, want success on the 3rd", err, calls)
+	}
+
+	calls = 0
+	err = s.withTx(func(tx *txn) error {
+		calls++
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	})
+	if !isBusy(err) || calls != busyAttempts {
+		t.Errorf("always busy: err = %v after %d calls, want busy after %d", err, calls, busyAttempts)
+	}
+
+	calls = 0
+	err = s.withTx(func(tx *txn) error {
+		calls++
+		return ErrMinerNotFound
+	})
+	if !errors.Is(err, ErrMinerNotFound) || calls != 1 {
+		t.Errorf("real error: err = %v after %d calls, want it returned without retrying", err, calls)
+	}
+}
@@ -1,11 +1,15 @@
 package store
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,23 +17,53 @@ import (
 	"tarish-server/models"
 )
 
-type Store struct {
-	db *sql.DB
+// sqlStore implements Store over database/sql. Queries are shared by both
+// backends; dialect covers where they differ.
+type sqlStore struct {
+	db *conn
 	mu sync.RWMutex
+
+	onlineWindow time.Duration
+	staleWindow  time.Duration
+}
+
+func New(dbPath string) (Store, error) {
+	return NewWithOptions(dbPath, Options{})
 }
 
-func New(dbPath string) (*Store, error) {
+// NewWithOptions opens (creating if needed) the SQLite database at dbPath
+func NewWithOptions(dbPath string, opts Options) (Store, error) {
+	return openSQLite(dbPath, opts)
+}
+
+func openSQLite(dbPath string, opts Options) (*sqlStore, error) {
 	if dir := filepath.Dir(dbPath); dir != "." {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return nil, fmt.Errorf("create database directory: %w", err)
 		}
 	}
-	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000")
+	return open(sqliteDialect, "sqlite3", dbPath+"?_journal_mode=WAL&_busy_timeout=5000", opts)
+}
+
+func open(d dialect, driver, dsn string, opts Options) (*sqlStore, error) {
+	onlineWindow := opts.OnlineWindow
+	if onlineWindow <= 0 {
+		onlineWindow = DefaultOnlineWindow
+	}
+	staleWindow := opts.StaleWindow
+	if staleWindow <= 0 {
+		staleWindow = DefaultStaleWindow
+	}
+	if staleWindow < onlineWindow {
+		return nil, fmt.Errorf("stale window (%v) must not be shorter than online window (%v)", staleWindow, onlineWindow)
+	}
+
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	s := &Store{db: db}
+	s := &sqlStore{db: &conn{DB: db, dialect: d}, onlineWindow: onlineWindow, staleWindow: staleWindow}
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("migrate: %w", err)
@@ -38,12 +72,42 @@ func New(dbPath string) (*Store, error) {
 	return s, nil
 }
 
-func (s *Store) Close() error {
-	return s.db.Close()
+func (s *sqlStore) Close() error {
+	// Checkpoint first so a clean shutdown leaves no -wal file behind
+	cpErr := s.Checkpoint()
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	if cpErr != nil {
+		return fmt.Errorf("checkpoint: %w", cpErr)
+	}
+	return nil
 }
 
-func (s *Store) migrate() error {
-	_, err := s.db.Exec(`
+// Checkpoint copies the WAL into the main database file and truncates it,
+// keeping the -wal file from growing without bound on a busy server.
+// Postgres manages its own WAL, so there it does nothing.
+func (s *sqlStore) Checkpoint() error {
+	if s.db.dialect == postgresDialect {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var busy, logFrames, checkpointed int
+	err := s.db.QueryRow(`PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &checkpointed)
+	if err != nil {
+		return err
+	}
+	if busy != 0 {
+		return fmt.Errorf("checkpoint incomplete: database busy (%d of %d frames)", checkpointed, logFrames)
+	}
+	return nil
+}
+
+func (s *sqlStore) migrate() error {
+	_, err := s.db.Exec(s.db.dialect.schema(`
 		CREATE TABLE IF NOT EXISTS miners (
 			id TEXT PRIMARY KEY,
 			miner_id TEXT NOT NULL,
@@ -72,6 +136,25 @@ func (s *Store) migrate() error {
 			applied_at DATETIME
 		);
 
+		CREATE TABLE IF NOT EXISTS config_override_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			miner_id TEXT NOT NULL,
+			override_json TEXT NOT NULL,
+			set_by TEXT DEFAULT '',
+			created_at DATETIME NOT NULL,
+			applied_at DATETIME
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_config_override_log_miner
+			ON config_override_log(miner_id, id);
+
+		CREATE TABLE IF NOT EXISTS tarish_overrides (
+			miner_id TEXT PRIMARY KEY,
+			settings_json TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			applied_at DATETIME
+		);
+
 		CREATE TABLE IF NOT EXISTS hashrate_history (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			miner_id TEXT NOT NULL,
@@ -83,11 +166,67 @@ func (s *Store) migrate() error {
 
 		CREATE INDEX IF NOT EXISTS idx_hashrate_history_miner_ts
 			ON hashrate_history(miner_id, timestamp);
-	`)
+
+		CREATE INDEX IF NOT EXISTS idx_hashrate_history_ts
+			ON hashrate_history(timestamp);
+
+		CREATE TABLE IF NOT EXISTS proxy_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			current REAL DEFAULT 0,
+			average REAL DEFAULT 0,
+			workers INTEGER DEFAULT 0
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_proxy_history_ts
+			ON proxy_history(timestamp);
+	`))
+	if err != nil {
+		return err
+	}
+
+	// Columns added after the initial schema
+	columns := []struct{ table, column, def string }{
+		{"miners", "config_hash", "TEXT DEFAULT ''"},
+		{"miners", "algo", "TEXT DEFAULT ''"},
+		{"miners", "name", "TEXT DEFAULT ''"},
+		{"miners", "accepted", "INTEGER DEFAULT 0"},
+		{"miners", "rejected", "INTEGER DEFAULT 0"},
+		{"miners", "config_name", "TEXT DEFAULT ''"},
+		{"miners", "using_fallback_config", "BOOLEAN DEFAULT FALSE"},
+		{"miners", "hugepages_enabled", "BOOLEAN DEFAULT FALSE"},
+		{"miners", "msr_enabled", "BOOLEAN DEFAULT FALSE"},
+		{"miners", "reported_at", "DATETIME"},
+		{"miners", "tag", "TEXT DEFAULT ''"},
+		// share counters per sample, for the windowed reject rate; NULL
+		// in rows from before they were recorded
+		{"hashrate_history", "accepted", "INTEGER"},
+		{"hashrate_history", "rejected", "INTEGER"},
+	}
+	for _, c := range columns {
+		if err := s.addColumn(c.table, c.column, c.def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column to an existing table. "duplicate column" errors
+// are ignored so migrate stays idempotent on databases that already have it;
+// Postgres can say IF NOT EXISTS instead.
+func (s *sqlStore) addColumn(table, column, def string) error {
+	if s.db.dialect == postgresDialect {
+		_, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, s.db.dialect.schema(def)))
+		return err
+	}
+	_, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, def))
+	if err != nil && strings.Contains(err.Error(), "duplicate column name") {
+		return nil
+	}
 	return err
 }
 
-func (s *Store) UpsertMiner(report *models.AgentReport) error {
+func (s *sqlStore) UpsertMiner(report *models.AgentReport) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -95,12 +234,17 @@ func (s *Store) UpsertMiner(report *models.AgentReport) error {
 	if id == "" {
 		id = report.WorkerID
 	}
+	if id == "" {
+		return ErrMissingID
+	}
 
 	configJSON := "{}"
+	configHash := ""
 	if report.Config != nil {
 		if data, err := json.Marshal(report.Config); err == nil {
 			configJSON = string(data)
 		}
+		configHash = hashConfig(report.Config)
 	}
 
 	var hCurrent, hAverage, hMax float64
@@ -110,59 +254,93 @@ func (s *Store) UpsertMiner(report *models.AgentReport) error {
 		hMax = report.Hashrate.Max
 	}
 
+	// last_seen is when the server heard from the miner, so a skewed agent
+	// clock can't make it look offline or online forever. The agent's own
+	// sample time orders its reports and dates the hashrate sample.
 	now := time.Now().UTC().Format(time.RFC3339)
+	sampled := reportTime(report.Timestamp).Format(time.RFC3339)
+
+	// The miner row and its history sample are written together so a
+	// crash in between can't leave one without the other.
+	return s.withTx(func(tx *txn) error {
+		// Buffered reports can arrive after newer ones; the WHERE guard keeps
+		// them from overwriting the miner's current state while still
+		// recording their hashrate sample below.
+		_, err := tx.Exec(`
+			INSERT INTO miners (id, miner_id, worker_id, hostname, ip, cpu_model, cpu_family,
+				cores, os, arch, xmrig_version, tarish_version, uptime_seconds,
+				hashrate_current, hashrate_average, hashrate_max, accepted, rejected,
+				config_json, config_hash, config_name, using_fallback_config,
+				hugepages_enabled, msr_enabled, algo, name, tag, last_seen, reported_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				miner_id=excluded.miner_id,
+				worker_id=excluded.worker_id,
+				hostname=excluded.hostname,
+				ip=excluded.ip,
+				cpu_model=excluded.cpu_model,
+				cpu_family=excluded.cpu_family,
+				cores=excluded.cores,
+				os=excluded.os,
+				arch=excluded.arch,
+				xmrig_version=excluded.xmrig_version,
+				tarish_version=excluded.tarish_version,
+				uptime_seconds=excluded.uptime_seconds,
+				hashrate_current=excluded.hashrate_current,
+				hashrate_average=excluded.hashrate_average,
+				hashrate_max=excluded.hashrate_max,
+				accepted=excluded.accepted,
+				rejected=excluded.rejected,
+				config_json=excluded.config_json,
+				config_hash=excluded.config_hash,
+				config_name=CASE WHEN excluded.config_name != '' THEN excluded.config_name ELSE miners.config_name END,
+				using_fallback_config=excluded.using_fallback_config,
+				hugepages_enabled=excluded.hugepages_enabled,
+				msr_enabled=excluded.msr_enabled,
+				algo=excluded.algo,
+				name=CASE WHEN excluded.name != '' THEN excluded.name ELSE miners.name END,
+				tag=excluded.tag,
+				last_seen=excluded.last_seen,
+				reported_at=excluded.reported_at
+			WHERE miners.reported_at IS NULL OR excluded.reported_at >= miners.reported_at
+		`, id, report.MinerID, report.WorkerID, report.Hostname, report.IP,
+			report.CPUModel, report.CPUFamily, report.Cores, report.OS, report.Arch,
+			report.XmrigVersion, report.TarishVersion, report.UptimeSeconds,
+			hCurrent, hAverage, hMax, report.Accepted, report.Rejected,
+			configJSON, configHash, report.ConfigName, report.UsingFallback,
+			report.Hugepages, report.MSR, report.Algo, report.Name, report.Tag, now, sampled)
 
-	_, err := s.db.Exec(`
-		INSERT INTO miners (id, miner_id, worker_id, hostname, ip, cpu_model, cpu_family,
-			cores, os, arch, xmrig_version, tarish_version, uptime_seconds,
-			hashrate_current, hashrate_average, hashrate_max, config_json, last_seen)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET
-			miner_id=excluded.miner_id,
-			worker_id=excluded.worker_id,
-			hostname=excluded.hostname,
-			ip=excluded.ip,
-			cpu_model=excluded.cpu_model,
-			cpu_family=excluded.cpu_family,
-			cores=excluded.cores,
-			os=excluded.os,
-			arch=excluded.arch,
-			xmrig_version=excluded.xmrig_version,
-			tarish_version=excluded.tarish_version,
-			uptime_seconds=excluded.uptime_seconds,
-			hashrate_current=excluded.hashrate_current,
-			hashrate_average=excluded.hashrate_average,
-			hashrate_max=excluded.hashrate_max,
-			config_json=excluded.config_json,
-			last_seen=excluded.last_seen
-	`, id, report.MinerID, report.WorkerID, report.Hostname, report.IP,
-		report.CPUModel, report.CPUFamily, report.Cores, report.OS, report.Arch,
-		report.XmrigVersion, report.TarishVersion, report.UptimeSeconds,
-		hCurrent, hAverage, hMax, configJSON, now)
-
-	if err != nil {
-		return err
-	}
-
-	// Record hashrate history (sample every report)
-	if report.Hashrate != nil {
-		_, err = s.db.Exec(`
-			INSERT INTO hashrate_history (miner_id, timestamp, current, average, max)
-			VALUES (?, ?, ?, ?, ?)
-		`, id, now, hCurrent, hAverage, hMax)
-	}
+		if err != nil {
+			return err
+		}
 
-	return err
+		// Record hashrate history (sample every report)
+		if report.Hashrate != nil {
+			_, err = tx.Exec(`
+				INSERT INTO hashrate_history (miner_id, timestamp, current, average, max, accepted, rejected)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+			`, id, sampled, hCurrent, hAverage, hMax, report.Accepted, report.Rejected)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (s *Store) GetMiners() ([]*models.Miner, error) {
+// minerColumns is the column list scanMiner expects, in order
+const minerColumns = `id, miner_id, worker_id, hostname, ip, cpu_model, cpu_family,
+	cores, os, arch, xmrig_version, tarish_version, uptime_seconds,
+	hashrate_current, hashrate_average, hashrate_max, accepted, rejected,
+	config_json, config_hash, config_name, using_fallback_config,
+	hugepages_enabled, msr_enabled, algo, name, tag, last_seen`
+
+func (s *sqlStore) GetMiners() ([]*models.Miner, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	rows, err := s.db.Query(`
-		SELECT id, miner_id, worker_id, hostname, ip, cpu_model, cpu_family,
-			cores, os, arch, xmrig_version, tarish_version, uptime_seconds,
-			hashrate_current, hashrate_average, hashrate_max, config_json, last_seen
+		SELECT ` + minerColumns + `
 		FROM miners ORDER BY hashrate_current DESC
 	`)
 	if err != nil {
@@ -172,7 +350,7 @@ func (s *Store) GetMiners() ([]*models.Miner, error) {
 
 	var miners []*models.Miner
 	for rows.Next() {
-		m, err := scanMiner(rows)
+		m, err := s.scanMiner(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -181,62 +359,89 @@ func (s *Store) GetMiners() ([]*models.Miner, error) {
 	return miners, rows.Err()
 }
 
-func (s *Store) GetMiner(id string) (*models.Miner, error) {
+func (s *sqlStore) GetMiner(id string) (*models.Miner, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	row := s.db.QueryRow(`
-		SELECT id, miner_id, worker_id, hostname, ip, cpu_model, cpu_family,
-			cores, os, arch, xmrig_version, tarish_version, uptime_seconds,
-			hashrate_current, hashrate_average, hashrate_max, config_json, last_seen
+		SELECT `+minerColumns+`
 		FROM miners WHERE id = ?
 	`, id)
 
-	m := &models.Miner{}
-	var configJSON string
-	var lastSeen string
-	var hCurrent, hAverage, hMax float64
+	return s.scanMiner(row)
+}
 
-	err := row.Scan(&m.ID, &m.MinerID, &m.WorkerID, &m.Hostname, &m.IP,
-		&m.CPUModel, &m.CPUFamily, &m.Cores, &m.OS, &m.Arch,
-		&m.XmrigVersion, &m.TarishVersion, &m.UptimeSeconds,
-		&hCurrent, &hAverage, &hMax, &configJSON, &lastSeen)
+// SetConfigOverride replaces the miner's pending override and appends it
+// to config_override_log. setBy records who pushed it and may be empty.
+func (s *sqlStore) SetConfigOverride(minerID string, override map[string]interface{}, setBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(override)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	now := time.Now().UTC().Format(time.RFC3339)
 
-	m.Hashrate = &models.HashrateData{Current: hCurrent, Average: hAverage, Max: hMax}
-	m.LastSeen = parseTime(lastSeen)
-	m.Status = minerStatus(m.LastSeen)
-
-	if configJSON != "" && configJSON != "{}" {
-		json.Unmarshal([]byte(configJSON), &m.Config)
-	}
+	return s.withTx(func(tx *txn) error {
+		_, err := tx.Exec(`
+			INSERT INTO config_overrides (miner_id, override_json, created_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT(miner_id) DO UPDATE SET
+				override_json=excluded.override_json,
+				created_at=excluded.created_at,
+				applied_at=NULL
+		`, minerID, string(data), now)
+		if err != nil {
+			return err
+		}
 
-	return m, nil
+		_, err = tx.Exec(`
+			INSERT INTO config_override_log (miner_id, override_json, set_by, created_at)
+			VALUES (?, ?, ?, ?)
+		`, minerID, string(data), setBy, now)
+		return err
+	})
 }
 
-func (s *Store) SetConfigOverride(minerID string, override map[string]interface{}) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// GetConfigOverrideHistory returns up to limit logged overrides for a
+// miner, newest first
+func (s *sqlStore) GetConfigOverrideHistory(minerID string, limit int) ([]*models.ConfigOverrideLogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	data, err := json.Marshal(override)
+	rows, err := s.db.Query(`
+		SELECT id, miner_id, override_json, set_by, created_at, applied_at
+		FROM config_override_log WHERE miner_id = ?
+		ORDER BY id DESC LIMIT ?
+	`, minerID, limit)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	_, err = s.db.Exec(`
-		INSERT INTO config_overrides (miner_id, override_json, created_at)
-		VALUES (?, ?, ?)
-		ON CONFLICT(miner_id) DO UPDATE SET
-			override_json=excluded.override_json,
-			created_at=excluded.created_at,
-			applied_at=NULL
-	`, minerID, string(data), time.Now().UTC().Format(time.RFC3339))
-	return err
+	var entries []*models.ConfigOverrideLogEntry
+	for rows.Next() {
+		e := &models.ConfigOverrideLogEntry{}
+		var overrideJSON, createdAt string
+		var appliedAt sql.NullString
+		if err := rows.Scan(&e.ID, &e.MinerID, &overrideJSON, &e.SetBy, &createdAt, &appliedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(overrideJSON), &e.Override); err != nil {
+			return nil, err
+		}
+		e.CreatedAt = parseTime(createdAt)
+		if appliedAt.Valid {
+			t := parseTime(appliedAt.String)
+			e.AppliedAt = &t
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
 }
 
-func (s *Store) GetConfigOverride(minerID string) (map[string]interface{}, error) {
+func (s *sqlStore) GetConfigOverride(minerID string) (map[string]interface{}, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -266,7 +471,7 @@ func (s *Store) GetConfigOverride(minerID string) (map[string]interface{}, error
 	return override, nil
 }
 
-func (s *Store) GetLastOverride(minerID string) (map[string]interface{}, error) {
+func (s *sqlStore) GetLastOverride(minerID string) (map[string]interface{}, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -289,17 +494,28 @@ func (s *Store) GetLastOverride(minerID string) (map[string]interface{}, error)
 	return override, nil
 }
 
-func (s *Store) MarkConfigApplied(minerID string) error {
+func (s *sqlStore) MarkConfigApplied(minerID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	now := time.Now().UTC().Format(time.RFC3339)
 	_, err := s.db.Exec(`
 		UPDATE config_overrides SET applied_at = ? WHERE miner_id = ?
-	`, time.Now().UTC().Format(time.RFC3339), minerID)
+	`, now, minerID)
+	if err != nil {
+		return err
+	}
+
+	// The agent only ever applies the latest override
+	_, err = s.db.Exec(`
+		UPDATE config_override_log SET applied_at = ?
+		WHERE id = (SELECT MAX(id) FROM config_override_log WHERE miner_id = ?)
+			AND applied_at IS NULL
+	`, now, minerID)
 	return err
 }
 
-func (s *Store) DeleteConfigOverride(minerID string) error {
+func (s *sqlStore) DeleteConfigOverride(minerID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -307,12 +523,148 @@ func (s *Store) DeleteConfigOverride(minerID string) error {
 	return err
 }
 
-func (s *Store) GetHashrateHistory(minerID string, since time.Time) ([]*models.HashrateHistory, error) {
+// SetTarishOverride stores tarish-level settings (auto-update, donate
+// floor, ...) for a miner and marks them pending delivery to the agent.
+func (s *sqlStore) SetTarishOverride(minerID string, settings map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO tarish_overrides (miner_id, settings_json, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(miner_id) DO UPDATE SET
+			settings_json=excluded.settings_json,
+			created_at=excluded.created_at,
+			applied_at=NULL
+	`, minerID, string(data), time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// GetTarishSettings returns the stored tarish settings for a miner whether
+// or not the agent has applied them yet, or nil if none were set.
+func (s *sqlStore) GetTarishSettings(minerID string) (*models.TarishSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var settingsJSON, createdAt string
+	var appliedAt sql.NullString
+
+	err := s.db.QueryRow(`
+		SELECT settings_json, created_at, applied_at FROM tarish_overrides WHERE miner_id = ?
+	`, minerID).Scan(&settingsJSON, &createdAt, &appliedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &models.TarishSettings{MinerID: minerID}
+	if err := json.Unmarshal([]byte(settingsJSON), &ts.Settings); err != nil {
+		return nil, err
+	}
+	ts.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if appliedAt.Valid {
+		t, _ := time.Parse(time.RFC3339, appliedAt.String)
+		ts.AppliedAt = &t
+	}
+	return ts, nil
+}
+
+// GetTarishOverride returns the tarish settings only while they are still
+// pending, mirroring GetConfigOverride.
+func (s *sqlStore) GetTarishOverride(minerID string) (map[string]interface{}, error) {
+	ts, err := s.GetTarishSettings(minerID)
+	if err != nil || ts == nil || ts.AppliedAt != nil {
+		return nil, err
+	}
+	return ts.Settings, nil
+}
+
+func (s *sqlStore) MarkTarishOverrideApplied(minerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		UPDATE tarish_overrides SET applied_at = ? WHERE miner_id = ?
+	`, time.Now().UTC().Format(time.RFC3339), minerID)
+	return err
+}
+
+func (s *sqlStore) DeleteTarishOverride(minerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`DELETE FROM tarish_overrides WHERE miner_id = ?`, minerID)
+	return err
+}
+
+func (s *sqlStore) GetHashrateHistory(minerID string, since time.Time) ([]*models.HashrateHistory, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	var history []*models.HashrateHistory
+	_, err := s.eachHashrateSample(minerID, since, nil, 0, func(h *models.HashrateHistory) error {
+		history = append(history, h)
+		return nil
+	})
+	return history, err
+}
+
+// historyPageSize is how many samples EachHashrateSample reads per hold
+// of s.mu; swapped out in tests
+var historyPageSize = 1000
+
+// EachHashrateSample calls fn for every raw history sample, so large
+// exports never hold the whole range in memory. An error from fn stops the
+// iteration and is returned. fn may be slow (it writes to an HTTP client),
+// so samples are read in pages under s.mu and fn runs with it released; a
+// sample written between pages shows up if it sorts after the last one
+// read.
+func (s *sqlStore) EachHashrateSample(minerID string, since time.Time, fn func(*models.HashrateHistory) error) error {
+	var after *sampleCursor
+	for {
+		var page []*models.HashrateHistory
+		s.mu.RLock()
+		next, err := s.eachHashrateSample(minerID, since, after, historyPageSize, func(h *models.HashrateHistory) error {
+			page = append(page, h)
+			return nil
+		})
+		s.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		for _, h := range page {
+			if err := fn(h); err != nil {
+				return err
+			}
+		}
+		if len(page) < historyPageSize {
+			return nil
+		}
+		after = next
+	}
+}
+
+// sampleCursor is the last history row a page returned, in the
+// (timestamp, id) order samples are read in
+type sampleCursor struct {
+	timestamp string
+	id        int64
+}
+
+// eachHashrateSample calls fn for the samples after the cursor (all of
+// them when it is nil), up to limit if it is positive, and returns the
+// cursor of the last one. The caller holds s.mu.
+func (s *sqlStore) eachHashrateSample(minerID string, since time.Time, after *sampleCursor, limit int, fn func(*models.HashrateHistory) error) (*sampleCursor, error) {
 	query := `
-		SELECT miner_id, timestamp, current, average, max
+		SELECT id, miner_id, timestamp, current, average, max
 		FROM hashrate_history WHERE timestamp > ?
 	`
 	args := []interface{}{since.Format(time.RFC3339)}
@@ -321,7 +673,63 @@ func (s *Store) GetHashrateHistory(minerID string, since time.Time) ([]*models.H
 		query += " AND miner_id = ?"
 		args = append(args, minerID)
 	}
-	query += " ORDER BY timestamp ASC"
+	if after != nil {
+		query += " AND (timestamp > ? OR (timestamp = ? AND id > ?))"
+		args = append(args, after.timestamp, after.timestamp, after.id)
+	}
+	query += " ORDER BY timestamp ASC, id ASC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	last := after
+	for rows.Next() {
+		h := &models.HashrateHistory{}
+		cur := &sampleCursor{}
+		if err := rows.Scan(&cur.id, &h.MinerID, &cur.timestamp, &h.Current, &h.Average, &h.Max); err != nil {
+			return nil, err
+		}
+		h.Timestamp = parseTime(cur.timestamp)
+		if err := fn(h); err != nil {
+			return nil, err
+		}
+		last = cur
+	}
+	return last, rows.Err()
+}
+
+// GetHashrateRollup is GetHashrateHistory averaged into fixed buckets
+// (e.g. 1m, 1h) per miner, so long ranges return a point per bucket rather
+// than every raw sample. Timestamps are the start of each bucket.
+func (s *sqlStore) GetHashrateRollup(minerID string, since time.Time, bucket time.Duration) ([]*models.HashrateHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	secs := int64(bucket / time.Second)
+	if secs < 1 {
+		return nil, fmt.Errorf("bucket must be at least 1s, got %v", bucket)
+	}
+
+	query := `
+		SELECT miner_id,
+			(` + s.db.dialect.epochSeconds("timestamp") + ` / ?) * ? AS bucket,
+			AVG(current), AVG(average), MAX(max)
+		FROM hashrate_history WHERE timestamp > ?
+	`
+	args := []interface{}{secs, secs, since.Format(time.RFC3339)}
+
+	if minerID != "" {
+		query += " AND miner_id = ?"
+		args = append(args, minerID)
+	}
+	query += " GROUP BY miner_id, bucket ORDER BY bucket ASC"
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -332,8 +740,47 @@ func (s *Store) GetHashrateHistory(minerID string, since time.Time) ([]*models.H
 	var history []*models.HashrateHistory
 	for rows.Next() {
 		h := &models.HashrateHistory{}
+		var start int64
+		if err := rows.Scan(&h.MinerID, &start, &h.Current, &h.Average, &h.Max); err != nil {
+			return nil, err
+		}
+		h.Timestamp = time.Unix(start, 0).UTC()
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// AddProxySample records one xmrig-proxy summary sample
+func (s *sqlStore) AddProxySample(current, average float64, workers int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO proxy_history (timestamp, current, average, workers)
+		VALUES (?, ?, ?, ?)
+	`, time.Now().UTC().Format(time.RFC3339), current, average, workers)
+	return err
+}
+
+func (s *sqlStore) GetProxyHistory(since time.Time) ([]*models.ProxyHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT timestamp, current, average, workers
+		FROM proxy_history WHERE timestamp > ?
+		ORDER BY timestamp ASC
+	`, since.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.ProxyHistory
+	for rows.Next() {
+		h := &models.ProxyHistory{}
 		var ts string
-		if err := rows.Scan(&h.MinerID, &ts, &h.Current, &h.Average, &h.Max); err != nil {
+		if err := rows.Scan(&ts, &h.Current, &h.Average, &h.Workers); err != nil {
 			return nil, err
 		}
 		h.Timestamp = parseTime(ts)
@@ -342,7 +789,7 @@ func (s *Store) GetHashrateHistory(minerID string, since time.Time) ([]*models.H
 	return history, rows.Err()
 }
 
-func (s *Store) GetOverview() (*models.OverviewResponse, error) {
+func (s *sqlStore) GetOverview() (*models.OverviewResponse, error) {
 	miners, err := s.GetMiners()
 	if err != nil {
 		return nil, err
@@ -352,16 +799,29 @@ func (s *Store) GetOverview() (*models.OverviewResponse, error) {
 		TotalMiners: len(miners),
 	}
 
+	online := make(map[string]bool)
 	for _, m := range miners {
 		if m.Status == "online" {
+			online[m.ID] = true
 			overview.ActiveMiners++
 			if m.Hashrate != nil {
 				overview.TotalHashrate += m.Hashrate.Current
 				overview.AverageHashrate += m.Hashrate.Average
 			}
+			overview.TotalAccepted += m.Accepted
+			overview.TotalRejected += m.Rejected
 		}
 	}
 
+	accepted, rejected, err := s.recentShares(online, time.Now().Add(-RejectRateWindow))
+	if err != nil {
+		return nil, err
+	}
+	overview.RejectRateMinutes = int(RejectRateWindow / time.Minute)
+	if total := accepted + rejected; total > 0 {
+		overview.RejectRate = float64(rejected) / float64(total) * 100
+	}
+
 	// Top 5 miners by hashrate
 	limit := 5
 	if len(miners) < limit {
@@ -372,16 +832,213 @@ func (s *Store) GetOverview() (*models.OverviewResponse, error) {
 	return overview, nil
 }
 
-func (s *Store) PruneHistory(olderThan time.Duration) error {
+// recentShares sums the shares the given miners had accepted and rejected
+// since the cutoff, from the counters in their history samples. A counter
+// that went down means xmrig restarted and counts from zero again.
+func (s *sqlStore) recentShares(miners map[string]bool, since time.Time) (accepted, rejected int64, err error) {
+	rows, err := s.db.Query(`
+		SELECT miner_id, accepted, rejected FROM hashrate_history
+		WHERE timestamp >= ? AND accepted IS NOT NULL
+		ORDER BY miner_id, timestamp ASC
+	`, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var prevID string
+	var prevAccepted, prevRejected int64
+	for rows.Next() {
+		var id string
+		var acc, rej sql.NullInt64
+		if err := rows.Scan(&id, &acc, &rej); err != nil {
+			return 0, 0, err
+		}
+		if !miners[id] {
+			continue
+		}
+		// each miner's first sample in the window is the baseline
+		if id == prevID {
+			accepted += counterDelta(prevAccepted, acc.Int64)
+			rejected += counterDelta(prevRejected, rej.Int64)
+		}
+		prevID, prevAccepted, prevRejected = id, acc.Int64, rej.Int64
+	}
+	return accepted, rejected, rows.Err()
+}
+
+// counterDelta is how far a share counter moved between two samples
+func counterDelta(prev, cur int64) int64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// GetConfigDrift groups miners by CPU family and tag and reports the
+// groups whose members run different configs, listing the rigs that differ
+// from the most common config in their group.
+func (s *sqlStore) GetConfigDrift() ([]*models.ConfigDriftGroup, error) {
+	miners, err := s.GetMiners()
+	if err != nil {
+		return nil, err
+	}
+
+	type groupKey struct{ family, tag string }
+	byGroup := make(map[groupKey][]*models.Miner)
+	for _, m := range miners {
+		if m.ConfigHash == "" {
+			continue
+		}
+		key := groupKey{m.CPUFamily, m.Tag}
+		byGroup[key] = append(byGroup[key], m)
+	}
+
+	var groups []*models.ConfigDriftGroup
+	for key, members := range byGroup {
+		counts := make(map[string]int)
+		for _, m := range members {
+			counts[m.ConfigHash]++
+		}
+		if len(counts) < 2 {
+			continue
+		}
+
+		majority := ""
+		for hash, n := range counts {
+			if n > counts[majority] || (n == counts[majority] && hash < majority) {
+				majority = hash
+			}
+		}
+
+		group := &models.ConfigDriftGroup{
+			CPUFamily:    key.family,
+			Tag:          key.tag,
+			Miners:       len(members),
+			MajorityHash: majority,
+			Hashes:       counts,
+		}
+		for _, m := range members {
+			if m.ConfigHash != majority {
+				group.Outliers = append(group.Outliers, &models.ConfigDriftOutlier{
+					ID:         m.ID,
+					Hostname:   m.Hostname,
+					ConfigHash: m.ConfigHash,
+					Status:     m.Status,
+				})
+			}
+		}
+		sort.Slice(group.Outliers, func(i, j int) bool {
+			return group.Outliers[i].ID < group.Outliers[j].ID
+		})
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].CPUFamily != groups[j].CPUFamily {
+			return groups[i].CPUFamily < groups[j].CPUFamily
+		}
+		return groups[i].Tag < groups[j].Tag
+	})
+	return groups, nil
+}
+
+func (s *sqlStore) PruneHistory(olderThan time.Duration) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	cutoff := time.Now().UTC().Add(-olderThan).Format(time.RFC3339)
-	_, err := s.db.Exec(`DELETE FROM hashrate_history WHERE timestamp < ?`, cutoff)
-	return err
+
+	return s.withTx(func(tx *txn) error {
+		if _, err := tx.Exec(`DELETE FROM hashrate_history WHERE timestamp < ?`, cutoff); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM proxy_history WHERE timestamp < ?`, cutoff)
+		return err
+	})
 }
 
-func scanMiner(rows *sql.Rows) (*models.Miner, error) {
+// DeleteMiner removes a miner along with its hashrate history and
+// config/tarish overrides. Returns how many history rows were deleted.
+func (s *sqlStore) DeleteMiner(minerID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var history int64
+	err := s.withTx(func(tx *txn) error {
+		res, err := tx.Exec(`DELETE FROM miners WHERE id = ?`, minerID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrMinerNotFound
+		}
+
+		res, err = tx.Exec(`DELETE FROM hashrate_history WHERE miner_id = ?`, minerID)
+		if err != nil {
+			return err
+		}
+		history, _ = res.RowsAffected()
+
+		// config_override_log is kept: it is an audit trail, and a deleted
+		// miner that reports again keeps its history
+		for _, q := range []string{
+			`DELETE FROM config_overrides WHERE miner_id = ?`,
+			`DELETE FROM tarish_overrides WHERE miner_id = ?`,
+		} {
+			if _, err := tx.Exec(q, minerID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(history), nil
+}
+
+// PruneMiners deletes miners that haven't reported within olderThan, along
+// with their hashrate history and config/tarish overrides. Returns how many miners
+// were removed.
+func (s *sqlStore) PruneMiners(olderThan time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-olderThan).Format(time.RFC3339)
+
+	var n int64
+	err := s.withTx(func(tx *txn) error {
+		stale := `SELECT id FROM miners WHERE last_seen < ?`
+		for _, q := range []string{
+			`DELETE FROM hashrate_history WHERE miner_id IN (` + stale + `)`,
+			`DELETE FROM config_overrides WHERE miner_id IN (` + stale + `)`,
+			`DELETE FROM tarish_overrides WHERE miner_id IN (` + stale + `)`,
+		} {
+			if _, err := tx.Exec(q, cutoff); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.Exec(`DELETE FROM miners WHERE last_seen < ?`, cutoff)
+		if err != nil {
+			return err
+		}
+		n, _ = res.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (s *sqlStore) scanMiner(rows rowScanner) (*models.Miner, error) {
 	m := &models.Miner{}
 	var configJSON, lastSeen string
 	var hCurrent, hAverage, hMax float64
@@ -389,14 +1046,16 @@ func scanMiner(rows *sql.Rows) (*models.Miner, error) {
 	err := rows.Scan(&m.ID, &m.MinerID, &m.WorkerID, &m.Hostname, &m.IP,
 		&m.CPUModel, &m.CPUFamily, &m.Cores, &m.OS, &m.Arch,
 		&m.XmrigVersion, &m.TarishVersion, &m.UptimeSeconds,
-		&hCurrent, &hAverage, &hMax, &configJSON, &lastSeen)
+		&hCurrent, &hAverage, &hMax, &m.Accepted, &m.Rejected,
+		&configJSON, &m.ConfigHash, &m.ConfigName, &m.UsingFallback,
+		&m.Hugepages, &m.MSR, &m.Algo, &m.Name, &m.Tag, &lastSeen)
 	if err != nil {
 		return nil, err
 	}
 
 	m.Hashrate = &models.HashrateData{Current: hCurrent, Average: hAverage, Max: hMax}
 	m.LastSeen = parseTime(lastSeen)
-	m.Status = minerStatus(m.LastSeen)
+	m.Status = s.minerStatus(m.LastSeen)
 
 	if configJSON != "" && configJSON != "{}" {
 		json.Unmarshal([]byte(configJSON), &m.Config)
@@ -405,6 +1064,42 @@ func scanMiner(rows *sql.Rows) (*models.Miner, error) {
 	return m, nil
 }
 
+// hashConfig returns a short fingerprint of a miner's config. Per-rig
+// identity and API settings (api ids, http token, log path) are left out
+// so identically configured rigs hash the same.
+func hashConfig(cfg map[string]interface{}) string {
+	stripped := make(map[string]interface{}, len(cfg))
+	for k, v := range cfg {
+		switch k {
+		case "api", "http", "log-file":
+			continue
+		}
+		stripped[k] = v
+	}
+
+	// encoding/json sorts map keys, so the encoding is canonical
+	data, err := json.Marshal(stripped)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// reportTime returns when a report was sampled, falling back to now for
+// agents that don't send a timestamp or send one from the future.
+func reportTime(ts string) time.Time {
+	now := time.Now().UTC()
+	if ts == "" {
+		return now
+	}
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil || t.After(now) {
+		return now
+	}
+	return t.UTC()
+}
+
 // parseTime tries multiple formats that SQLite/go-sqlite3 may produce
 func parseTime(s string) time.Time {
 	for _, fmt := range []string{
@@ -421,12 +1116,12 @@ func parseTime(s string) time.Time {
 	return time.Time{}
 }
 
-func minerStatus(lastSeen time.Time) string {
+func (s *sqlStore) minerStatus(lastSeen time.Time) string {
 	since := time.Since(lastSeen)
-	if since < 90*time.Second {
+	if since < s.onlineWindow {
 		return "online"
 	}
-	if since < 5*time.Minute {
+	if since < s.staleWindow {
 		return "stale"
 	}
 	return "offline"
@@ -0,0 +1,372 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tarish-server/models"
+)
+
+func newTestStore(t *testing.T) *sqlStore {
+	t.Helper()
+	s, err := openSQLite(filepath.Join(t.TempDir(), "test.db"), Options{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestUpsertMinerWritesMinerAndHistory(t *testing.T) {
+	s := newTestStore(t)
+
+	report := &models.AgentReport{
+		MinerID:  "m1",
+		Hashrate: &models.HashrateData{Current: 100, Average: 90, Max: 120},
+	}
+	if err := s.UpsertMiner(report); err != nil {
+		t.Fatalf("UpsertMiner: %v", err)
+	}
+
+	if m, err := s.GetMiner("m1"); err != nil || m == nil {
+		t.Fatalf("GetMiner = %v, %v", m, err)
+	}
+	history, err := s.GetHashrateHistory("m1", time.Now().Add(-time.Hour))
+	if err != nil || len(history) != 1 {
+		t.Fatalf("history = %d rows, %v; want 1", len(history), err)
+	}
+}
+
+func TestUpsertMinerLastSeenIsServerTime(t *testing.T) {
+	s := newTestStore(t)
+
+	// an agent whose clock runs an hour behind still counts as online
+	behind := time.Now().Add(-time.Hour).UTC()
+	report := &models.AgentReport{MinerID: "m1", Algo: "rx/0", Timestamp: behind.Format(time.RFC3339)}
+	if err := s.UpsertMiner(report); err != nil {
+		t.Fatalf("UpsertMiner: %v", err)
+	}
+	m, err := s.GetMiner("m1")
+	if err != nil || m == nil {
+		t.Fatalf("GetMiner = %v, %v", m, err)
+	}
+	if time.Since(m.LastSeen) > time.Minute || m.Status != "online" {
+		t.Errorf("LastSeen = %v (%s), want about now", m.LastSeen, m.Status)
+	}
+
+	// a buffered report sampled earlier by the agent doesn't overwrite it
+	older := &models.AgentReport{MinerID: "m1", Algo: "old", Timestamp: behind.Add(-time.Minute).Format(time.RFC3339)}
+	if err := s.UpsertMiner(older); err != nil {
+		t.Fatalf("UpsertMiner: %v", err)
+	}
+	if m, _ := s.GetMiner("m1"); m.Algo != "rx/0" {
+		t.Errorf("Algo = %q after an older buffered report, want rx/0", m.Algo)
+	}
+}
+
+func TestDeleteMiner(t *testing.T) {
+	s := newTestStore(t)
+
+	for i := 0; i < 3; i++ {
+		if err := s.UpsertMiner(&models.AgentReport{
+			MinerID:  "m1",
+			Hashrate: &models.HashrateData{Current: float64(i)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SetConfigOverride("m1", map[string]interface{}{"a": 1}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.DeleteMiner("m1")
+	if err != nil || n != 3 {
+		t.Fatalf("DeleteMiner = %d, %v; want 3 history rows", n, err)
+	}
+	if override, _ := s.GetLastOverride("m1"); override != nil {
+		t.Error("config override should be deleted with the miner")
+	}
+
+	if _, err := s.DeleteMiner("m1"); !errors.Is(err, ErrMinerNotFound) {
+		t.Errorf("second DeleteMiner error = %v, want ErrMinerNotFound", err)
+	}
+}
+
+func TestGetHashrateRollup(t *testing.T) {
+	s := newTestStore(t)
+
+	// Two samples in one hour, one in the next
+	base := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	for _, sample := range []struct {
+		at      time.Time
+		current float64
+	}{
+		{base.Add(5 * time.Minute), 100},
+		{base.Add(35 * time.Minute), 200},
+		{base.Add(65 * time.Minute), 300},
+	} {
+		if err := s.UpsertMiner(&models.AgentReport{
+			MinerID:   "m1",
+			Hashrate:  &models.HashrateData{Current: sample.current, Max: sample.current},
+			Timestamp: sample.at.Format(time.RFC3339),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rollup, err := s.GetHashrateRollup("m1", base.Add(-time.Minute), time.Hour)
+	if err != nil {
+		t.Fatalf("GetHashrateRollup: %v", err)
+	}
+	if len(rollup) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(rollup))
+	}
+	if !rollup[0].Timestamp.Equal(base) || rollup[0].Current != 150 || rollup[0].Max != 200 {
+		t.Errorf("first bucket = %+v, want start %v, current 150, max 200", rollup[0], base)
+	}
+	if rollup[1].Current != 300 {
+		t.Errorf("second bucket current = %v, want 300", rollup[1].Current)
+	}
+}
+
+func TestGetOverviewRejectRate(t *testing.T) {
+	s := newTestStore(t)
+
+	// the last few minutes: 70 accepted, 10 rejected, 12.5%
+	now := time.Now().UTC()
+	hashrate := &models.HashrateData{Current: 100}
+	for _, r := range []*models.AgentReport{
+		{MinerID: "m1", Accepted: 500, Rejected: 0, Timestamp: now.Add(-time.Hour).Format(time.RFC3339)},
+		{MinerID: "m1", Accepted: 900, Rejected: 10, Timestamp: now.Add(-10 * time.Minute).Format(time.RFC3339)},
+		{MinerID: "m1", Accepted: 930, Rejected: 15, Timestamp: now.Add(-5 * time.Minute).Format(time.RFC3339)},
+		// xmrig restarted: counters start over
+		{MinerID: "m1", Accepted: 10, Rejected: 5, Timestamp: now.Add(-time.Minute).Format(time.RFC3339)},
+		{MinerID: "m2", Accepted: 20, Rejected: 0, Timestamp: now.Add(-8 * time.Minute).Format(time.RFC3339)},
+		{MinerID: "m2", Accepted: 50, Rejected: 0, Timestamp: now.Format(time.RFC3339)},
+	} {
+		r.Hashrate = hashrate
+		if err := s.UpsertMiner(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overview, err := s.GetOverview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.TotalAccepted != 60 || overview.TotalRejected != 5 {
+		t.Errorf("totals = %d/%d, want the latest counters 60/5", overview.TotalAccepted, overview.TotalRejected)
+	}
+	// m1: +30/+5, then +10/+5 after the restart; m2: +30/+0
+	if overview.RejectRate != 12.5 || overview.RejectRateMinutes != 15 {
+		t.Errorf("RejectRate = %v over %d minutes, want 12.5 over 15", overview.RejectRate, overview.RejectRateMinutes)
+	}
+}
+
+func TestCheckpointTruncatesWAL(t *testing.T) {
+	s := newTestStore(t)
+
+	for i := 0; i < 50; i++ {
+		if err := s.UpsertMiner(&models.AgentReport{MinerID: "m1", WorkerID: "w1"}); err != nil {
+			t.Fatalf("UpsertMiner: %v", err)
+		}
+	}
+
+	if err := s.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	var walPath string
+	if err := s.db.QueryRow(`PRAGMA database_list`).Scan(new(int), new(string), &walPath); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(walPath + "-wal")
+	if err != nil {
+		t.Fatalf("stat wal: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("WAL size after checkpoint = %d, want 0", info.Size())
+	}
+}
+
+func TestMinerStatusWindows(t *testing.T) {
+	s, err := openSQLite(filepath.Join(t.TempDir(), "test.db"), Options{
+		OnlineWindow: 3 * time.Minute,
+		StaleWindow:  10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer s.Close()
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{30 * time.Second, "online"},
+		{2 * time.Minute, "online"},
+		{5 * time.Minute, "stale"},
+		{15 * time.Minute, "offline"},
+	}
+	for _, tt := range tests {
+		if got := s.minerStatus(time.Now().Add(-tt.ago)); got != tt.want {
+			t.Errorf("minerStatus(-%v) = %s, want %s", tt.ago, got, tt.want)
+		}
+	}
+
+	if _, err := NewWithOptions(filepath.Join(t.TempDir(), "bad.db"), Options{
+		OnlineWindow: 10 * time.Minute,
+		StaleWindow:  time.Minute,
+	}); err == nil {
+		t.Error("NewWithOptions accepted a stale window shorter than the online window")
+	}
+}
+
+func TestUpsertMinerStoresHugepagesAndMSR(t *testing.T) {
+	s := newTestStore(t)
+
+	if err := s.UpsertMiner(&models.AgentReport{MinerID: "m1", Hugepages: true, MSR: true}); err != nil {
+		t.Fatalf("UpsertMiner: %v", err)
+	}
+	m, err := s.GetMiner("m1")
+	if err != nil || m == nil {
+		t.Fatalf("GetMiner = %v, %v", m, err)
+	}
+	if !m.Hugepages || !m.MSR {
+		t.Fatalf("hugepages=%v msr=%v, want both true", m.Hugepages, m.MSR)
+	}
+
+	if err := s.UpsertMiner(&models.AgentReport{MinerID: "m1"}); err != nil {
+		t.Fatalf("UpsertMiner: %v", err)
+	}
+	if m, _ = s.GetMiner("m1"); m.Hugepages || m.MSR {
+		t.Fatalf("hugepages=%v msr=%v after a report without them, want both false", m.Hugepages, m.MSR)
+	}
+}
+
+func TestConfigOverrideHistory(t *testing.T) {
+	s := newTestStore(t)
+
+	if err := s.SetConfigOverride("m1", map[string]interface{}{"donate-level": 1.0}, "10.0.0.5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkConfigApplied("m1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetConfigOverride("m1", map[string]interface{}{"donate-level": 2.0}, "10.0.0.6"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.GetConfigOverrideHistory("m1", 10)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("history = %d entries, %v; want 2", len(entries), err)
+	}
+	latest, first := entries[0], entries[1]
+	if latest.Override["donate-level"] != 2.0 || latest.SetBy != "10.0.0.6" || latest.AppliedAt != nil {
+		t.Errorf("latest = %+v, want the pending donate-level 2 from 10.0.0.6", latest)
+	}
+	if first.Override["donate-level"] != 1.0 || first.AppliedAt == nil {
+		t.Errorf("first = %+v, want the applied donate-level 1", first)
+	}
+
+	if entries, _ := s.GetConfigOverrideHistory("m1", 1); len(entries) != 1 || entries[0].ID != latest.ID {
+		t.Errorf("limit 1 = %v, want only the latest entry", entries)
+	}
+
+	// the audit trail outlives the miner
+	s.UpsertMiner(&models.AgentReport{MinerID: "m1"})
+	if _, err := s.DeleteMiner("m1"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := s.GetConfigOverrideHistory("m1", 10); len(entries) != 2 {
+		t.Errorf("history after DeleteMiner = %d entries, want 2", len(entries))
+	}
+}
+
+func TestGetConfigDriftGroupsByTag(t *testing.T) {
+	s := newTestStore(t)
+
+	renderCfg := map[string]interface{}{"cpu": map[string]interface{}{"max-threads-hint": 100}}
+	buildCfg := map[string]interface{}{"cpu": map[string]interface{}{"max-threads-hint": 50}}
+	// Two tags in one family: render has an outlier, build agrees with
+	// itself even though it runs another config than most render rigs
+	reports := []struct {
+		id, tag string
+		cfg     map[string]interface{}
+	}{
+		{"m1", "render", renderCfg},
+		{"m2", "render", renderCfg},
+		{"m3", "render", buildCfg},
+		{"m4", "build", buildCfg},
+		{"m5", "build", buildCfg},
+		{"m6", "", renderCfg},
+	}
+	for _, r := range reports {
+		report := &models.AgentReport{MinerID: r.id, Tag: r.tag, CPUFamily: "zen3", Config: r.cfg}
+		if err := s.UpsertMiner(report); err != nil {
+			t.Fatalf("UpsertMiner(%s): %v", r.id, err)
+		}
+	}
+
+	groups, err := s.GetConfigDrift()
+	if err != nil {
+		t.Fatalf("GetConfigDrift: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d drift groups, want 1 (render)", len(groups))
+	}
+	g := groups[0]
+	if g.CPUFamily != "zen3" || g.Tag != "render" || g.Miners != 3 {
+		t.Errorf("group = %s/%s with %d miners, want zen3/render with 3", g.CPUFamily, g.Tag, g.Miners)
+	}
+	if len(g.Outliers) != 1 || g.Outliers[0].ID != "m3" {
+		t.Errorf("outliers = %v, want only m3", g.Outliers)
+	}
+
+	m, err := s.GetMiner("m4")
+	if err != nil || m.Tag != "build" {
+		t.Errorf("GetMiner(m4) tag = %q, %v; want build", m.Tag, err)
+	}
+}
+
+func TestEachHashrateSamplePages(t *testing.T) {
+	s := newTestStore(t)
+	orig := historyPageSize
+	historyPageSize = 2
+	defer func() { historyPageSize = orig }()
+
+	// five samples, two sharing a timestamp across a page boundary
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	offsets := []time.Duration{0, time.Minute, time.Minute, 2 * time.Minute, 3 * time.Minute}
+	for i, off := range offsets {
+		report := &models.AgentReport{
+			MinerID:   "m1",
+			Hashrate:  &models.HashrateData{Current: float64(i)},
+			Timestamp: base.Add(off).Format(time.RFC3339),
+		}
+		if err := s.UpsertMiner(report); err != nil {
+			t.Fatalf("UpsertMiner: %v", err)
+		}
+	}
+
+	var got []float64
+	err := s.EachHashrateSample("m1", base.Add(-time.Minute), func(h *models.HashrateHistory) error {
+		got = append(got, h.Current)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachHashrateSample: %v", err)
+	}
+	if len(got) != len(offsets) {
+		t.Fatalf("got %d samples %v, want %d", len(got), got, len(offsets))
+	}
+	for i, v := range got {
+		if v != float64(i) {
+			t.Errorf("samples = %v, want 0..4 in order", got)
+			break
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"tarish-server/models"
+)
+
+// ErrMissingID is returned by UpsertMiner for reports with neither a
+// miner_id nor a worker_id
+var ErrMissingID = errors.New("miner_id or worker_id required")
+
+// ErrMinerNotFound is returned by DeleteMiner for an unknown miner ID
+var ErrMinerNotFound = errors.New("miner not found")
+
+// Default miner status thresholds, sized for the agent's 30s heartbeat
+const (
+	DefaultOnlineWindow = 90 * time.Second
+	DefaultStaleWindow  = 5 * time.Minute
+)
+
+// RejectRateWindow is how far back the overview's reject rate looks
+const RejectRateWindow = 15 * time.Minute
+
+// Options configures a Store
+type Options struct {
+	// OnlineWindow is how recently a miner must have reported to be
+	// "online"; zero means DefaultOnlineWindow
+	OnlineWindow time.Duration
+	// StaleWindow is how recently a miner must have reported to be
+	// "stale" rather than "offline"; zero means DefaultStaleWindow
+	StaleWindow time.Duration
+}
+
+// Store is the server's persistence layer. New opens the default SQLite
+// backend, NewPostgres a PostgreSQL one.
+type Store interface {
+	Close() error
+	// Checkpoint trims the backend's write-ahead log where it keeps one
+	// itself (SQLite); a no-op otherwise
+	Checkpoint() error
+
+	UpsertMiner(report *models.AgentReport) error
+	GetMiners() ([]*models.Miner, error)
+	GetMiner(id string) (*models.Miner, error)
+	DeleteMiner(minerID string) (int, error)
+	PruneMiners(olderThan time.Duration) (int, error)
+
+	SetConfigOverride(minerID string, override map[string]interface{}, setBy string) error
+	GetConfigOverride(minerID string) (map[string]interface{}, error)
+	GetLastOverride(minerID string) (map[string]interface{}, error)
+	GetConfigOverrideHistory(minerID string, limit int) ([]*models.ConfigOverrideLogEntry, error)
+	MarkConfigApplied(minerID string) error
+	DeleteConfigOverride(minerID string) error
+
+	SetTarishOverride(minerID string, settings map[string]interface{}) error
+	GetTarishSettings(minerID string) (*models.TarishSettings, error)
+	GetTarishOverride(minerID string) (map[string]interface{}, error)
+	MarkTarishOverrideApplied(minerID string) error
+	DeleteTarishOverride(minerID string) error
+
+	GetHashrateHistory(minerID string, since time.Time) ([]*models.HashrateHistory, error)
+	EachHashrateSample(minerID string, since time.Time, fn func(*models.HashrateHistory) error) error
+	GetHashrateRollup(minerID string, since time.Time, bucket time.Duration) ([]*models.HashrateHistory, error)
+	AddProxySample(current, average float64, workers int) error
+	GetProxyHistory(since time.Time) ([]*models.ProxyHistory, error)
+	PruneHistory(olderThan time.Duration) error
+
+	GetOverview() (*models.OverviewResponse, error)
+	GetConfigDrift() ([]*models.ConfigDriftGroup, error)
+}
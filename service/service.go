@@ -1,12 +1,16 @@
 package service
 
 import (
+	"bytes"
+	"encoding/xml"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"text/template"
 )
 
 const (
@@ -16,13 +20,13 @@ const (
 	plistName              = "com.tarish.plist"
 
 	// Linux systemd paths
-	systemdPath    = "/etc/systemd/system"
-	systemdService = "tarish.service"
+	systemdPath     = "/etc/systemd/system"
+	userSystemdPath = ".config/systemd/user" // Relative to Home
+	systemdService  = "tarish.service"
 )
 
-// launchPlistTemplate is the macOS LaunchDaemon/Agent plist template
-// %s placeholders: 1=binary path, 2=log path, 3=error log path, 4=working dir
-const launchPlistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+// launchPlistTemplate is the macOS LaunchDaemon/Agent plist template, rendered with serviceParams
+var launchPlistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
 <dict>
@@ -30,41 +34,137 @@ const launchPlistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
     <string>com.tarish</string>
     <key>ProgramArguments</key>
     <array>
-        <string>%s</string>
+        <string>{{xml .BinaryPath}}</string>
         <string>start</string>
         <string>--force</string>
     </array>
     <key>RunAtLoad</key>
     <true/>
     <key>KeepAlive</key>
+    {{- if eq .Restart "always"}}
+    <true/>
+    {{- else if eq .Restart "on-failure"}}
+    <dict>
+        <key>SuccessfulExit</key>
+        <false/>
+    </dict>
+    {{- else}}
     <false/>
+    {{- end}}
     <key>StandardOutPath</key>
-    <string>%s</string>
+    <string>{{xml .LogPath}}</string>
     <key>StandardErrorPath</key>
-    <string>%s</string>
+    <string>{{xml .ErrorLogPath}}</string>
     <key>WorkingDirectory</key>
-    <string>%s</string>
+    <string>{{xml .WorkingDir}}</string>
+    {{- if .Nice}}
+    <key>Nice</key>
+    <integer>{{.Nice}}</integer>
+    {{- end}}
+    {{- if .IdleIO}}
+    <key>LowPriorityIO</key>
+    <true/>
+    {{- end}}
 </dict>
 </plist>
-`
+`))
 
-// systemdTemplate is the Linux systemd unit file template
-// %s placeholders: 1=binary path, 2=binary path (stop), 3=PID file
-const systemdTemplate = `[Unit]
+// systemdTemplate is the Linux systemd unit file template, rendered with serviceParams
+var systemdTemplate = template.Must(template.New("systemd").Parse(`[Unit]
 Description=Tarish Donate-free XMRig Manager
 After=network.target
 
 [Service]
 Type=forking
-ExecStart=%s start --force
-ExecStop=%s stop
-PIDFile=%s
-Restart=on-failure
-RestartSec=10
+ExecStart={{.BinaryPath}} start --force
+ExecStop={{.BinaryPath}} stop
+PIDFile={{.PIDFile}}
+Restart={{.Restart}}
+RestartSec={{.RestartSec}}
+{{- if .CPUQuota}}
+CPUQuota={{.CPUQuota}}
+{{- end}}
+{{- if .Nice}}
+Nice={{.Nice}}
+{{- end}}
+{{- if .IdleIO}}
+IOSchedulingClass=idle
+{{- end}}
 
 [Install]
-WantedBy=multi-user.target
-`
+WantedBy={{.WantedBy}}
+`))
+
+// Options holds optional resource limits for the service. Limits are
+// applied by systemd to the whole unit, so they cover the xmrig process.
+type Options struct {
+	// CPUQuota caps CPU time, e.g. "50%" is half a core, "200%" two cores
+	CPUQuota string
+	// Nice sets the scheduling priority (-20..19); 0 leaves it unchanged
+	Nice int
+	// IdleIO runs the miner in the idle I/O scheduling class
+	IdleIO bool
+}
+
+// HasLimits reports whether any resource limit is set
+func (o Options) HasLimits() bool {
+	return o.CPUQuota != "" || o.Nice != 0 || o.IdleIO
+}
+
+// Validate checks the limits and normalizes CPUQuota to "<n>%"
+func (o *Options) Validate() error {
+	if o.CPUQuota != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(o.CPUQuota), "%"))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid CPU quota %q (expected a percentage like 50%%)", o.CPUQuota)
+		}
+		o.CPUQuota = strconv.Itoa(n) + "%"
+	}
+	if o.Nice < -20 || o.Nice > 19 {
+		return fmt.Errorf("invalid nice value %d (expected -20..19)", o.Nice)
+	}
+	return nil
+}
+
+// serviceParams holds the values rendered into the service templates
+type serviceParams struct {
+	BinaryPath   string
+	LogPath      string // launchd only; systemd logs to the journal
+	ErrorLogPath string // launchd only
+	WorkingDir   string // launchd only
+	PIDFile      string // systemd only
+	WantedBy     string // systemd install target
+	// Restart is the systemd restart policy ("no", "on-failure", "always").
+	// launchd has no direct equivalent: only "always" sets KeepAlive.
+	Restart    string
+	RestartSec int
+	Options
+}
+
+// defaultParams fills in the settings shared by every platform
+func defaultParams(binPath string, opts Options) serviceParams {
+	return serviceParams{
+		BinaryPath: binPath,
+		Restart:    "on-failure",
+		RestartSec: 10,
+		Options:    opts,
+	}
+}
+
+// xmlEscape escapes a value for use inside a plist <string>
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func renderTemplate(t *template.Template, params serviceParams) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, params); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", t.Name(), err)
+	}
+	return buf.String(), nil
+}
 
 // getInstallPaths returns binary and share paths based on user/root
 func getInstallPaths() (binPath, sharePath string) {
@@ -109,11 +209,24 @@ func findSharePath(binPath string) string {
 
 // Enable installs and enables the auto-start service
 func Enable() error {
+	return EnableWithOptions(Options{})
+}
+
+// EnableWithOptions installs and enables the auto-start service with
+// optional resource limits (CPU quota is systemd only)
+func EnableWithOptions(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
 	switch runtime.GOOS {
 	case "darwin":
-		return enableMacOS()
+		if opts.CPUQuota != "" {
+			fmt.Println("Warning: --cpu-quota is only supported with systemd, ignoring")
+		}
+		return enableMacOS(opts)
 	case "linux":
-		return enableLinux()
+		return enableLinux(opts)
 	default:
 		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -131,6 +244,105 @@ func Disable() error {
 	}
 }
 
+// Start starts the installed service now
+func Start() error {
+	return control("start")
+}
+
+// Stop stops the installed service (and the xmrig it launched)
+func Stop() error {
+	return control("stop")
+}
+
+// Restart restarts the installed service, e.g. after a config change
+func Restart() error {
+	return control("restart")
+}
+
+// control runs a start/stop/restart action through the platform's service manager
+func control(action string) error {
+	if !IsInstalled() {
+		return fmt.Errorf("service is not enabled. Run 'tarish service enable' first")
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return controlMacOS(action)
+	case "linux":
+		return controlLinux(action)
+	default:
+		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+}
+
+// controlMacOS drives the launchd job. The job only runs 'tarish start
+// --force', which detaches xmrig, so kickstart -k restarts mining and stop
+// goes through 'tarish stop' rather than launchctl.
+func controlMacOS(action string) error {
+	_, isRoot, err := getMacOSPlistPath()
+	if err != nil {
+		return err
+	}
+	target := fmt.Sprintf("gui/%d/com.tarish", os.Getuid())
+	if isRoot {
+		target = "system/com.tarish"
+	}
+
+	var cmd *exec.Cmd
+	switch action {
+	case "start":
+		cmd = exec.Command("launchctl", "kickstart", target)
+	case "restart":
+		cmd = exec.Command("launchctl", "kickstart", "-k", target)
+	case "stop":
+		binPath, err := findTarishBinary()
+		if err != nil {
+			return err
+		}
+		cmd = exec.Command(binPath, "stop")
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to %s service: %v: %s", action, err, strings.TrimSpace(string(out)))
+	}
+	fmt.Printf("Service %s: ok\n", action)
+	return nil
+}
+
+// controlLinux runs systemctl [--user] <action> tarish.service
+func controlLinux(action string) error {
+	out, err := systemctl(os.Geteuid() == 0, action, systemdService).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to %s service: %v: %s", action, err, strings.TrimSpace(string(out)))
+	}
+	fmt.Printf("Service %s: ok\n", action)
+	return nil
+}
+
+// IsInstalled reports whether a service file (systemd unit or launchd
+// plist) exists for the current user/root context, enabled or not
+func IsInstalled() bool {
+	var path string
+	switch runtime.GOOS {
+	case "darwin":
+		p, _, err := getMacOSPlistPath()
+		if err != nil {
+			return false
+		}
+		path = p
+	case "linux":
+		p, _, err := getSystemdUnitPath()
+		if err != nil {
+			return false
+		}
+		path = p
+	default:
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 // IsEnabled checks if the service is enabled
 func IsEnabled() (bool, error) {
 	switch runtime.GOOS {
@@ -163,7 +375,7 @@ func getMacOSPlistPath() (string, bool, error) {
 }
 
 // enableMacOS installs the LaunchDaemon/Agent on macOS
-func enableMacOS() error {
+func enableMacOS(opts Options) error {
 	// Find tarish binary
 	binPath, err := findTarishBinary()
 	if err != nil {
@@ -185,7 +397,16 @@ func enableMacOS() error {
 	}
 
 	// Generate plist content with correct paths
-	plistContent := fmt.Sprintf(launchPlistTemplate, binPath, logPath, errorLogPath, sharePath)
+	params := defaultParams(binPath, opts)
+	params.LogPath = logPath
+	params.ErrorLogPath = errorLogPath
+	params.WorkingDir = sharePath
+	// 'tarish start' exits once xmrig is detached; don't relaunch it
+	params.Restart = "no"
+	plistContent, err := renderTemplate(launchPlistTemplate, params)
+	if err != nil {
+		return err
+	}
 
 	// Write plist file
 	if err := os.WriteFile(plistPath, []byte(plistContent), 0644); err != nil {
@@ -263,10 +484,33 @@ func isEnabledMacOS() (bool, error) {
 	return true, nil
 }
 
+// getSystemdUnitPath returns the unit file path based on permissions:
+// the system unit for root, a user unit (systemctl --user) otherwise
+func getSystemdUnitPath() (string, bool, error) {
+	if os.Geteuid() == 0 {
+		return filepath.Join(systemdPath, systemdService), true, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false, err
+	}
+	return filepath.Join(home, userSystemdPath, systemdService), false, nil
+}
+
+// systemctl builds a systemctl command, adding --user for user units
+func systemctl(isRoot bool, args ...string) *exec.Cmd {
+	if !isRoot {
+		args = append([]string{"--user"}, args...)
+	}
+	return exec.Command("systemctl", args...)
+}
+
 // enableLinux installs the systemd service on Linux
-func enableLinux() error {
-	if os.Geteuid() != 0 {
-		return fmt.Errorf("enabling service requires root privileges. Run with sudo")
+func enableLinux(opts Options) error {
+	servicePath, isRoot, err := getSystemdUnitPath()
+	if err != nil {
+		return err
 	}
 
 	// Find tarish binary
@@ -280,47 +524,71 @@ func enableLinux() error {
 	pidFile := filepath.Join(sharePath, "log", "xmrig.pid")
 
 	// Write service file
-	servicePath := filepath.Join(systemdPath, systemdService)
-	serviceContent := fmt.Sprintf(systemdTemplate, binPath, binPath, pidFile)
+	wantedBy := "multi-user.target"
+	if !isRoot {
+		wantedBy = "default.target"
+		if err := os.MkdirAll(filepath.Dir(servicePath), 0755); err != nil {
+			return fmt.Errorf("failed to create systemd user directory: %w", err)
+		}
+	}
+	params := defaultParams(binPath, opts)
+	params.PIDFile = pidFile
+	params.WantedBy = wantedBy
+	serviceContent, err := renderTemplate(systemdTemplate, params)
+	if err != nil {
+		return err
+	}
 	if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
 		return fmt.Errorf("failed to write systemd service: %w", err)
 	}
 
 	// Reload systemd
-	if err := exec.Command("systemctl", "daemon-reload").Run(); err != nil {
+	if err := systemctl(isRoot, "daemon-reload").Run(); err != nil {
 		return fmt.Errorf("failed to reload systemd: %w", err)
 	}
 
 	// Enable the service
-	if err := exec.Command("systemctl", "enable", systemdService).Run(); err != nil {
+	if err := systemctl(isRoot, "enable", systemdService).Run(); err != nil {
 		return fmt.Errorf("failed to enable service: %w", err)
 	}
 
 	fmt.Println("Service enabled successfully")
-	fmt.Println("Tarish will start automatically on boot")
-	fmt.Println("To start now, run: sudo systemctl start tarish")
+	if isRoot {
+		fmt.Println("Tarish will start automatically on boot")
+		fmt.Println("To start now, run: sudo tarish service start")
+	} else {
+		fmt.Println("Tarish will start automatically when you log in")
+		fmt.Println("To keep it running while logged out, run: loginctl enable-linger")
+		fmt.Println("To start now, run: tarish service start")
+	}
 	return nil
 }
 
 // disableLinux removes the systemd service on Linux
 func disableLinux() error {
-	if os.Geteuid() != 0 {
-		return fmt.Errorf("disabling service requires root privileges. Run with sudo")
+	servicePath, isRoot, err := getSystemdUnitPath()
+	if err != nil {
+		return err
 	}
 
-	servicePath := filepath.Join(systemdPath, systemdService)
-
 	// Check if service exists
 	if _, err := os.Stat(servicePath); os.IsNotExist(err) {
+		// Try checking the system unit just in case
+		if !isRoot {
+			sysPath := filepath.Join(systemdPath, systemdService)
+			if _, err := os.Stat(sysPath); err == nil {
+				return fmt.Errorf("system service found at %s. Run with sudo to disable", sysPath)
+			}
+		}
 		fmt.Println("Service is not installed")
 		return nil
 	}
 
 	// Stop the service if running
-	exec.Command("systemctl", "stop", systemdService).Run()
+	systemctl(isRoot, "stop", systemdService).Run()
 
 	// Disable the service
-	exec.Command("systemctl", "disable", systemdService).Run()
+	systemctl(isRoot, "disable", systemdService).Run()
 
 	// Remove the service file
 	if err := os.Remove(servicePath); err != nil {
@@ -328,7 +596,7 @@ func disableLinux() error {
 	}
 
 	// Reload systemd
-	exec.Command("systemctl", "daemon-reload").Run()
+	systemctl(isRoot, "daemon-reload").Run()
 
 	fmt.Println("Service disabled successfully")
 	return nil
@@ -336,7 +604,7 @@ func disableLinux() error {
 
 // isEnabledLinux checks if the systemd service is enabled on Linux
 func isEnabledLinux() (bool, error) {
-	output, err := exec.Command("systemctl", "is-enabled", systemdService).Output()
+	output, err := systemctl(os.Geteuid() == 0, "is-enabled", systemdService).Output()
 	if err != nil {
 		// Service not found or disabled
 		return false, nil
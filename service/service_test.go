@@ -0,0 +1,75 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemdTemplateLimits(t *testing.T) {
+	params := defaultParams("/usr/local/bin/tarish", Options{})
+	params.PIDFile = "/usr/local/share/tarish/log/xmrig.pid"
+	params.WantedBy = "multi-user.target"
+
+	plain, err := renderTemplate(systemdTemplate, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, directive := range []string{"CPUQuota=", "Nice=", "IOSchedulingClass="} {
+		if strings.Contains(plain, directive) {
+			t.Errorf("unit without limits contains %q", directive)
+		}
+	}
+	for _, directive := range []string{"ExecStart=/usr/local/bin/tarish start --force\n", "Restart=on-failure\n", "RestartSec=10\n"} {
+		if !strings.Contains(plain, directive) {
+			t.Errorf("unit missing %q:\n%s", directive, plain)
+		}
+	}
+
+	params.Options = Options{CPUQuota: "50", Nice: 10, IdleIO: true}
+	if err := params.Options.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	limited, err := renderTemplate(systemdTemplate, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, directive := range []string{"CPUQuota=50%\n", "Nice=10\n", "IOSchedulingClass=idle\n"} {
+		if !strings.Contains(limited, directive) {
+			t.Errorf("unit missing %q:\n%s", directive, limited)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	for _, opts := range []Options{{CPUQuota: "abc"}, {CPUQuota: "0%"}, {Nice: 20}, {Nice: -21}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", opts)
+		}
+	}
+}
+
+func TestLaunchPlistTemplate(t *testing.T) {
+	params := defaultParams("/Users/me/.local/bin/tarish", Options{Nice: 10})
+	params.LogPath = "/Users/me/.local/share/tarish/log/tarish.log"
+	params.ErrorLogPath = "/Users/me/.local/share/tarish/log/tarish.error.log"
+	params.WorkingDir = "/Users/me/R&D"
+	params.Restart = "no"
+
+	plist, err := renderTemplate(launchPlistTemplate, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<string>/Users/me/.local/bin/tarish</string>",
+		"<key>KeepAlive</key>\n    <false/>",
+		"<string>/Users/me/R&amp;D</string>",
+		"<key>Nice</key>\n    <integer>10</integer>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+	if strings.Contains(plist, "LowPriorityIO") {
+		t.Errorf("plist contains LowPriorityIO without IdleIO:\n%s", plist)
+	}
+}
@@ -1,6 +1,7 @@
 package update
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +10,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"golang.org/x/mod/semver"
 )
 
 const (
@@ -16,6 +19,8 @@ const (
 	baseURL = "https://file.aooo.nl/tarish"
 )
 
+var errNotFound = errors.New("not found")
+
 // Version is set at build time via -ldflags
 var Version = "dev"
 
@@ -34,7 +39,7 @@ func Update() error {
 	} else {
 		fmt.Printf("Latest version: %s\n", latestVersion)
 
-		if currentVersion == latestVersion && currentVersion != "dev" {
+		if currentVersion != "dev" && !isNewer(latestVersion, currentVersion) {
 			fmt.Println("You are already running the latest version")
 			return nil
 		}
@@ -43,6 +48,62 @@ func Update() error {
 	return downloadAndReplace()
 }
 
+// UpdateToVersion installs a specific tarish release, downgrading if needed.
+// Pinned builds live under dist/<version>/ on the update server.
+func UpdateToVersion(version string) error {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return fmt.Errorf("version is required")
+	}
+
+	currentVersion := GetCurrentVersion()
+	fmt.Printf("Current version: %s\n", currentVersion)
+	fmt.Printf("Target version:  %s\n", version)
+
+	// dev builds have no ordering; always install the pinned release
+	if currentVersion != "dev" {
+		cmp := compareVersions(version, currentVersion)
+		if cmp == 0 {
+			fmt.Printf("Already running %s\n", currentVersion)
+			return nil
+		}
+		if cmp < 0 {
+			fmt.Printf("\033[1;33mWARNING: downgrading tarish %s -> %s\033[0m\n", currentVersion, version)
+			fmt.Println("\033[1;33mAuto-update will move back to the latest release unless you run 'tarish update disable'\033[0m")
+		}
+	}
+
+	downloadURL := fmt.Sprintf("%s/dist/%s/%s", baseURL, version, getBinaryName())
+	return downloadAndReplaceFrom(downloadURL)
+}
+
+// compareVersions compares two tarish versions with semver ordering,
+// accepting them with or without a leading "v". Invalid versions sort
+// before valid ones, as in semver.Compare.
+func compareVersions(a, b string) int {
+	return semver.Compare(canonicalVersion(a), canonicalVersion(b))
+}
+
+// isNewer reports whether latest is strictly newer than current, so tag
+// formatting differences ("v1.2.0" vs "1.2.0") never trigger a reinstall
+// and an older "latest" never downgrades. If either side isn't valid
+// semver we can't order them and fall back to plain inequality.
+func isNewer(latest, current string) bool {
+	l, c := canonicalVersion(latest), canonicalVersion(current)
+	if !semver.IsValid(l) || !semver.IsValid(c) {
+		return strings.TrimSpace(latest) != strings.TrimSpace(current)
+	}
+	return semver.Compare(l, c) > 0
+}
+
+func canonicalVersion(v string) string {
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	return v
+}
+
 // AutoUpdateResult represents the outcome of an auto-update attempt.
 type AutoUpdateResult int
 
@@ -69,7 +130,7 @@ func AutoUpdate() AutoUpdateResult {
 		return AutoUpdateCheckErr
 	}
 
-	if latestVersion == currentVersion {
+	if !isNewer(latestVersion, currentVersion) {
 		return AutoUpdateNoChange
 	}
 
@@ -85,14 +146,19 @@ func AutoUpdate() AutoUpdateResult {
 	return AutoUpdateApplied
 }
 
-// downloadAndReplace fetches the platform binary and replaces the current one
+// downloadAndReplace fetches the latest platform binary and replaces the current one
 func downloadAndReplace() error {
-	binaryName := getBinaryName()
-	downloadURL := fmt.Sprintf("%s/dist/%s", baseURL, binaryName)
+	return downloadAndReplaceFrom(fmt.Sprintf("%s/dist/%s", baseURL, getBinaryName()))
+}
 
-	fmt.Printf("Downloading %s...\n", binaryName)
+// downloadAndReplaceFrom fetches the binary at downloadURL and replaces the current one
+func downloadAndReplaceFrom(downloadURL string) error {
+	fmt.Printf("Downloading %s...\n", downloadURL)
 
 	tempFile, err := downloadFile(downloadURL)
+	if errors.Is(err, errNotFound) {
+		return fmt.Errorf("no build for %s at %s", getBinaryName(), downloadURL)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}
@@ -145,7 +211,7 @@ func CheckForUpdates() (bool, string, error) {
 	}
 
 	currentVersion := GetCurrentVersion()
-	return currentVersion != latestVersion && currentVersion != "dev", latestVersion, nil
+	return currentVersion != "dev" && isNewer(latestVersion, currentVersion), latestVersion, nil
 }
 
 // getBinaryName returns the expected binary name for current platform
@@ -169,6 +235,9 @@ func downloadFile(url string) (string, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errNotFound
+	}
 	if resp.StatusCode != 200 {
 		return "", fmt.Errorf("download failed with status %d", resp.StatusCode)
 	}
@@ -0,0 +1,34 @@
+package update
+
+import "testing"
+
+func TestIsNewer(t *testing.T) {
+	cases := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"1.2.0", "v1.2.0", false},
+		{"v1.2.0", "1.2.0", false},
+		{"1.2.10", "1.2.9", true},
+		{"1.2.9", "1.2.10", false},
+		{"1.3.0", "1.2.99", true},
+		{"1.2.0", "1.2.1", false},
+		{" 1.2.1\n", "1.2.0", true},
+		{"nightly", "1.2.0", true},
+		{"1.2.0", "1.2.0", false},
+	}
+	for _, c := range cases {
+		if got := isNewer(c.latest, c.current); got != c.want {
+			t.Errorf("isNewer(%q, %q) = %v, want %v", c.latest, c.current, got, c.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	if compareVersions("v1.0.0", "1.0.0") != 0 {
+		t.Error("v-prefix should not affect ordering")
+	}
+	if compareVersions("1.0.0", "1.1.0") >= 0 {
+		t.Error("1.0.0 should sort before 1.1.0")
+	}
+}
@@ -0,0 +1,182 @@
+package watchdog
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"tarish/antisleep"
+	"tarish/daemon"
+	"tarish/logging"
+	"tarish/xmrig"
+)
+
+// pollInterval is how often the daemon reads xmrig's hashrate
+const pollInterval = 30 * time.Second
+
+// DefaultWindow is how long xmrig may sit at 0 H/s before it is restarted.
+// It has to outlast a normal start: allocating the RandomX dataset alone
+// takes up to a minute on slow machines.
+const DefaultWindow = 5 * time.Minute
+
+// MinWindow is the shortest window StartDaemon accepts
+const MinWindow = time.Minute
+
+// stopMining and startMining are swapped out in tests
+var (
+	stopMining  = xmrig.Stop
+	startMining = xmrig.StartWithOptions
+)
+
+// Options describes the xmrig the watchdog supervises and restarts
+type Options struct {
+	Window     time.Duration
+	BinaryPath string
+	ConfigPath string
+	CPUs       string
+	SleepMode  antisleep.Mode
+}
+
+// RunDaemon restarts xmrig whenever its API has reported 0 H/s for longer
+// than opts.Window. A paused xmrig, or one whose API can't be read, doesn't
+// count as stalled. Exits once xmrig is no longer running, so 'tarish
+// stop' isn't undone. Invoked via the hidden "_watchdog-daemon" command.
+func RunDaemon(opts Options) {
+	logger := logging.New(os.Stdout, "watchdog")
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+
+	logger.Info("started", "pid", os.Getpid(), "window", opts.Window)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	w := &watchdog{opts: opts, logger: logger}
+	apiDown := false
+	for {
+		select {
+		case <-ticker.C:
+			if _, running := xmrig.IsRunning(); !running {
+				xmrig.ReapExited()
+				logger.Info("xmrig is not running, exiting")
+				return
+			}
+			summary, err := xmrig.APISummary()
+			if err != nil && !apiDown {
+				logger.Warn("cannot read hashrate from the xmrig API", "err", err)
+			}
+			apiDown = err != nil
+			w.check(time.Now(), summary)
+		case <-sig:
+			logger.Info("received signal, shutting down")
+			return
+		}
+	}
+}
+
+// watchdog tracks how long xmrig has been stalled
+type watchdog struct {
+	opts      Options
+	logger    *slog.Logger
+	zeroSince time.Time // start of the current 0 H/s stretch, zero if hashing
+	restarts  int
+}
+
+// check applies one API sample, nil if the API couldn't be read, and
+// restarts xmrig once it has been at 0 H/s for longer than the window
+func (w *watchdog) check(now time.Time, summary *xmrig.APIResponse) {
+	if summary == nil || summary.Paused || hashrate(summary) > 0 {
+		w.zeroSince = time.Time{}
+		return
+	}
+	if w.zeroSince.IsZero() {
+		w.zeroSince = now
+		return
+	}
+	stalled := now.Sub(w.zeroSince)
+	if stalled <= w.opts.Window {
+		return
+	}
+
+	w.restarts++
+	w.zeroSince = time.Time{}
+	w.logger.Warn("hashrate at 0 H/s, restarting xmrig",
+		"stalled", stalled.Round(time.Second), "pool", summary.Connection.Pool, "restarts", w.restarts)
+	if err := stopMining(); err != nil {
+		w.logger.Error("failed to stop xmrig", "err", err)
+		return
+	}
+	startOpts := xmrig.StartOptions{Force: true, CPUs: w.opts.CPUs, SleepMode: w.opts.SleepMode}
+	if err := startMining(w.opts.BinaryPath, w.opts.ConfigPath, startOpts); err != nil {
+		w.logger.Error("failed to restart xmrig", "err", err)
+		return
+	}
+	w.logger.Info("xmrig restarted")
+}
+
+// hashrate is the 10s average, which xmrig reports as null (0) until the
+// first measurement
+func hashrate(summary *xmrig.APIResponse) float64 {
+	if len(summary.Hashrate.Total) == 0 {
+		return 0
+	}
+	return summary.Hashrate.Total[0]
+}
+
+// daemonProc is the background "_watchdog-daemon" process
+var daemonProc = daemon.New("watchdog")
+
+// StartDaemon spawns the watchdog as a background process, replacing one
+// that is already running so new options apply.
+func StartDaemon(opts Options) error {
+	StopDaemon()
+
+	if opts.Window < MinWindow {
+		return fmt.Errorf("watchdog window must be at least %v", MinWindow)
+	}
+
+	_, err := daemonProc.Start(strconv.Itoa(int(opts.Window.Seconds())),
+		opts.BinaryPath, opts.ConfigPath, opts.CPUs, opts.SleepMode.String())
+	return err
+}
+
+// ParseDaemonArgs reads the arguments StartDaemon passes to
+// "_watchdog-daemon": window seconds, binary, config, CPU list and sleep mode
+func ParseDaemonArgs(args []string) (Options, error) {
+	if len(args) < 3 {
+		return Options{}, fmt.Errorf("usage: _watchdog-daemon <window-seconds> <binary> <config> [cpus] [sleep-mode]")
+	}
+	secs, err := strconv.Atoi(args[0])
+	if err != nil || secs <= 0 {
+		return Options{}, fmt.Errorf("invalid watchdog window %q", args[0])
+	}
+	opts := Options{
+		Window:     time.Duration(secs) * time.Second,
+		BinaryPath: args[1],
+		ConfigPath: args[2],
+	}
+	if len(args) > 3 {
+		opts.CPUs = args[3]
+	}
+	if len(args) > 4 {
+		if opts.SleepMode, err = antisleep.ParseMode(args[4]); err != nil {
+			return Options{}, err
+		}
+	}
+	return opts, nil
+}
+
+// StopDaemon sends SIGTERM to the watchdog daemon (if running).
+func StopDaemon() {
+	daemonProc.Stop()
+}
+
+// IsDaemonRunning reports the PID and whether the watchdog daemon is alive.
+func IsDaemonRunning() (int, bool) {
+	return daemonProc.IsRunning()
+}
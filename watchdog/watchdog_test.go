@@ -0,0 +1,90 @@
+package watchdog
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"tarish/antisleep"
+	"tarish/xmrig"
+)
+
+func summary(t *testing.T, s string) *xmrig.APIResponse {
+	t.Helper()
+	var resp xmrig.APIResponse
+	if err := json.Unmarshal([]byte(s), &resp); err != nil {
+		t.Fatalf("unmarshal %s: %v", s, err)
+	}
+	return &resp
+}
+
+func TestCheckRestartsAfterWindow(t *testing.T) {
+	origStop, origStart := stopMining, startMining
+	defer func() { stopMining, startMining = origStop, origStart }()
+
+	var calls []string
+	stopMining = func() error { calls = append(calls, "stop"); return nil }
+	startMining = func(binaryPath, configPath string, opts xmrig.StartOptions) error {
+		calls = append(calls, "start "+binaryPath+" "+configPath+" "+opts.CPUs)
+		return nil
+	}
+
+	w := &watchdog{
+		opts:   Options{Window: 5 * time.Minute, BinaryPath: "/bin/xmrig", ConfigPath: "/tmp/c.json", CPUs: "0-3"},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	zero := summary(t, `{"hashrate": {"total": [null, null, null]}}`)
+	hashing := summary(t, `{"hashrate": {"total": [1500.5, 1490, 1600]}}`)
+	paused := summary(t, `{"paused": true, "hashrate": {"total": [0, 0, 0]}}`)
+
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	w.check(at(0), zero)
+	w.check(at(4*time.Minute), zero)
+	w.check(at(5*time.Minute), hashing) // recovered on its own
+	w.check(at(6*time.Minute), zero)
+	w.check(at(10*time.Minute), paused) // a pause isn't a stall
+	w.check(at(11*time.Minute), zero)
+	w.check(at(15*time.Minute), nil) // API unreadable: no verdict
+	w.check(at(16*time.Minute), zero)
+	if len(calls) != 0 {
+		t.Fatalf("restarted before a full window at 0 H/s: %v", calls)
+	}
+
+	w.check(at(21*time.Minute+time.Second), zero)
+	if len(calls) != 2 || calls[0] != "stop" || calls[1] != "start /bin/xmrig /tmp/c.json 0-3" {
+		t.Fatalf("calls = %v, want stop then start with the daemon's options", calls)
+	}
+
+	// the window starts over after a restart
+	w.check(at(22*time.Minute), zero)
+	w.check(at(26*time.Minute), zero)
+	if len(calls) != 2 || w.restarts != 1 {
+		t.Errorf("restarted again too soon: %v", calls)
+	}
+}
+
+func TestParseDaemonArgs(t *testing.T) {
+	opts, err := ParseDaemonArgs([]string{"300", "/bin/xmrig", "/tmp/c.json", ""})
+	if err != nil {
+		t.Fatalf("ParseDaemonArgs: %v", err)
+	}
+	want := Options{Window: 5 * time.Minute, BinaryPath: "/bin/xmrig", ConfigPath: "/tmp/c.json"}
+	if opts != want {
+		t.Errorf("ParseDaemonArgs = %+v, want %+v", opts, want)
+	}
+
+	opts, err = ParseDaemonArgs([]string{"300", "/bin/xmrig", "/tmp/c.json", "0-3", "lid-only"})
+	if err != nil || opts.CPUs != "0-3" || opts.SleepMode != antisleep.ModeLidOnly {
+		t.Errorf("ParseDaemonArgs with sleep mode = %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{{"300", "/bin/xmrig"}, {"soon", "/bin/xmrig", "/tmp/c.json"}, {"300", "/bin/xmrig", "/tmp/c.json", "", "never"}} {
+		if _, err := ParseDaemonArgs(args); err == nil {
+			t.Errorf("ParseDaemonArgs(%q) succeeded", args)
+		}
+	}
+}
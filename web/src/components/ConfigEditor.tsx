@@ -6,7 +6,7 @@ import { Slider } from "@/components/ui/slider"
 import { Switch } from "@/components/ui/switch"
 import { Select, SelectTrigger, SelectValue, SelectContent, SelectItem } from "@/components/ui/select"
 import { cn } from "@/lib/utils"
-import { Settings, Check, RotateCcw } from "lucide-react"
+import { Settings, Check, RotateCcw, FileDown } from "lucide-react"
 
 interface Props {
   miner: Miner
@@ -27,6 +27,7 @@ export default function ConfigEditor({ miner, onApplied }: Props) {
   const [hugePages, setHugePages] = useState(currentHugePages)
   const [applying, setApplying] = useState(false)
   const [applied, setApplied] = useState(false)
+  const [applyError, setApplyError] = useState<string | null>(null)
 
   const configKey = `${miner.id}:${currentThreadsHint}:${currentRx.join(",")}:${currentPriority}:${currentHugePages}`
 
@@ -58,6 +59,7 @@ export default function ConfigEditor({ miner, onApplied }: Props) {
   const handleApply = async () => {
     setApplying(true)
     setApplied(false)
+    setApplyError(null)
     try {
       const fullConfig = structuredClone(miner.config ?? {})
       const cpu = ((fullConfig.cpu as Record<string, unknown>) ?? {})
@@ -70,13 +72,23 @@ export default function ConfigEditor({ miner, onApplied }: Props) {
       setApplied(true)
       onApplied?.()
       setTimeout(() => setApplied(false), 3000)
-    } catch {
-      // silently fail
+    } catch (e) {
+      setApplyError(e instanceof Error ? e.message : String(e))
     } finally {
       setApplying(false)
     }
   }
 
+  const handleRevert = async () => {
+    setApplyError(null)
+    try {
+      await api.revertConfig(miner.id)
+      onApplied?.()
+    } catch (e) {
+      setApplyError(e instanceof Error ? e.message : String(e))
+    }
+  }
+
   const handleReset = () => {
     setThreadsHint(currentThreadsHint)
     setActiveCores(new Set(currentRx))
@@ -96,8 +108,13 @@ export default function ConfigEditor({ miner, onApplied }: Props) {
             <CardDescription>
               Changes are queued and applied on the miner's next heartbeat (~30s)
             </CardDescription>
+            {applyError && <p className="mt-1 text-sm text-destructive">{applyError}</p>}
           </div>
           <div className="flex gap-2">
+            <Button variant="outline" size="sm" onClick={handleRevert} title="Drop live edits and reload the miner's config file">
+              <FileDown className="mr-1 h-3 w-3" />
+              Revert to File
+            </Button>
             <Button variant="outline" size="sm" onClick={handleReset} disabled={!hasChanges}>
               <RotateCcw className="mr-1 h-3 w-3" />
               Reset
@@ -2,7 +2,11 @@ const BASE = ""
 
 async function fetchJSON<T>(path: string, init?: RequestInit): Promise<T> {
   const res = await fetch(`${BASE}${path}`, init)
-  if (!res.ok) throw new Error(`${res.status} ${res.statusText}`)
+  if (!res.ok) {
+    // the server explains rejected requests in the body, e.g. an invalid config
+    const text = (await res.text()).trim()
+    throw new Error(text || `${res.status} ${res.statusText}`)
+  }
   return res.json()
 }
 
@@ -25,9 +29,18 @@ export interface Miner {
   arch: string
   xmrig_version: string
   tarish_version: string
+  algo: string
+  name: string
   uptime_seconds: number
   hashrate: HashrateData | null
+  accepted: number
+  rejected: number
   config: Record<string, unknown> | null
+  config_hash: string
+  config_name: string
+  using_fallback_config: boolean
+  hugepages_enabled: boolean
+  msr_enabled: boolean
   last_seen: string
   status: string
 }
@@ -37,6 +50,10 @@ export interface Overview {
   average_hashrate: number
   active_miners: number
   total_miners: number
+  total_accepted: number
+  total_rejected: number
+  reject_rate: number
+  reject_rate_minutes: number
   top_miners: Miner[]
 }
 
@@ -58,13 +75,18 @@ export const api = {
       headers: { "Content-Type": "application/json" },
       body: JSON.stringify(config),
     }),
+  revertConfig: (id: string) =>
+    fetchJSON<{ ok: boolean }>(`/api/miners/${encodeURIComponent(id)}/config/revert`, {
+      method: "POST",
+    }),
   deleteConfig: (id: string) =>
     fetchJSON<{ ok: boolean }>(`/api/miners/${encodeURIComponent(id)}/config`, {
       method: "DELETE",
     }),
-  getHashrateHistory: (minerID?: string, hours = 24) => {
+  getHashrateHistory: (minerID?: string, hours = 24, bucket?: string) => {
     const params = new URLSearchParams({ hours: String(hours) })
     if (minerID) params.set("miner_id", minerID)
+    if (bucket) params.set("bucket", bucket)
     return fetchJSON<HashrateHistory[]>(`/api/hashrate/history?${params}`)
   },
 }
@@ -28,7 +28,10 @@ export function formatTimeAgo(date: string | Date): string {
   return `${Math.floor(seconds / 86400)}d ago`
 }
 
-export function displayName(miner: { hostname: string; cpu_family: string; miner_id: string }): string {
+export function displayName(miner: { name?: string; hostname: string; cpu_family: string; miner_id: string }): string {
+  if (miner.name) {
+    return miner.name
+  }
   if (miner.hostname) {
     const short = miner.hostname.replace(/\.local$/, "")
     return `${short} (${friendlyCPU(miner.cpu_family)})`
@@ -24,6 +24,7 @@ export default function Dashboard() {
         <StatCard
           title="Total Hashrate"
           value={formatHashrate(overview?.total_hashrate ?? 0)}
+          subtitle={`${(overview?.reject_rate ?? 0).toFixed(2)}% shares rejected (last ${overview?.reject_rate_minutes ?? 15}m)`}
           icon={<Zap className="h-4 w-4 text-primary" />}
         />
         <StatCard
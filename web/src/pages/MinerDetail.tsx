@@ -87,6 +87,10 @@ export default function MinerDetail() {
             <InfoRow icon={<Globe className="h-4 w-4" />} label="OS / Arch" value={`${miner.os} / ${miner.arch}`} />
             <Separator />
             <InfoRow label="XMRig" value={miner.xmrig_version || "—"} />
+            <InfoRow label="Algorithm" value={miner.algo || "—"} />
+            <InfoRow label="Config" value={(miner.config_name || "—") + (miner.using_fallback_config ? " (fallback)" : "")} />
+            <InfoRow label="Huge pages" value={miner.hugepages_enabled ? "enabled" : "disabled"} />
+            <InfoRow label="MSR mod" value={miner.msr_enabled ? "available" : "unavailable"} />
             <InfoRow label="Tarish" value={miner.tarish_version || "—"} />
             <InfoRow label="Hostname" value={miner.hostname || "—"} />
             <InfoRow label="Worker ID" value={miner.worker_id || "—"} />
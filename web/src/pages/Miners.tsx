@@ -32,9 +32,11 @@ export default function Miners() {
     let list = miners.filter(m => {
       if (!q) return true
       return (
+        (m.name ?? "").toLowerCase().includes(q) ||
         m.hostname.toLowerCase().includes(q) ||
         m.ip.includes(q) ||
         m.cpu_family.toLowerCase().includes(q) ||
+        (m.algo ?? "").toLowerCase().includes(q) ||
         m.miner_id.toLowerCase().includes(q) ||
         m.cpu_model.toLowerCase().includes(q)
       )
@@ -120,6 +122,11 @@ export default function Miners() {
                       <Link to={`/miners/${encodeURIComponent(m.id)}`} className="font-medium text-sm hover:text-primary transition-colors">
                         {displayName(m)}
                       </Link>
+                      {m.using_fallback_config && (
+                        <Badge variant="warning" className="ml-2 text-[10px]" title={`No tuned config for ${friendlyCPU(m.cpu_family)}`}>
+                          fallback config
+                        </Badge>
+                      )}
                     </td>
                     <td className="px-4 py-3 text-sm font-mono text-muted-foreground">{m.ip}</td>
                     <td className="px-4 py-3 text-sm text-muted-foreground">{friendlyCPU(m.cpu_family)}</td>
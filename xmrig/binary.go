@@ -24,15 +24,7 @@ type BinaryInfo struct {
 func FindBinary(basePath string) (*BinaryInfo, error) {
 	targetOS := runtime.GOOS
 	targetArch := runtime.GOARCH
-
-	// Map Go OS names to binary naming convention
-	osName := targetOS
-	if targetOS == "darwin" {
-		osName = "macos"
-	}
-
-	// Expected binary name pattern: xmrig_{os}_{arch}
-	expectedName := fmt.Sprintf("xmrig_%s_%s", osName, targetArch)
+	expectedName := expectedBinaryName()
 
 	// Find all version directories
 	versions, err := findVersionDirs(basePath)
@@ -41,10 +33,137 @@ func FindBinary(basePath string) (*BinaryInfo, error) {
 	}
 
 	if len(versions) == 0 {
+		// A manual install may drop the binary straight into bin/
+		if binaryPath, err := findFlatBinary(basePath); err == nil {
+			debugf("no version directories in %s, using %s", basePath, binaryPath)
+			// bin/'s own name says nothing; ask the binary, which a
+			// manual copy may have left without its execute bit
+			if err := EnsureExecutable(binaryPath); err != nil {
+				debugf("xmrig chmod: %v", err)
+			}
+			version, err := QueryBinaryVersion(binaryPath)
+			if err != nil {
+				debugf("xmrig version: %v", err)
+				version = "unknown"
+			}
+			return &BinaryInfo{
+				Path:    binaryPath,
+				Version: version,
+				OS:      targetOS,
+				Arch:    targetArch,
+			}, nil
+		}
 		return nil, fmt.Errorf("no xmrig versions found in %s", basePath)
 	}
 
-	// Sort versions in descending order (latest first)
+	sortVersionsDesc(versions)
+	debugf("xmrig versions in %s: %v (want %s)", basePath, versions, expectedName)
+
+	// Try each version from latest to oldest
+	for _, version := range versions {
+		binaryPath, err := findBinaryInDir(filepath.Join(basePath, version))
+		if err != nil {
+			debugf("xmrig %s: %v", version, err)
+			continue
+		}
+		debugf("xmrig %s: using %s", version, binaryPath)
+		return &BinaryInfo{
+			Path:    binaryPath,
+			Version: version,
+			OS:      targetOS,
+			Arch:    targetArch,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no compatible xmrig binary found for %s/%s in %s", targetOS, targetArch, basePath)
+}
+
+// FindBinaryVersion returns the xmrig binary for the current system from a
+// specific version directory ("6.24.0" or "v6.24.0") under basePath.
+func FindBinaryVersion(basePath, version string) (*BinaryInfo, error) {
+	want := strings.TrimPrefix(version, "v")
+
+	versions, err := findVersionDirs(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan binary directory: %w", err)
+	}
+
+	for _, v := range versions {
+		if strings.TrimPrefix(v, "v") != want {
+			continue
+		}
+		if binaryPath, err := findBinaryInDir(filepath.Join(basePath, v)); err == nil {
+			return &BinaryInfo{
+				Path:    binaryPath,
+				Version: v,
+				OS:      runtime.GOOS,
+				Arch:    runtime.GOARCH,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("xmrig %s not found for %s/%s in %s", version, runtime.GOOS, runtime.GOARCH, basePath)
+}
+
+// expectedBinaryName returns the binary name for the current system: xmrig_{os}_{arch}
+func expectedBinaryName() string {
+	osName := runtime.GOOS
+	if osName == "darwin" {
+		osName = "macos"
+	}
+	return fmt.Sprintf("xmrig_%s_%s", osName, runtime.GOARCH)
+}
+
+// findBinaryInDir returns the xmrig binary for the current system in dir:
+// xmrig_{os}_{arch} itself, else a variant of it such as
+// xmrig_linux_amd64_musl. A variant suffix must start with _ or - and have
+// no extension, so xmrig_linux_arm doesn't pick up xmrig_linux_arm64 and
+// checksums or archives (xmrig_linux_amd64_musl.sha256) are skipped.
+func findBinaryInDir(dir string) (string, error) {
+	expectedName := expectedBinaryName()
+	exact := filepath.Join(dir, expectedName)
+	if info, err := os.Stat(exact); err == nil && info.Mode().IsRegular() {
+		return exact, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	// ReadDir sorts by name, so the choice among variants is stable
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), expectedName)
+		if !ok || suffix == "" || (suffix[0] != '_' && suffix[0] != '-') || strings.Contains(suffix, ".") {
+			continue
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		debugf("no %s in %s, using variant %s", expectedName, dir, entry.Name())
+		return filepath.Join(dir, entry.Name()), nil
+	}
+	return "", fmt.Errorf("no %s in %s", expectedName, dir)
+}
+
+// findFlatBinary returns an xmrig binary placed directly in basePath,
+// named for the current system or just "xmrig"
+func findFlatBinary(basePath string) (string, error) {
+	if binaryPath, err := findBinaryInDir(basePath); err == nil {
+		return binaryPath, nil
+	}
+	binaryPath := filepath.Join(basePath, "xmrig")
+	info, err := os.Stat(binaryPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a file", binaryPath)
+	}
+	return binaryPath, nil
+}
+
+// sortVersionsDesc sorts version directory names latest first
+func sortVersionsDesc(versions []string) {
 	sort.Slice(versions, func(i, j int) bool {
 		// Add 'v' prefix for semver comparison if not present
 		vi := versions[i]
@@ -57,23 +176,6 @@ func FindBinary(basePath string) (*BinaryInfo, error) {
 		}
 		return semver.Compare(vi, vj) > 0
 	})
-
-	// Try each version from latest to oldest
-	for _, version := range versions {
-		versionDir := filepath.Join(basePath, version)
-		binaryPath := filepath.Join(versionDir, expectedName)
-
-		if _, err := os.Stat(binaryPath); err == nil {
-			return &BinaryInfo{
-				Path:    binaryPath,
-				Version: version,
-				OS:      targetOS,
-				Arch:    targetArch,
-			}, nil
-		}
-	}
-
-	return nil, fmt.Errorf("no compatible xmrig binary found for %s/%s in %s", targetOS, targetArch, basePath)
 }
 
 // findVersionDirs returns all version directories in the base path
@@ -97,48 +199,42 @@ func findVersionDirs(basePath string) ([]string, error) {
 	return versions, nil
 }
 
-// GetInstalledBinaryPath returns the path to installed xmrig binary
-func GetInstalledBinaryPath() (*BinaryInfo, error) {
-	// 1. Check user-local path (~/.local/share/tarish/bin)
+// binarySearchPaths returns the bin directories searched for xmrig, in order
+func binarySearchPaths() []string {
+	var paths []string
+
+	// 1. User-local path (~/.local/share/tarish/bin)
 	home, _ := os.UserHomeDir()
 	if home != "" {
-		userPath := filepath.Join(home, ".local", "share", "tarish", "bin")
-		info, err := FindBinary(userPath)
-		if err == nil {
-			return info, nil
-		}
+		paths = append(paths, filepath.Join(home, ".local", "share", "tarish", "bin"))
 	}
 
-	// 2. Check standard system installation path
-	installPath := "/usr/local/share/tarish/bin"
-	info, err := FindBinary(installPath)
-	if err == nil {
-		return info, nil
+	// 2. Standard system installation path
+	paths = append(paths, "/usr/local/share/tarish/bin")
+
+	// 3. Relative to the executable (for development)
+	if execPath, err := os.Executable(); err == nil {
+		paths = append(paths, filepath.Join(filepath.Dir(execPath), "bin"))
 	}
 
-	// Fallback to relative path (for development)
-	execPath, err := os.Executable()
-	if err == nil {
-		execDir := filepath.Dir(execPath)
-		devPath := filepath.Join(execDir, "bin")
-		info, err = FindBinary(devPath)
-		if err == nil {
-			return info, nil
-		}
+	// 4. Current working directory
+	if cwd, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(cwd, "bin"))
 	}
 
-	// Try current working directory
-	cwd, err := os.Getwd()
-	if err == nil {
-		cwdPath := filepath.Join(cwd, "bin")
-		info, err = FindBinary(cwdPath)
-		if err == nil {
+	return paths
+}
+
+// GetInstalledBinaryPath returns the path to installed xmrig binary
+func GetInstalledBinaryPath() (*BinaryInfo, error) {
+	for _, path := range binarySearchPaths() {
+		if info, err := FindBinary(path); err == nil {
 			return info, nil
 		}
 	}
 
 	// Fallback: extract from embedded assets on-demand
-	fmt.Println("  Extracting xmrig from embedded assets...")
+	progressf("  Extracting xmrig from embedded assets...")
 	binaryPath, err := embedded.ExtractXmrigBinary("") // Uses default path
 	if err != nil {
 		return nil, fmt.Errorf("no xmrig binary found: %w", err)
@@ -154,7 +250,51 @@ func GetInstalledBinaryPath() (*BinaryInfo, error) {
 	}, nil
 }
 
-// GetBinaryVersion returns version info for a specific binary
+// GetInstalledBinaryVersion returns the installed xmrig binary of a specific
+// version, erroring with the available versions if it isn't installed.
+func GetInstalledBinaryVersion(version string) (*BinaryInfo, error) {
+	for _, path := range binarySearchPaths() {
+		if info, err := FindBinaryVersion(path, version); err == nil {
+			return info, nil
+		}
+	}
+
+	available := ListBinaryVersions()
+	if len(available) == 0 {
+		return nil, fmt.Errorf("xmrig %s not found (no xmrig binaries installed)", version)
+	}
+	return nil, fmt.Errorf("xmrig %s not found (available: %s)", version, strings.Join(available, ", "))
+}
+
+// ListBinaryVersions returns every installed xmrig version that has a
+// binary for the current system, latest first
+func ListBinaryVersions() []string {
+	seen := make(map[string]bool)
+	var versions []string
+
+	for _, path := range binarySearchPaths() {
+		dirs, err := findVersionDirs(path)
+		if err != nil {
+			continue
+		}
+		for _, v := range dirs {
+			if seen[v] {
+				continue
+			}
+			if _, err := findBinaryInDir(filepath.Join(path, v)); err == nil {
+				seen[v] = true
+				versions = append(versions, v)
+			}
+		}
+	}
+
+	sortVersionsDesc(versions)
+	return versions
+}
+
+// GetBinaryVersion returns the version of an xmrig binary: the name of
+// its version directory, else what the binary reports with --version
+// (cached, see QueryBinaryVersion). "unknown" if neither works.
 func GetBinaryVersion(binaryPath string) (string, error) {
 	// Extract version from path (parent directory name)
 	dir := filepath.Dir(binaryPath)
@@ -165,7 +305,11 @@ func GetBinaryVersion(binaryPath string) (string, error) {
 		return version, nil
 	}
 
-	return "unknown", nil
+	version, err := QueryBinaryVersion(binaryPath)
+	if err != nil {
+		return "unknown", err
+	}
+	return version, nil
 }
 
 // EnsureExecutable ensures the binary has execute permissions
@@ -0,0 +1,184 @@
+package xmrig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindBinaryVersion(t *testing.T) {
+	base := t.TempDir()
+	for _, v := range []string{"6.24.0", "6.25.0"} {
+		dir := filepath.Join(base, v)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, expectedBinaryName()), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest, err := FindBinary(base)
+	if err != nil || latest.Version != "6.25.0" {
+		t.Fatalf("FindBinary = %+v, %v; want 6.25.0", latest, err)
+	}
+
+	for _, want := range []string{"6.24.0", "v6.24.0"} {
+		info, err := FindBinaryVersion(base, want)
+		if err != nil {
+			t.Fatalf("FindBinaryVersion(%q): %v", want, err)
+		}
+		if info.Version != "6.24.0" {
+			t.Errorf("FindBinaryVersion(%q).Version = %q, want 6.24.0", want, info.Version)
+		}
+	}
+
+	if _, err := FindBinaryVersion(base, "6.20.0"); err == nil {
+		t.Error("FindBinaryVersion(6.20.0) should fail when the version is absent")
+	}
+}
+
+func TestFindBinaryVariantSuffix(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "6.25.0")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	name := expectedBinaryName()
+	for _, f := range []string{name + "_musl.sha256", name + "64", name + "_musl"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := FindBinary(base)
+	if err != nil {
+		t.Fatalf("FindBinary: %v", err)
+	}
+	if want := filepath.Join(dir, name+"_musl"); info.Path != want || info.Version != "6.25.0" {
+		t.Errorf("FindBinary = %s (%s), want %s", info.Path, info.Version, want)
+	}
+
+	// the exact name still wins when both are present
+	if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := FindBinaryVersion(base, "6.25.0"); err != nil || info.Path != filepath.Join(dir, name) {
+		t.Errorf("FindBinaryVersion = %+v, %v; want the exact name", info, err)
+	}
+}
+
+func TestFindBinaryFlatInstall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	binaryVersions.entries = nil
+	defer func() { binaryVersions.entries = nil }()
+
+	base := t.TempDir()
+	if _, err := FindBinary(base); err == nil {
+		t.Fatal("FindBinary succeeded in an empty directory")
+	}
+
+	if err := os.WriteFile(filepath.Join(base, "xmrig"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	info, err := FindBinary(base)
+	if err != nil {
+		t.Fatalf("FindBinary: %v", err)
+	}
+	if info.Path != filepath.Join(base, "xmrig") || info.Version != "unknown" {
+		t.Errorf("FindBinary = %s (%s), want bin/xmrig with an unknown version", info.Path, info.Version)
+	}
+
+	// a system-specific name is preferred over plain xmrig
+	if err := os.WriteFile(filepath.Join(base, expectedBinaryName()), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := FindBinary(base); err != nil || info.Path != filepath.Join(base, expectedBinaryName()) {
+		t.Errorf("FindBinary = %+v, %v; want %s", info, err, expectedBinaryName())
+	}
+}
+
+func TestFindBinaryFlatInstallNotExecutable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	binaryVersions.entries = nil
+	defer func() { binaryVersions.entries = nil }()
+
+	base := t.TempDir()
+	bin := filepath.Join(base, "xmrig")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho 'XMRig 6.22.1'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := FindBinary(base)
+	if err != nil || info.Version != "6.22.1" {
+		t.Fatalf("FindBinary = %+v, %v; want 6.22.1 from a binary made executable", info, err)
+	}
+	if fi, _ := os.Stat(bin); fi.Mode()&0100 == 0 {
+		t.Errorf("mode = %v, want the binary made executable", fi.Mode())
+	}
+}
+
+func TestQueryBinaryVersionCaches(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	binaryVersions.entries = nil
+	defer func() { binaryVersions.entries = nil }()
+
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	bin := filepath.Join(dir, "bin", "xmrig")
+	os.MkdirAll(filepath.Dir(bin), 0755)
+	script := "#!/bin/sh\necho run >> " + calls + "\necho 'XMRig 6.22.1'\necho ' built on Jan  1 2025 with GCC 13.2.0'\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	countCalls := func() int {
+		data, _ := os.ReadFile(calls)
+		return len(data) / len("run\n")
+	}
+
+	for i := 0; i < 2; i++ {
+		if v, err := GetBinaryVersion(bin); err != nil || v != "6.22.1" {
+			t.Fatalf("GetBinaryVersion = %q, %v; want 6.22.1", v, err)
+		}
+	}
+	if n := countCalls(); n != 1 {
+		t.Errorf("binary ran %d times, want 1 (cached)", n)
+	}
+
+	// the cache survives a new tarish process
+	binaryVersions.entries = nil
+	if v, _ := QueryBinaryVersion(bin); v != "6.22.1" || countCalls() != 1 {
+		t.Errorf("after reload: version %q, %d runs; want the cached 6.22.1", v, countCalls())
+	}
+
+	// replacing the binary invalidates the entry
+	script = "#!/bin/sh\necho run >> " + calls + "\necho 'XMRig-MO 6.24.0-mo1'\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := QueryBinaryVersion(bin); v != "6.24.0-mo1" || countCalls() != 2 {
+		t.Errorf("after replace: version %q, %d runs; want 6.24.0-mo1 from a second run", v, countCalls())
+	}
+
+	// a binary that fails is not run again until it changes
+	script = "#!/bin/sh\necho run >> " + calls + "\nexit 1\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := QueryBinaryVersion(bin); err == nil {
+			t.Fatal("QueryBinaryVersion succeeded for a failing binary")
+		}
+	}
+	binaryVersions.entries = nil
+	if _, err := QueryBinaryVersion(bin); err == nil || countCalls() != 3 {
+		t.Errorf("failing binary: %v, %d runs; want the cached error from a third run", err, countCalls())
+	}
+
+	// a directory named for the version is used without running anything
+	versioned := filepath.Join(dir, "6.25.0", "xmrig")
+	os.MkdirAll(filepath.Dir(versioned), 0755)
+	os.WriteFile(versioned, []byte(script), 0755)
+	if v, _ := GetBinaryVersion(versioned); v != "6.25.0" || countCalls() != 3 {
+		t.Errorf("versioned dir: version %q, %d runs; want 6.25.0 without running", v, countCalls())
+	}
+}
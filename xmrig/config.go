@@ -1,17 +1,23 @@
 package xmrig
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 
 	"tarish/config"
 	"tarish/cpu"
 	"tarish/embedded"
+	"tarish/xmrig/validate"
 )
 
 // TLS connection constants for xmrig-proxy
@@ -53,23 +59,89 @@ type HTTPConfig struct {
 // SelectConfig finds the most appropriate config file for the detected CPU.
 // If no static config file matches, it generates a generic config based on core count.
 func SelectConfig(cpuInfo *cpu.Info, configsPath string) (string, error) {
+	sel, err := SelectConfigDetailed(cpuInfo, configsPath)
+	if err != nil {
+		return "", err
+	}
+	return sel.Path, nil
+}
+
+// ConfigSelection describes which config SelectConfigDetailed picked
+type ConfigSelection struct {
+	Path     string
+	Index    int  // index into the candidate list, -1 for a generated generic config
+	Fallback bool // true if no CPU-specific config matched
+}
+
+// SelectConfigDetailed is SelectConfig but also reports which candidate
+// matched. Landing on an arch/OS default, default.json or a generated
+// generic config is a fallback: the CPU family has no tuned config.
+func SelectConfigDetailed(cpuInfo *cpu.Info, configsPath string) (*ConfigSelection, error) {
 	// List of config file candidates in priority order
 	candidates := buildConfigCandidates(cpuInfo)
+	debugf("config candidates for %s (%s/%s) in %s: %v", cpuInfo.Family, cpuInfo.OS, cpuInfo.Arch, configsPath, candidates)
 
-	for _, candidate := range candidates {
+	for i, candidate := range candidates {
 		configPath := filepath.Join(configsPath, candidate)
-		if _, err := os.Stat(configPath); err == nil {
-			return configPath, nil
+		if _, err := os.Stat(configPath); err != nil {
+			debugf("config candidate %s: %v", candidate, err)
+			continue
 		}
+		debugf("config candidate %s: matched", candidate)
+		return &ConfigSelection{
+			Path:     configPath,
+			Index:    i,
+			Fallback: isFallbackCandidate(cpuInfo, candidate),
+		}, nil
 	}
 
 	// No static config found — generate a generic one based on core count
-	fmt.Printf("  No static config found, generating generic config for %d cores...\n", cpuInfo.Cores)
-	genericPath, err := generateGenericConfig(cpuInfo, configsPath)
+	progressf("  No static config found, generating generic config for %d cores...", cpuInfo.Cores)
+	genericPath, err := generateGenericConfig(cpuInfo)
 	if err != nil {
-		return "", fmt.Errorf("no suitable config found for CPU: %s (family: %s): %w", cpuInfo.RawModel, cpuInfo.Family, err)
+		return nil, &ConfigNotFoundError{
+			CPUModel:    cpuInfo.RawModel,
+			Family:      cpuInfo.Family,
+			Candidates:  candidates,
+			ConfigsPath: configsPath,
+			Err:         err,
+		}
 	}
-	return genericPath, nil
+	return &ConfigSelection{Path: genericPath, Index: -1, Fallback: true}, nil
+}
+
+// ConfigNotFoundError is returned by SelectConfig when no candidate exists
+// and a generic config couldn't be generated either. Candidates are the
+// filenames tried, in priority order, under ConfigsPath.
+type ConfigNotFoundError struct {
+	CPUModel    string
+	Family      string
+	Candidates  []string
+	ConfigsPath string
+	Err         error // why generating the generic config failed
+}
+
+func (e *ConfigNotFoundError) Error() string {
+	return fmt.Sprintf("no suitable config found for CPU: %s (family: %s): %v", e.CPUModel, e.Family, e.Err)
+}
+
+func (e *ConfigNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// Tried describes the search, e.g. "apple_m3_pro.json, m3pro.json, ... in /usr/local/share/tarish/configs"
+func (e *ConfigNotFoundError) Tried() string {
+	return fmt.Sprintf("%s in %s", strings.Join(e.Candidates, ", "), e.ConfigsPath)
+}
+
+// isFallbackCandidate reports whether candidate is one of the arch, OS or
+// generic defaults at the end of buildConfigCandidates
+func isFallbackCandidate(cpuInfo *cpu.Info, candidate string) bool {
+	switch candidate {
+	case cpuInfo.Arch + "_default.json", cpuInfo.OS + "_default.json", "default.json":
+		return true
+	}
+	return false
 }
 
 // buildConfigCandidates returns a prioritized list of config filenames to try
@@ -115,9 +187,9 @@ func buildConfigCandidates(cpuInfo *cpu.Info) []string {
 }
 
 // generateGenericConfig creates a config file dynamically based on CPU core count
-// and vendor information. It writes the config to configsPath/generic_NNcores.json
-// and returns the path.
-func generateGenericConfig(cpuInfo *cpu.Info, configsPath string) (string, error) {
+// and vendor information. It writes the config to generic_NNcores.json in the
+// data dir, never among the shipped configs, and returns the path.
+func generateGenericConfig(cpuInfo *cpu.Info) (string, error) {
 	cores := cpuInfo.Cores
 	if cores < 1 {
 		cores = 1
@@ -159,11 +231,10 @@ func generateGenericConfig(cpuInfo *cpu.Info, configsPath string) (string, error
 			"worker-id": nil,
 		},
 		"http": map[string]interface{}{
-			"enabled":      true,
-			"host":         "0.0.0.0",
-			"port":         8181,
-			"access-token": "Hello2025@",
-			"restricted":   false,
+			"enabled":    true,
+			"host":       "127.0.0.1",
+			"port":       8181,
+			"restricted": false, // tarish pauses and resumes through the API
 		},
 		"autosave":   false,
 		"background": false,
@@ -206,7 +277,7 @@ func generateGenericConfig(cpuInfo *cpu.Info, configsPath string) (string, error
 			"cn-lite/0": false,
 			"cn/0":      false,
 		},
-		"log-file":          "/usr/local/share/tarish/log/xmrig.log",
+		"log-file":          GetLogFile(),
 		"donate-level":      0,
 		"donate-over-proxy": 0,
 		"pools": []map[string]interface{}{
@@ -255,7 +326,7 @@ func generateGenericConfig(cpuInfo *cpu.Info, configsPath string) (string, error
 
 	// Write the generic config file
 	configName := fmt.Sprintf("generic_%dcores.json", cores)
-	configPath := filepath.Join(configsPath, configName)
+	configPath := filepath.Join(GetDataDir(), configName)
 
 	output, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
@@ -263,9 +334,8 @@ func generateGenericConfig(cpuInfo *cpu.Info, configsPath string) (string, error
 	}
 	output = append(output, '\n')
 
-	// Ensure configs directory exists
-	if err := os.MkdirAll(configsPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to create configs directory: %w", err)
+	if err := EnsureDataDir(); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
 	}
 
 	if err := os.WriteFile(configPath, output, 0644); err != nil {
@@ -314,7 +384,8 @@ func getBaseFamily(family string) string {
 		return getVendor(family)
 	}
 
-	return family
+	// ARM SoCs and servers fall back to their core (bcm2711 -> cortex_a72)
+	return cpu.ARMBaseFamily(family)
 }
 
 // getVendor extracts the vendor from family (e.g., "apple" from "apple_m3_pro")
@@ -328,6 +399,9 @@ func getVendor(family string) string {
 	if strings.HasPrefix(family, "amd") {
 		return "amd"
 	}
+	if cpu.IsARMFamily(family) {
+		return "arm"
+	}
 	return ""
 }
 
@@ -366,7 +440,7 @@ func GetInstalledConfigPath() string {
 	}
 
 	// Fallback: extract from embedded assets on-demand
-	fmt.Println("  Extracting configs from embedded assets...")
+	progressf("  Extracting configs from embedded assets...")
 	if err := embedded.ExtractConfigs(""); err == nil {
 		return embedded.GetSharePath()
 	}
@@ -374,12 +448,19 @@ func GetInstalledConfigPath() string {
 	return installPath // Return default even if not found
 }
 
-// LoadConfig loads and parses an xmrig config file
+// LoadConfig loads and parses an xmrig config file. A template is
+// rendered with the tarish config's values when they're all set, and
+// parsed with its placeholders left in otherwise.
 func LoadConfig(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
+	if IsTemplate(data) {
+		if rendered, err := RenderTemplate(data, DefaultTemplateVars()); err == nil {
+			data = rendered
+		}
+	}
 
 	// Parse into raw map first to preserve all fields
 	var raw map[string]interface{}
@@ -397,6 +478,290 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// ValidateConfig checks a parsed config for problems xmrig would only
+// report at start (or silently mine around). The checks are shared with
+// tarish-server, which runs them on dashboard overrides.
+func ValidateConfig(cfg *Config) error {
+	raw := cfg.Raw
+	if raw == nil {
+		// built in code rather than loaded; check the fields it has
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+	return validate.Config(raw)
+}
+
+// ValidateConfigFile loads and validates the xmrig config at path, which
+// needn't be installed. A template is checked as written, placeholders and
+// all, so the result doesn't depend on this machine's tarish settings.
+func ValidateConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, col := lineColumn(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("invalid JSON at line %d, column %d: %w", line, col, err)
+		}
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("invalid JSON: config must be an object")
+	}
+
+	// validate first: its messages beat a struct decoding error
+	if err := ValidateConfig(&Config{Raw: raw}); err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	cfg.Raw = raw
+	return &cfg, nil
+}
+
+// lineColumn converts a byte offset in data to a 1-based line and column
+func lineColumn(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - (bytes.LastIndexByte(before, '\n') + 1)
+	return line, col
+}
+
+// ThreadsHintResult says how a threads hint was applied
+type ThreadsHintResult struct {
+	// Threads and Of are the cpu.rx threads kept and listed in the full
+	// profile; both are 0 when xmrig derives the threads from the hint
+	Threads, Of int
+	// Was is how many cpu.rx threads were listed before
+	Was int
+}
+
+// SetMaxThreadsHint sets cpu.max-threads-hint (0-100) in the config file.
+// xmrig only applies the hint to auto-generated thread lists, so when the
+// profile lists cpu.rx threads explicitly (all shipped x86 and Apple
+// configs do) the list is cut to that share of its threads instead. The
+// full list is kept in rx-threads.json in the data dir, so a higher hint
+// later, or 100, brings threads back.
+func SetMaxThreadsHint(configPath string, pct int) (*ThreadsHintResult, error) {
+	if pct < 0 || pct > 100 {
+		return nil, fmt.Errorf("max-threads-hint %d out of range (0-100)", pct)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	cpuSection, ok := raw["cpu"].(map[string]interface{})
+	if !ok {
+		cpuSection = make(map[string]interface{})
+	}
+	full, err := fullRxThreads(configPath, cpuSection)
+	if err != nil {
+		return nil, err
+	}
+	result := applyThreadsHint(cpuSection, full, pct)
+	raw["cpu"] = cpuSection
+
+	output, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(configPath, output, 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyThreadsHint sets the hint in a cpu section and, for an explicit
+// profile, cuts cpu.rx to the first pct% of full (at least one thread)
+func applyThreadsHint(cpuSection map[string]interface{}, full []interface{}, pct int) *ThreadsHintResult {
+	cpuSection["max-threads-hint"] = pct
+	if len(full) == 0 {
+		return &ThreadsHintResult{}
+	}
+	was, _ := cpuSection["rx"].([]interface{})
+	n := len(full) * pct / 100
+	if n < 1 {
+		n = 1
+	}
+	cpuSection["rx"] = append([]interface{}(nil), full[:n]...)
+	return &ThreadsHintResult{Threads: n, Of: len(full), Was: len(was)}
+}
+
+func rxThreadsFile() string {
+	return filepath.Join(GetDataDir(), "rx-threads.json")
+}
+
+// fullRxThreads returns the untrimmed cpu.rx list for configPath: the one
+// saved by an earlier hint, else the section's own list, which is saved.
+// It is nil when cpu.rx isn't an explicit list.
+func fullRxThreads(configPath string, cpuSection map[string]interface{}) ([]interface{}, error) {
+	if full := savedRxThreads(configPath); full != nil {
+		return full, nil
+	}
+	full, ok := cpuSection["rx"].([]interface{})
+	if !ok || len(full) == 0 {
+		return nil, nil
+	}
+
+	saved := readRxThreads()
+	key, _ := filepath.Abs(configPath)
+	saved[key] = full
+	if err := EnsureDataDir(); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(rxThreadsFile(), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save cpu.rx threads: %w", err)
+	}
+	return full, nil
+}
+
+// savedRxThreads returns the full cpu.rx list saved for configPath, if any
+func savedRxThreads(configPath string) []interface{} {
+	key, _ := filepath.Abs(configPath)
+	return readRxThreads()[key]
+}
+
+// readRxThreads reads rx-threads.json: full cpu.rx lists by config path
+func readRxThreads() map[string][]interface{} {
+	saved := make(map[string][]interface{})
+	if data, err := os.ReadFile(rxThreadsFile()); err == nil {
+		json.Unmarshal(data, &saved)
+	}
+	return saved
+}
+
+// TemplateVars are the values substituted into a config template's
+// ${WALLET}, ${WORKER} and ${POOL} placeholders
+type TemplateVars struct {
+	Wallet string
+	Worker string
+	Pool   string
+}
+
+// templateVarRe matches a ${NAME} or ${ENV:NAME} placeholder
+var templateVarRe = regexp.MustCompile(`\$\{((?:ENV:)?[A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// DefaultTemplateVars returns the template values from the tarish config;
+// the worker falls back to the short hostname
+func DefaultTemplateVars() TemplateVars {
+	cfg := config.Load()
+	vars := TemplateVars{Wallet: cfg.Wallet, Worker: cfg.Worker, Pool: cfg.Pool}
+	if vars.Worker == "" {
+		vars.Worker = WorkerID()
+	}
+	return vars
+}
+
+func (v TemplateVars) lookup(name string) (string, error) {
+	// ${ENV:NAME} keeps credentials out of the config file entirely
+	if envName, ok := strings.CutPrefix(name, "ENV:"); ok {
+		value, set := os.LookupEnv(envName)
+		if !set {
+			return "", fmt.Errorf("environment variable %s is not set (needed by ${%s})", envName, name)
+		}
+		return value, nil
+	}
+
+	var value string
+	switch name {
+	case "WALLET":
+		value = v.Wallet
+	case "WORKER":
+		value = v.Worker
+	case "POOL":
+		value = v.Pool
+	default:
+		return "", fmt.Errorf("unknown placeholder ${%s}", name)
+	}
+	if value == "" {
+		return "", fmt.Errorf("no value for ${%s} (set it with 'tarish config set %s')", name, strings.ToLower(name))
+	}
+	return value, nil
+}
+
+// TemplateError is returned by PrepareRuntimeConfig when the selected
+// config is a template that can't be rendered. Unlike other runtime config
+// failures, falling back to the unrendered file would mine to a literal
+// "${WALLET}".
+type TemplateError struct {
+	Path string
+	Err  error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("failed to render config template %s: %v", e.Path, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// IsTemplate reports whether config data contains ${...} placeholders
+func IsTemplate(data []byte) bool {
+	return templateVarRe.Match(data)
+}
+
+// RenderTemplate substitutes the placeholders in a config template and
+// checks that the result is still valid JSON. Placeholders are expected
+// inside JSON strings, so values are escaped accordingly.
+func RenderTemplate(data []byte, vars TemplateVars) ([]byte, error) {
+	var firstErr error
+	rendered := templateVarRe.ReplaceAllFunc(data, func(match []byte) []byte {
+		name := string(templateVarRe.FindSubmatch(match)[1])
+		value, err := vars.lookup(name)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return match
+		}
+		quoted, _ := json.Marshal(value)
+		return quoted[1 : len(quoted)-1]
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(rendered, &raw); err != nil {
+		return nil, fmt.Errorf("rendered config is not valid JSON: %w", err)
+	}
+	return rendered, nil
+}
+
+// RenderConfigTemplate reads a config template and returns it rendered
+func RenderConfigTemplate(templatePath string, vars TemplateVars) ([]byte, error) {
+	data, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return RenderTemplate(data, vars)
+}
+
 // GetConfigForCurrentSystem detects CPU and returns the appropriate config path
 func GetConfigForCurrentSystem() (string, *cpu.Info, error) {
 	cpuInfo, err := cpu.Detect()
@@ -432,6 +797,63 @@ func ListAvailableConfigs() ([]string, error) {
 	return configs, nil
 }
 
+// ExportSelectedConfig returns the name and contents of the xmrig config
+// 'tarish start' uses here: the one it last selected, or the one it would
+// select now if it hasn't run yet
+func ExportSelectedConfig() (string, []byte, error) {
+	path := ""
+	if name := GetSelectedConfigName(); name != "" {
+		path = filepath.Join(GetInstalledConfigPath(), name)
+		if _, err := os.Stat(path); err != nil {
+			path = ""
+		}
+	}
+	if path == "" {
+		var err error
+		path, _, err = GetConfigForCurrentSystem()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return filepath.Base(path), data, nil
+}
+
+// ImportConfig validates an xmrig config (a template keeps its
+// placeholders) and writes it to the configs directory as name, replacing
+// any config of that name. Returns the path written.
+func ImportConfig(name string, data []byte) (string, error) {
+	if name != filepath.Base(name) || !strings.HasSuffix(name, ".json") {
+		return "", fmt.Errorf("invalid config name %q", name)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return "", fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := ValidateConfig(&Config{Raw: raw}); err != nil {
+		return "", fmt.Errorf("invalid config: %w", err)
+	}
+
+	dir := GetInstalledConfigPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return path, nil
+}
+
 // GetDataDir returns the tarish data directory path
 func GetDataDir() string {
 	home, err := os.UserHomeDir()
@@ -453,13 +875,27 @@ func GetPIDFile() string {
 	return filepath.Join(GetLogDir(), "xmrig.pid")
 }
 
+// geteuid is swapped out in tests to exercise both install layouts
+var geteuid = os.Geteuid
+
+// GetShareDir returns the tarish share directory for the current user.
+// It mirrors install.getInstallPaths: root uses the system-wide location,
+// everyone else uses ~/.local/share/tarish, so a non-root miner never
+// tries to write into a directory it doesn't own.
+func GetShareDir() string {
+	if geteuid() == 0 {
+		return "/usr/local/share/tarish"
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "/usr/local/share/tarish"
+	}
+	return filepath.Join(home, ".local", "share", "tarish")
+}
+
 // GetLogDir returns the log directory path
 func GetLogDir() string {
-	// Base it on where configs are installed
-	configPath := GetInstalledConfigPath()
-	// configPath is .../tarish/configs, so dir is .../tarish
-	baseDir := filepath.Dir(configPath)
-	return filepath.Join(baseDir, "log")
+	return filepath.Join(GetShareDir(), "log")
 }
 
 // GetLogFile returns the path to the log file
@@ -523,49 +959,113 @@ func GetRuntimeConfigPath() string {
 // PrepareRuntimeConfig creates a runtime config with api.id and worker-id populated.
 // It reads the selected config, injects identity fields, and writes to a runtime path.
 func PrepareRuntimeConfig(configPath string, cpuInfo *cpu.Info) (string, error) {
+	raw, err := buildRuntimeConfig(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Write runtime config
+	runtimePath := GetRuntimeConfigPath()
+	output, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	output = append(output, '\n')
+
+	// Owner-only: it holds the pool credentials (expanded ${ENV:...}
+	// secrets included) and the API access-token
+	if err := os.WriteFile(runtimePath, output, 0600); err != nil {
+		return "", fmt.Errorf("failed to write runtime config: %w", err)
+	}
+	os.Chmod(runtimePath, 0600)
+
+	return runtimePath, nil
+}
+
+// RevertConfig returns configPath as PrepareRuntimeConfig would write it,
+// for undoing live overrides. The running miner's api and http sections are
+// kept so its identity and control API token don't change mid-run.
+func RevertConfig(configPath string, live map[string]interface{}) (map[string]interface{}, error) {
+	raw, err := buildRuntimeConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"api", "http"} {
+		if v, ok := live[key]; ok {
+			raw[key] = v
+		}
+	}
+	return raw, nil
+}
+
+// buildRuntimeConfig reads the selected config and applies everything
+// tarish layers on top of it
+func buildRuntimeConfig(configPath string) (map[string]interface{}, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read config: %w", err)
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// A shared fleet template gets this machine's wallet/worker/pool
+	if IsTemplate(data) {
+		data, err = RenderTemplate(data, DefaultTemplateVars())
+		if err != nil {
+			return nil, &TemplateError{Path: configPath, Err: err}
+		}
 	}
 
 	var raw map[string]interface{}
 	if err := json.Unmarshal(data, &raw); err != nil {
-		return "", fmt.Errorf("failed to parse config: %w", err)
+		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	// Build api.id: short CPU name + index (e.g. "m3max-0", "5900x-0")
-	shortName := getShortCPUName(cpuInfo.Family)
-	apiID := shortName + "-0"
-
-	// Build worker-id: local IP with dots replaced by dashes (e.g. "192-168-1-50")
-	workerID := buildWorkerID()
-
-	// Inject into the api section
+	// Inject identity into the api section. Values set explicitly in the
+	// selected config win; otherwise api.id is a UUID persisted in the data
+	// dir (stable across restarts) and worker-id follows 'tarish worker-id'.
 	apiSection, ok := raw["api"].(map[string]interface{})
 	if !ok {
 		apiSection = make(map[string]interface{})
 	}
-	apiSection["id"] = apiID
-	apiSection["worker-id"] = workerID
+	if id, _ := apiSection["id"].(string); id == "" {
+		apiID, err := LoadOrCreateMinerID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create miner ID: %w", err)
+		}
+		apiSection["id"] = apiID
+	}
+	if wid, _ := apiSection["worker-id"].(string); wid == "" {
+		apiSection["worker-id"] = WorkerID()
+	}
 	raw["api"] = apiSection
 
+	// Point xmrig's own log at the resolved log dir; shipped configs
+	// hardcode the system-wide path, which a user install can't write to.
+	if _, ok := raw["log-file"]; ok {
+		raw["log-file"] = GetLogFile()
+	}
+
 	// Apply TLS xmrig-proxy settings based on tarish config
 	applyTLSPoolSettings(raw)
 
-	// Write runtime config
-	runtimePath := GetRuntimeConfigPath()
-	output, err := json.MarshalIndent(raw, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal config: %w", err)
-	}
-	output = append(output, '\n')
+	// Keep the xmrig control API off the network and token-protected
+	applyHTTPSecurity(raw)
 
-	if err := os.WriteFile(runtimePath, output, 0666); err != nil {
-		return "", fmt.Errorf("failed to write runtime config: %w", err)
-	}
-	os.Chmod(runtimePath, 0666)
+	// Raise donate-level to the floor the server may have set
+	applyDonateLevelFloor(raw, config.GetDonateLevelFloor())
 
-	return runtimePath, nil
+	return raw, nil
+}
+
+// applyDonateLevelFloor raises donate-level to at least floor. Configs
+// that already donate more are left alone.
+func applyDonateLevelFloor(raw map[string]interface{}, floor int) {
+	if floor <= 0 {
+		return
+	}
+	current, _ := raw["donate-level"].(float64)
+	if int(current) < floor {
+		raw["donate-level"] = floor
+	}
 }
 
 // applyTLSPoolSettings modifies the pools section of a raw xmrig config
@@ -626,49 +1126,158 @@ func applyTLSPoolSettings(raw map[string]interface{}) {
 	}
 }
 
-// getShortCPUName returns a concise identifier for the CPU family.
-// Apple Silicon: apple_m3_max → m3max, AMD specific: 5900x → 5900x
-func getShortCPUName(family string) string {
-	if short := getShortName(family); short != "" {
-		return short
+// applyHTTPSecurity locks down the http section of a raw xmrig config.
+// The API accepts config PUTs, so unless the user opted into a LAN bind
+// the host is forced to 127.0.0.1, and an access-token is always set.
+// With token rotation enabled the shipped token is replaced on every start;
+// the agent reads it back from the runtime config, so nothing else changes.
+func applyHTTPSecurity(raw map[string]interface{}) {
+	httpSection, ok := raw["http"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if !config.IsAPILANBindEnabled() {
+		httpSection["host"] = "127.0.0.1"
+	}
+
+	if token, _ := httpSection["access-token"].(string); token == "" || config.IsAPITokenRotationEnabled() {
+		if token, err := generateAccessToken(); err == nil {
+			httpSection["access-token"] = token
+		}
 	}
-	return family
 }
 
-// buildWorkerID returns the local IP address with dots replaced by dashes.
-func buildWorkerID() string {
-	ip := getLocalIP()
-	return strings.ReplaceAll(ip, ".", "-")
+// generateAccessToken returns a random hex token for the xmrig HTTP API
+func generateAccessToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
-// getLocalIP returns the machine's preferred outbound IPv4 address.
-func getLocalIP() string {
-	// Preferred: determine the outbound IP via a UDP dial (no data is sent)
-	conn, err := net.Dial("udp", "8.8.8.8:80")
-	if err == nil {
-		defer conn.Close()
-		if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
-			return addr.IP.String()
+// IsPublicHTTPHost reports whether an xmrig http.host value makes the API
+// reachable from other machines. An empty host is xmrig's loopback default.
+func IsPublicHTTPHost(host string) bool {
+	if host == "" || host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return true
+	}
+	return !ip.IsLoopback()
+}
+
+// minerIDFile is where the generated miner ID is persisted
+func minerIDFile() string {
+	return filepath.Join(GetDataDir(), "miner-id")
+}
+
+// LoadOrCreateMinerID returns the persisted miner ID, generating a random
+// UUID on first use so the ID survives restarts and config changes.
+func LoadOrCreateMinerID() (string, error) {
+	path := minerIDFile()
+	if data, err := os.ReadFile(path); err == nil {
+		if id := strings.TrimSpace(string(data)); id != "" {
+			return id, nil
 		}
 	}
 
-	// Fallback: enumerate network interfaces
-	addrs, err := net.InterfaceAddrs()
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	buf[6] = (buf[6] & 0x0f) | 0x40 // version 4
+	buf[8] = (buf[8] & 0x3f) | 0x80 // RFC 4122 variant
+	id := fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:])
+
+	if err := EnsureDataDir(); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(id+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// configNameFile records which config template 'tarish start' selected
+func configNameFile() string {
+	return filepath.Join(GetDataDir(), "config-name")
+}
+
+// SaveSelectedConfigName persists the basename of the selected config so
+// the agent can report it (e.g. "apple_m3_pro.json"). A second line marks
+// selections that fell back to a default config.
+func SaveSelectedConfigName(configPath string, fallback bool) error {
+	if err := EnsureDataDir(); err != nil {
+		return err
+	}
+	content := filepath.Base(configPath) + "\n"
+	if fallback {
+		content += "fallback\n"
+	}
+	return os.WriteFile(configNameFile(), []byte(content), 0644)
+}
+
+// readSelectedConfig returns the lines of the config-name file
+func readSelectedConfig() []string {
+	data, err := os.ReadFile(configNameFile())
 	if err != nil {
-		return "unknown"
+		return nil
 	}
-	for _, addr := range addrs {
-		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
-			if ipNet.IP.To4() != nil {
-				return ipNet.IP.String()
-			}
+	return strings.Fields(string(data))
+}
+
+// GetSelectedConfigName returns the config basename saved by the last
+// 'tarish start', or "" if none was recorded.
+func GetSelectedConfigName() string {
+	lines := readSelectedConfig()
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[0]
+}
+
+// UsingFallbackConfig reports whether the last 'tarish start' fell back
+// to a default config instead of one tuned for the CPU family.
+func UsingFallbackConfig() bool {
+	lines := readSelectedConfig()
+	return len(lines) > 1 && lines[1] == "fallback"
+}
+
+// WorkerID returns the worker-id tarish gives xmrig under the configured
+// strategy. The ip strategy encodes the LAN IPv4 address with dashes, the
+// form the agent turns back into an IP; without one it uses the hostname.
+func WorkerID() string {
+	strategy, value := config.GetWorkerIDStrategy()
+	switch strategy {
+	case config.WorkerIDCustom:
+		return value
+	case config.WorkerIDIP:
+		if ip := DetectLANIP(false); ip != "" {
+			return strings.ReplaceAll(ip, ".", "-")
 		}
 	}
-	return "unknown"
+	return shortHostname()
+}
+
+// shortHostname returns the hostname without its domain part
+func shortHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	if i := strings.IndexByte(hostname, '.'); i > 0 {
+		hostname = hostname[:i]
+	}
+	return hostname
 }
 
 // GetHTTPConfigFromRuntime reads port and access-token from the active config.
 // It checks the runtime config first, then falls back to the system-selected config.
+// The runtime config is authoritative: it carries the rotated token, if any.
 func GetHTTPConfigFromRuntime() (port int, accessToken string) {
 	port = 8181 // match config default
 	accessToken = ""
@@ -676,12 +1285,14 @@ func GetHTTPConfigFromRuntime() (port int, accessToken string) {
 	// Try runtime config first, then fall back to system-selected config
 	data, err := os.ReadFile(GetRuntimeConfigPath())
 	if err != nil {
+		debugf("runtime config unavailable (%v), using the system config for API settings", err)
 		// Miner may have been started before runtime config was introduced,
 		// or manually — fall back to the config that matches this system.
 		if configPath, _, cfgErr := GetConfigForCurrentSystem(); cfgErr == nil {
 			data, err = os.ReadFile(configPath)
 		}
 		if err != nil {
+			debugf("no config to read API settings from, assuming port %d", port)
 			return
 		}
 	}
@@ -0,0 +1,517 @@
+package xmrig
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tarish/config"
+	"tarish/cpu"
+)
+
+func TestGetLogDirMatchesInstallShareDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	origEuid := geteuid
+	defer func() { geteuid = origEuid }()
+
+	tests := []struct {
+		name     string
+		euid     int
+		shareDir string
+	}{
+		{"root", 0, "/usr/local/share/tarish"},
+		{"user", 1000, filepath.Join(home, ".local", "share", "tarish")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geteuid = func() int { return tt.euid }
+
+			if got := GetShareDir(); got != tt.shareDir {
+				t.Errorf("GetShareDir() = %q, want %q", got, tt.shareDir)
+			}
+			wantLog := filepath.Join(tt.shareDir, "log")
+			if got := GetLogDir(); got != wantLog {
+				t.Errorf("GetLogDir() = %q, want %q", got, wantLog)
+			}
+			if got := GetPIDFile(); got != filepath.Join(wantLog, "xmrig.pid") {
+				t.Errorf("GetPIDFile() = %q, want it under %q", got, wantLog)
+			}
+		})
+	}
+}
+
+func TestLoadOrCreateMinerIDIsStable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	first, err := LoadOrCreateMinerID()
+	if err != nil {
+		t.Fatalf("LoadOrCreateMinerID() error: %v", err)
+	}
+	if len(first) != 36 || first[14] != '4' {
+		t.Errorf("LoadOrCreateMinerID() = %q, want a v4 UUID", first)
+	}
+
+	second, err := LoadOrCreateMinerID()
+	if err != nil {
+		t.Fatalf("LoadOrCreateMinerID() error: %v", err)
+	}
+	if second != first {
+		t.Errorf("second call = %q, want persisted %q", second, first)
+	}
+}
+
+func TestApplyDonateLevelFloor(t *testing.T) {
+	cases := []struct {
+		current interface{}
+		floor   int
+		want    interface{}
+	}{
+		{float64(0), 0, float64(0)},
+		{float64(0), 2, 2},
+		{float64(5), 2, float64(5)},
+		{nil, 1, 1},
+	}
+	for _, c := range cases {
+		raw := map[string]interface{}{}
+		if c.current != nil {
+			raw["donate-level"] = c.current
+		}
+		applyDonateLevelFloor(raw, c.floor)
+		if got := raw["donate-level"]; got != c.want {
+			t.Errorf("current=%v floor=%d: donate-level = %v, want %v", c.current, c.floor, got, c.want)
+		}
+	}
+}
+
+func TestSelectConfigDetailedFallback(t *testing.T) {
+	info := &cpu.Info{Family: "apple_m3_pro", OS: "darwin", Arch: "arm64", Cores: 12}
+
+	tests := []struct {
+		name     string
+		files    []string
+		want     string
+		fallback bool
+	}{
+		{"family", []string{"apple_m3_pro.json", "default.json"}, "apple_m3_pro.json", false},
+		{"base family", []string{"m3.json", "default.json"}, "m3.json", false},
+		{"arch default", []string{"arm64_default.json", "default.json"}, "arm64_default.json", true},
+		{"generic default", []string{"default.json"}, "default.json", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, f), []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sel, err := SelectConfigDetailed(info, dir)
+			if err != nil {
+				t.Fatalf("SelectConfigDetailed: %v", err)
+			}
+			if filepath.Base(sel.Path) != tt.want {
+				t.Errorf("Path = %s, want %s", filepath.Base(sel.Path), tt.want)
+			}
+			if sel.Fallback != tt.fallback {
+				t.Errorf("Fallback = %v, want %v", sel.Fallback, tt.fallback)
+			}
+		})
+	}
+}
+
+func TestSelectedConfigNameRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SaveSelectedConfigName("/x/configs/default.json", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetSelectedConfigName(); got != "default.json" {
+		t.Errorf("GetSelectedConfigName() = %q, want default.json", got)
+	}
+	if !UsingFallbackConfig() {
+		t.Error("UsingFallbackConfig() = false, want true")
+	}
+
+	if err := SaveSelectedConfigName("/x/configs/m3.json", false); err != nil {
+		t.Fatal(err)
+	}
+	if UsingFallbackConfig() {
+		t.Error("UsingFallbackConfig() = true, want false")
+	}
+}
+
+func TestSelectConfigNotFoundListsCandidates(t *testing.T) {
+	// A data dir under a regular file can't be created, so generating the
+	// generic config fails too
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", blocker)
+	configsPath := t.TempDir()
+
+	info := &cpu.Info{Family: "apple_m3_pro", OS: "darwin", Arch: "arm64", Cores: 12}
+	_, err := SelectConfig(info, configsPath)
+
+	var notFound *ConfigNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("SelectConfig error = %v, want *ConfigNotFoundError", err)
+	}
+	if notFound.ConfigsPath != configsPath {
+		t.Errorf("ConfigsPath = %s, want %s", notFound.ConfigsPath, configsPath)
+	}
+	want := buildConfigCandidates(info)
+	if strings.Join(notFound.Candidates, ",") != strings.Join(want, ",") {
+		t.Errorf("Candidates = %v, want %v", notFound.Candidates, want)
+	}
+	if tried := notFound.Tried(); !strings.HasPrefix(tried, "apple_m3_pro.json, ") || !strings.HasSuffix(tried, " in "+configsPath) {
+		t.Errorf("Tried() = %q", tried)
+	}
+}
+
+func TestGenericConfigGoesToDataDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configsPath := t.TempDir()
+
+	info := &cpu.Info{Family: "unknown", OS: "linux", Arch: "amd64", Cores: 4}
+	sel, err := SelectConfigDetailed(info, configsPath)
+	if err != nil {
+		t.Fatalf("SelectConfigDetailed: %v", err)
+	}
+	if want := filepath.Join(GetDataDir(), "generic_4cores.json"); sel.Path != want || sel.Index != -1 {
+		t.Errorf("Path = %s (index %d), want %s", sel.Path, sel.Index, want)
+	}
+	if entries, _ := os.ReadDir(configsPath); len(entries) != 0 {
+		t.Errorf("generic config written among the shipped configs: %v", entries)
+	}
+
+	cfg, err := LoadConfig(sel.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Host != "127.0.0.1" || cfg.HTTP.AccessToken != "" {
+		t.Errorf("http = %+v, want 127.0.0.1 with no baked-in token", cfg.HTTP)
+	}
+}
+
+func TestBuildConfigCandidatesARM(t *testing.T) {
+	info := &cpu.Info{Family: "bcm2711", OS: "linux", Arch: "arm64"}
+	got := strings.Join(buildConfigCandidates(info), ",")
+	want := "bcm2711.json,cortex_a72.json,arm.json,arm64_default.json,linux_default.json,default.json"
+	if got != want {
+		t.Errorf("buildConfigCandidates(bcm2711) = %s, want %s", got, want)
+	}
+}
+
+// TestShippedARMConfigs checks that ARM families land on a config shipped
+// in configs/ rather than the generated generic one
+func TestShippedARMConfigs(t *testing.T) {
+	tests := map[string]string{
+		"bcm2712":      "cortex_a76.json",
+		"bcm2711":      "cortex_a72.json",
+		"bcm2837":      "cortex_a53.json",
+		"ampere_altra": "neoverse_n1.json",
+		"neoverse_n1":  "neoverse_n1.json",
+		"neoverse_v2":  "arm.json",
+		"ampere_one":   "arm.json",
+	}
+	for family, want := range tests {
+		info := &cpu.Info{Family: family, OS: "linux", Arch: "arm64", Cores: 4}
+		sel, err := SelectConfigDetailed(info, filepath.Join("..", "configs"))
+		if err != nil {
+			t.Fatalf("%s: %v", family, err)
+		}
+		if got := filepath.Base(sel.Path); got != want || sel.Fallback {
+			t.Errorf("%s: selected %s (fallback %v), want %s", family, got, sel.Fallback, want)
+		}
+	}
+}
+
+func TestSetMaxThreadsHint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "test.json")
+	if err := os.WriteFile(path, []byte(`{"cpu":{"enabled":true,"max-threads-hint":100},"pools":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := SetMaxThreadsHint(path, 50)
+	if err != nil {
+		t.Fatalf("SetMaxThreadsHint: %v", err)
+	}
+	if result.Of != 0 {
+		t.Errorf("result = %+v, want no explicit threads", result)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpuSection, _ := cfg.CPU.(map[string]interface{})
+	if cpuSection["max-threads-hint"] != float64(50) || cpuSection["enabled"] != true {
+		t.Errorf("cpu = %v, want max-threads-hint 50 and other keys kept", cfg.CPU)
+	}
+
+	if _, err := SetMaxThreadsHint(path, 101); err == nil {
+		t.Error("SetMaxThreadsHint accepted 101")
+	}
+}
+
+func TestSetMaxThreadsHintExplicitThreads(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	data, err := os.ReadFile(filepath.Join("..", "configs", "5900x.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "5900x.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rxLen := func() int {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cpuSection, _ := cfg.CPU.(map[string]interface{})
+		rx, _ := cpuSection["rx"].([]interface{})
+		return len(rx)
+	}
+
+	// xmrig ignores max-threads-hint when cpu.rx lists threads, so the
+	// list itself is cut, and restored from the saved copy later
+	for _, tt := range []struct{ pct, want, was int }{{50, 12, 24}, {50, 12, 12}, {25, 6, 12}, {100, 24, 6}} {
+		result, err := SetMaxThreadsHint(path, tt.pct)
+		if err != nil {
+			t.Fatalf("SetMaxThreadsHint(%d): %v", tt.pct, err)
+		}
+		if result.Threads != tt.want || result.Of != 24 || result.Was != tt.was {
+			t.Errorf("SetMaxThreadsHint(%d) = %+v, want %d of 24, was %d", tt.pct, result, tt.want, tt.was)
+		}
+		if got := rxLen(); got != tt.want {
+			t.Errorf("after %d%%: cpu.rx has %d threads, want %d", tt.pct, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl := []byte(`{"pools": [{"url": "${POOL}", "user": "${WALLET}.${WORKER}", "pass": "x"}]}`)
+	vars := TemplateVars{Wallet: "4abc", Worker: `rig"1`, Pool: "pool.example:3333"}
+
+	rendered, err := RenderTemplate(tmpl, vars)
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(rendered, &cfg); err != nil {
+		t.Fatalf("rendered config doesn't parse: %v\n%s", err, rendered)
+	}
+	if got := cfg.Pools[0].URL; got != "pool.example:3333" {
+		t.Errorf("url = %q", got)
+	}
+	// values are JSON-escaped, so a quote in the worker can't break the config
+	if got := cfg.Pools[0].User; got != `4abc.rig"1` {
+		t.Errorf("user = %q", got)
+	}
+	if IsTemplate(rendered) {
+		t.Error("rendered config still has placeholders")
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	full := TemplateVars{Wallet: "w", Worker: "r", Pool: "p"}
+	tests := []struct {
+		name string
+		tmpl string
+		vars TemplateVars
+		want string
+	}{
+		{"missing value", `{"user": "${WALLET}"}`, TemplateVars{Worker: "r"}, "no value for ${WALLET}"},
+		{"unknown placeholder", `{"user": "${PASSWORD}"}`, full, "unknown placeholder ${PASSWORD}"},
+		{"invalid result", `{"user": ${WALLET}}`, full, "not valid JSON"},
+	}
+	for _, tt := range tests {
+		_, err := RenderTemplate([]byte(tt.tmpl), tt.vars)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRenderTemplateEnv(t *testing.T) {
+	t.Setenv("TARISH_TEST_WALLET", "4fromenv")
+	tmpl := []byte(`{"pools": [{"url": "p:3333", "user": "${ENV:TARISH_TEST_WALLET}", "pass": "${ENV:TARISH_TEST_PASS}"}]}`)
+
+	_, err := RenderTemplate(tmpl, TemplateVars{})
+	if err == nil || !strings.Contains(err.Error(), "TARISH_TEST_PASS is not set") {
+		t.Fatalf("err = %v, want unset TARISH_TEST_PASS error", err)
+	}
+
+	t.Setenv("TARISH_TEST_PASS", "")
+	rendered, err := RenderTemplate(tmpl, TemplateVars{})
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(rendered, &cfg); err != nil {
+		t.Fatalf("rendered config doesn't parse: %v", err)
+	}
+	if cfg.Pools[0].User != "4fromenv" || cfg.Pools[0].Pass != "" {
+		t.Errorf("pool = %+v", cfg.Pools[0])
+	}
+}
+
+func TestRevertConfigKeepsLiveIdentity(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	origEuid := geteuid
+	geteuid = func() int { return 1000 }
+	defer func() { geteuid = origEuid }()
+
+	configPath := filepath.Join(t.TempDir(), "rig.json")
+	os.WriteFile(configPath, []byte(`{
+		"cpu": {"max-threads-hint": 100},
+		"pools": [{"url": "pool:3333", "user": "4abc"}],
+		"http": {"enabled": true, "port": 18088}
+	}`), 0644)
+	live := map[string]interface{}{
+		"cpu":  map[string]interface{}{"max-threads-hint": 25.0},
+		"api":  map[string]interface{}{"id": "live-id", "worker-id": "rig"},
+		"http": map[string]interface{}{"enabled": true, "port": 18088.0, "access-token": "live-token"},
+	}
+
+	reverted, err := RevertConfig(configPath, live)
+	if err != nil {
+		t.Fatalf("RevertConfig: %v", err)
+	}
+	if hint := reverted["cpu"].(map[string]interface{})["max-threads-hint"]; hint != 100.0 {
+		t.Errorf("max-threads-hint = %v, want the on-disk 100", hint)
+	}
+	if token := reverted["http"].(map[string]interface{})["access-token"]; token != "live-token" {
+		t.Errorf("access-token = %v, want the running miner's token", token)
+	}
+	if id := reverted["api"].(map[string]interface{})["id"]; id != "live-id" {
+		t.Errorf("api.id = %v, want the running miner's id", id)
+	}
+}
+
+func TestImportConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configsDir := filepath.Join(home, ".local", "share", "tarish", "configs")
+	if err := os.MkdirAll(configsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := []byte(`{"pools": [{"url": "${POOL}", "user": "${WALLET}"}]}`)
+	path, err := ImportConfig("m3.json", valid)
+	if err != nil || path != filepath.Join(configsDir, "m3.json") {
+		t.Fatalf("ImportConfig = %q, %v", path, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(valid) {
+		t.Errorf("written config = %s", data)
+	}
+
+	for _, tt := range []struct {
+		name string
+		data string
+	}{
+		{"../m3.json", string(valid)},
+		{"m3.txt", string(valid)},
+		{"bad.json", `{"pools": []}`},
+		{"bad.json", `not json`},
+	} {
+		if _, err := ImportConfig(tt.name, []byte(tt.data)); err == nil {
+			t.Errorf("ImportConfig(%q, %s) succeeded, want an error", tt.name, tt.data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(configsDir, "bad.json")); err == nil {
+		t.Error("an invalid config was written")
+	}
+}
+
+func TestWorkerIDStrategy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	origEuid := geteuid
+	geteuid = func() int { return 1000 }
+	defer func() { geteuid = origEuid }()
+
+	configPath := filepath.Join(t.TempDir(), "rig.json")
+	os.WriteFile(configPath, []byte(`{"pools": [{"url": "pool:3333", "user": "4abc"}]}`), 0644)
+	workerID := func() interface{} {
+		raw, err := buildRuntimeConfig(configPath)
+		if err != nil {
+			t.Fatalf("buildRuntimeConfig: %v", err)
+		}
+		return raw["api"].(map[string]interface{})["worker-id"]
+	}
+
+	if got := workerID(); got != shortHostname() {
+		t.Errorf("default worker-id = %v, want the hostname %q", got, shortHostname())
+	}
+
+	if err := config.SetWorkerIDStrategy(config.WorkerIDCustom, "rig-07"); err != nil {
+		t.Fatal(err)
+	}
+	if got := workerID(); got != "rig-07" {
+		t.Errorf("custom worker-id = %v, want rig-07", got)
+	}
+	if vars := DefaultTemplateVars(); vars.Worker != "rig-07" {
+		t.Errorf("${WORKER} = %q, want it to follow the worker-id", vars.Worker)
+	}
+
+	// the agent turns IP-style worker-ids back into the IP
+	if err := config.SetWorkerIDStrategy(config.WorkerIDIP, ""); err != nil {
+		t.Fatal(err)
+	}
+	if ip := DetectLANIP(false); ip != "" {
+		got, _ := workerID().(string)
+		if net.ParseIP(strings.ReplaceAll(got, "-", ".")).String() != ip {
+			t.Errorf("ip worker-id = %q, want %s with dashes", got, ip)
+		}
+	}
+
+	for _, bad := range [][2]string{{config.WorkerIDCustom, ""}, {config.WorkerIDCustom, "my rig"}, {"mac", ""}} {
+		if err := config.SetWorkerIDStrategy(bad[0], bad[1]); err == nil {
+			t.Errorf("SetWorkerIDStrategy(%q, %q) succeeded", bad[0], bad[1])
+		}
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, content, wantErr string
+	}{
+		{"ok.json", `{"pools": [{"url": "pool:3333", "user": "4abc"}], "donate-level": 1}`, ""},
+		{"template.json", `{"pools": [{"url": "${POOL}", "user": "${WALLET}"}]}`, ""},
+		{"syntax.json", "{\n  \"pools\": [],\n}", "line 3, column 1"},
+		{"array.json", `[]`, "invalid JSON"},
+		{"nopools.json", `{"pools": []}`, "no pools configured"},
+		{"donate.json", `{"pools": [{"url": "pool:3333", "user": "4abc"}], "donate-level": 101}`, "donate-level 101 out of range"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		os.WriteFile(path, []byte(tt.content), 0644)
+		cfg, err := ValidateConfigFile(path)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr == "" && cfg.Raw == nil:
+			t.Errorf("%s: no config returned", tt.name)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if _, err := ValidateConfigFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file validated")
+	}
+}
@@ -0,0 +1,112 @@
+package xmrig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// exitLogLines is how much of the log an ExitRecord keeps
+const exitLogLines = 20
+
+// ExitRecord is last-exit.json: how xmrig last stopped on its own. The
+// process that started xmrig records the exit status if it is still
+// waiting on it; later exits are found by ReapExited from a PID file that
+// 'tarish stop' didn't remove, with the status unknown (Code -1).
+type ExitRecord struct {
+	Code     int       `json:"code"` // -1 if unknown
+	Signal   string    `json:"signal,omitempty"`
+	Time     time.Time `json:"time"`
+	LogLines []string  `json:"log_lines,omitempty"`
+}
+
+func lastExitFile() string {
+	return filepath.Join(GetDataDir(), "last-exit.json")
+}
+
+// newExitRecord describes an abnormal exit, or returns nil for a clean one
+func newExitRecord(state *os.ProcessState, logFile string) *ExitRecord {
+	if state == nil || state.Success() {
+		return nil
+	}
+	rec := &ExitRecord{Code: state.ExitCode(), Time: time.Now().UTC()}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		rec.Signal = ws.Signal().String()
+	}
+	rec.LogLines, _ = TailFile(logFile, exitLogLines)
+	return rec
+}
+
+// vanishedExit describes the exit of an xmrig the PID file still names
+// but that is gone, or returns nil if the process that started xmrig
+// recorded it already. Nobody waited on it, so the status is unknown; the
+// log's last write dates it.
+func vanishedExit() *ExitRecord {
+	started, _ := readStartedAt()
+	if rec, _ := LastExit(); rec != nil && !rec.Time.Before(started) {
+		return nil
+	}
+	logFile := GetLogFile()
+	rec := &ExitRecord{Code: -1, Time: time.Now().UTC()}
+	if fi, err := os.Stat(logFile); err == nil && fi.ModTime().After(started) {
+		rec.Time = fi.ModTime().UTC()
+	}
+	rec.LogLines, _ = TailFile(logFile, exitLogLines)
+	return rec
+}
+
+// recordVanishedExit saves vanishedExit as the last exit
+func recordVanishedExit() {
+	if rec := vanishedExit(); rec != nil {
+		saveLastExit(rec)
+	}
+}
+
+func saveLastExit(rec *ExitRecord) error {
+	if err := EnsureDataDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(lastExitFile(), data, 0644)
+}
+
+// clearLastExit forgets the previous run's exit when a new one starts
+func clearLastExit() {
+	os.Remove(lastExitFile())
+}
+
+// LastExit returns the recorded abnormal exit of the last run, or nil if
+// there is none
+func LastExit() (*ExitRecord, error) {
+	data, err := os.ReadFile(lastExitFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec ExitRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Describe is a one-line summary for status output
+func (r *ExitRecord) Describe() string {
+	how := fmt.Sprintf("code %d", r.Code)
+	switch {
+	case r.Signal != "":
+		how = "killed by " + r.Signal
+	case r.Code < 0:
+		how = "exit status unknown"
+	}
+	return fmt.Sprintf("last exited abnormally (%s) at %s", how, r.Time.Local().Format("2006-01-02 15:04:05"))
+}
@@ -0,0 +1,109 @@
+package xmrig
+
+import (
+	"net"
+	"strings"
+
+	"tarish/config"
+)
+
+// DetectLANIP returns a real LAN IP address, skipping VPN/tunnel interfaces.
+// Prefers RFC1918 addresses (192.168.x, 10.x, 172.16-31.x), then any other
+// IPv4 address, then, if allowIPv6, a global IPv6 address for IPv6-only
+// LANs. The agent reports it; the ip worker-id strategy encodes it.
+func DetectLANIP(allowIPv6 bool) string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+
+	// Both lists come from tarish.json (vpn_interface_prefixes, lan_interfaces)
+	vpnPrefixes := config.GetVPNInterfacePrefixes()
+	lanInterfaces := config.GetLANInterfaces()
+
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		// Skip VPN/tunnel interfaces unless explicitly allowed
+		name := iface.Name
+		if IsVPNInterface(name, vpnPrefixes) && !hasAnyPrefix(name, lanInterfaces) {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				ips = append(ips, ipNet.IP)
+			}
+		}
+	}
+	return pickLANIP(ips, allowIPv6)
+}
+
+// pickLANIP picks DetectLANIP's address from all interfaces' addresses,
+// in interface order: every IPv4 address is tried before any IPv6 one.
+func pickLANIP(ips []net.IP, allowIPv6 bool) string {
+	var fallback, fallbackV6 string
+	for _, addr := range ips {
+		ip := addr.To4()
+		if ip == nil {
+			if allowIPv6 && fallbackV6 == "" && isLANIPv6(addr) {
+				fallbackV6 = addr.String()
+			}
+			continue
+		}
+		if isPrivateIP(ip) {
+			return ip.String()
+		}
+		if fallback == "" {
+			fallback = ip.String()
+		}
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return fallbackV6
+}
+
+// IsVPNInterface reports whether name matches one of the VPN prefixes;
+// an empty prefix list disables filtering.
+func IsVPNInterface(name string, prefixes []string) bool {
+	return hasAnyPrefix(name, prefixes)
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if p != "" && strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPrivateIP(ip net.IP) bool {
+	private := []net.IPNet{
+		{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+		{IP: net.IP{172, 16, 0, 0}, Mask: net.CIDRMask(12, 32)},
+		{IP: net.IP{192, 168, 0, 0}, Mask: net.CIDRMask(16, 32)},
+	}
+	for _, n := range private {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLANIPv6 reports whether ip is a routable IPv6 address worth reporting:
+// global unicast (including ULA), not link-local or an IPv4 mapping.
+func isLANIPv6(ip net.IP) bool {
+	if ip.To4() != nil || ip.To16() == nil {
+		return false
+	}
+	return ip.IsGlobalUnicast() && !ip.IsLinkLocalUnicast()
+}
@@ -0,0 +1,100 @@
+package xmrig
+
+import (
+	"net"
+	"testing"
+
+	"tarish/config"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"192.168.1.50", true},
+		{"10.0.0.1", true},
+		{"172.16.0.1", true},
+		{"172.31.255.254", true},
+		{"172.32.0.1", false},
+		{"100.64.0.1", false},
+		{"8.8.8.8", false},
+	}
+	for _, tt := range tests {
+		if got := isPrivateIP(net.ParseIP(tt.ip).To4()); got != tt.want {
+			t.Errorf("isPrivateIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestIsVPNInterface(t *testing.T) {
+	defaults := config.DefaultVPNInterfacePrefixes
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     bool
+	}{
+		{"tun0", defaults, true},
+		{"utun3", defaults, true},
+		{"wg0", defaults, true},
+		{"tailscale0", defaults, true},
+		{"eth0", defaults, false},
+		{"en0", defaults, false},
+		{"wlan0", defaults, false},
+		{"vpn0", []string{"vpn"}, true},
+		{"tun0", []string{"vpn"}, false},
+		{"tun0", []string{}, false}, // empty list disables filtering
+	}
+	for _, tt := range tests {
+		if got := IsVPNInterface(tt.name, tt.prefixes); got != tt.want {
+			t.Errorf("IsVPNInterface(%s, %v) = %v, want %v", tt.name, tt.prefixes, got, tt.want)
+		}
+	}
+}
+
+func TestIsLANIPv6(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"2001:db8::1", true},
+		{"fd12:3456:789a::1", true},
+		{"fe80::1", false},
+		{"::1", false},
+		{"::ffff:192.168.1.50", false},
+		{"192.168.1.50", false},
+		{"ff02::1", false},
+	}
+	for _, tt := range tests {
+		if got := isLANIPv6(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isLANIPv6(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestPickLANIP(t *testing.T) {
+	parse := func(addrs ...string) []net.IP {
+		var ips []net.IP
+		for _, a := range addrs {
+			ips = append(ips, net.ParseIP(a))
+		}
+		return ips
+	}
+	tests := []struct {
+		name      string
+		ips       []net.IP
+		allowIPv6 bool
+		want      string
+	}{
+		{"private first", parse("2001:db8::1", "203.0.113.7", "192.168.1.50"), true, "192.168.1.50"},
+		{"public IPv4 before IPv6", parse("2001:db8::1", "203.0.113.7"), true, "203.0.113.7"},
+		{"IPv6-only LAN", parse("fe80::1", "2001:db8::1"), true, "2001:db8::1"},
+		{"IPv6 not allowed", parse("2001:db8::1"), false, ""},
+		{"none", nil, true, ""},
+	}
+	for _, tt := range tests {
+		if got := pickLANIP(tt.ips, tt.allowIPv6); got != tt.want {
+			t.Errorf("%s: pickLANIP() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package xmrig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// PauseReason is who paused mining. Several can hold it paused at once;
+// mining resumes only when the last one lets go.
+type PauseReason string
+
+const (
+	PauseManual   PauseReason = "manual"   // tarish pause
+	PauseSchedule PauseReason = "schedule" // outside the mining windows
+	PauseActivity PauseReason = "activity" // user at the keyboard
+)
+
+// pauseAPI and resumeAPI are swapped out in tests
+var (
+	pauseAPI  = Pause
+	resumeAPI = Resume
+)
+
+func pauseFile() string {
+	return filepath.Join(GetDataDir(), "pause.json")
+}
+
+// PauseFor pauses mining on behalf of reason and records it in pause.json
+func PauseFor(reason PauseReason) error {
+	return updatePauseReasons(func(reasons map[PauseReason]bool) (bool, error) {
+		if err := pauseAPI(); err != nil {
+			return false, err
+		}
+		reasons[reason] = true
+		return true, nil
+	})
+}
+
+// ResumeFor drops reason and resumes mining unless another reason still
+// holds it paused. It returns those remaining reasons; none means mining
+// was resumed.
+func ResumeFor(reason PauseReason) ([]PauseReason, error) {
+	var held []PauseReason
+	err := updatePauseReasons(func(reasons map[PauseReason]bool) (bool, error) {
+		delete(reasons, reason)
+		if len(reasons) > 0 {
+			held = sortedReasons(reasons)
+			return true, nil
+		}
+		// Keep reason recorded if xmrig didn't resume, so a retry does
+		if err := resumeAPI(); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	return held, err
+}
+
+// PauseReasons returns who currently holds mining paused
+func PauseReasons() []PauseReason {
+	return sortedReasons(readPauseReasons())
+}
+
+// IsPausedFor reports whether reason is holding mining paused
+func IsPausedFor(reason PauseReason) bool {
+	return readPauseReasons()[reason]
+}
+
+// JoinPauseReasons formats reasons as "activity, schedule"
+func JoinPauseReasons(reasons []PauseReason) string {
+	names := make([]string, len(reasons))
+	for i, r := range reasons {
+		names[i] = string(r)
+	}
+	return strings.Join(names, ", ")
+}
+
+// clearPauseReasons forgets all reasons; a freshly started xmrig mines
+func clearPauseReasons() {
+	os.Remove(pauseFile())
+}
+
+// updatePauseReasons runs fn on the recorded reasons under an exclusive
+// lock, so the daemons and the CLI don't race each other's pause and
+// resume, and saves them if fn returns true
+func updatePauseReasons(fn func(map[PauseReason]bool) (bool, error)) error {
+	if err := EnsureDataDir(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(pauseFile(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	reasons := make(map[PauseReason]bool)
+	var list []PauseReason
+	if json.NewDecoder(f).Decode(&list) == nil {
+		for _, r := range list {
+			reasons[r] = true
+		}
+	}
+
+	save, err := fn(reasons)
+	if err != nil || !save {
+		return err
+	}
+	data, err := json.Marshal(sortedReasons(reasons))
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
+
+func readPauseReasons() map[PauseReason]bool {
+	reasons := make(map[PauseReason]bool)
+	data, err := os.ReadFile(pauseFile())
+	if err != nil {
+		return reasons
+	}
+	var list []PauseReason
+	json.Unmarshal(data, &list)
+	for _, r := range list {
+		reasons[r] = true
+	}
+	return reasons
+}
+
+func sortedReasons(reasons map[PauseReason]bool) []PauseReason {
+	list := make([]PauseReason, 0, len(reasons))
+	for r := range reasons {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
@@ -0,0 +1,45 @@
+package xmrig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPauseReasons(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	origPause, origResume := pauseAPI, resumeAPI
+	defer func() { pauseAPI, resumeAPI = origPause, origResume }()
+
+	var calls []string
+	pauseAPI = func() error { calls = append(calls, "pause"); return nil }
+	resumeAPI = func() error { calls = append(calls, "resume"); return nil }
+
+	// a manual pause outlasts the schedule and activity daemons
+	for _, r := range []PauseReason{PauseManual, PauseSchedule, PauseActivity} {
+		if err := PauseFor(r); err != nil {
+			t.Fatalf("PauseFor(%s): %v", r, err)
+		}
+	}
+	if held, err := ResumeFor(PauseActivity); err != nil || !reflect.DeepEqual(held, []PauseReason{PauseManual, PauseSchedule}) {
+		t.Errorf("ResumeFor(activity) = %v, %v; want manual and schedule still holding", held, err)
+	}
+	if held, _ := ResumeFor(PauseSchedule); !reflect.DeepEqual(held, []PauseReason{PauseManual}) {
+		t.Errorf("ResumeFor(schedule) = %v, want manual still holding", held)
+	}
+	if IsPausedFor(PauseSchedule) || !IsPausedFor(PauseManual) {
+		t.Errorf("reasons = %v, want only manual", PauseReasons())
+	}
+	if held, _ := ResumeFor(PauseManual); len(held) != 0 {
+		t.Errorf("ResumeFor(manual) = %v, want mining resumed", held)
+	}
+	if want := []string{"pause", "pause", "pause", "resume"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("API calls = %v, want %v", calls, want)
+	}
+
+	// a fresh xmrig starts unpaused
+	PauseFor(PauseActivity)
+	clearPauseReasons()
+	if reasons := PauseReasons(); len(reasons) != 0 {
+		t.Errorf("after clearPauseReasons: %v", reasons)
+	}
+}
@@ -1,8 +1,9 @@
 package xmrig
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,6 +18,8 @@ import (
 	"time"
 
 	"tarish/antisleep"
+	"tarish/config"
+	"tarish/proc"
 )
 
 // ProcessStatus represents the current state of xmrig
@@ -24,11 +27,14 @@ type ProcessStatus struct {
 	Running         bool
 	PID             int
 	Version         string
+	Algo            string
 	Uptime          time.Duration
 	Hashrate        *HashrateInfo
 	Pool            *PoolInfo
 	DonateLevel     int
 	SleepPrevention bool
+	LastExit        *ExitRecord   // how the last run ended, when not running
+	PausedBy        []PauseReason // who holds mining paused, when running
 }
 
 // HashrateInfo contains hashrate statistics
@@ -49,7 +55,9 @@ type PoolInfo struct {
 type APIResponse struct {
 	ID       string `json:"id"`
 	Version  string `json:"version"`
+	Algo     string `json:"algo"`
 	Uptime   int64  `json:"uptime"`
+	Paused   bool   `json:"paused"`
 	Hashrate struct {
 		Total []float64 `json:"total"`
 	} `json:"hashrate"`
@@ -59,15 +67,54 @@ type APIResponse struct {
 		Accepted int    `json:"accepted"`
 		Rejected int    `json:"rejected"`
 	} `json:"connection"`
+	// Hugepages is [used, total] on current xmrig, a bool on old builds
+	Hugepages json.RawMessage `json:"hugepages,omitempty"`
+	CPU       struct {
+		MSR string `json:"msr"` // MSR preset for this CPU, "none" if there isn't one
+	} `json:"cpu"`
+}
+
+// HugepagesEnabled reports whether xmrig got all the huge pages it asked
+// for; anything less costs hashrate.
+func (a *APIResponse) HugepagesEnabled() bool {
+	var pages []int64
+	if json.Unmarshal(a.Hugepages, &pages) == nil {
+		return len(pages) == 2 && pages[1] > 0 && pages[0] == pages[1]
+	}
+	var enabled bool
+	if json.Unmarshal(a.Hugepages, &enabled) == nil {
+		return enabled
+	}
+	return false
+}
+
+// StartOptions holds optional settings for StartWithOptions
+type StartOptions struct {
+	Force bool
+	// CPUs restricts xmrig to a CPU list in taskset syntax (e.g. "0-3,6").
+	// Linux runs xmrig under taskset; if taskset is missing a warning is
+	// printed and xmrig runs unpinned. macOS has no affinity API, so the
+	// list is written into the runtime config's cpu.rx thread affinities.
+	CPUs string
+	// SleepMode selects what antisleep inhibits while mining (default ModeFull).
+	SleepMode antisleep.Mode
 }
 
 // Start starts xmrig as a daemon process
 func Start(binaryPath, configPath string, force bool) error {
+	return StartWithOptions(binaryPath, configPath, StartOptions{Force: force})
+}
+
+// StartWithOptions starts xmrig as a daemon process
+func StartWithOptions(binaryPath, configPath string, opts StartOptions) error {
+	force := opts.Force
+
 	if err := EnsureDataDir(); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
 	// Check if already running
+	ReapExited()
 	if pid, running := IsRunning(); running {
 		if !force {
 			return fmt.Errorf("xmrig is already running (PID: %d). Use --force to kill and restart", pid)
@@ -89,16 +136,29 @@ func Start(binaryPath, configPath string, force bool) error {
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
 	logFile := GetLogFile()
+	// Keep the previous runs' logs around for crash diagnosis
+	if err := rotateLog(logFile, logRotations); err != nil {
+		fmt.Printf("Warning: failed to rotate %s: %v\n", logFile, err)
+	}
 	// Open with 0666 permissions (read/write for everyone) so different users can append
-	logHandle, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	// O_APPEND so a size-based rotation can truncate it under a running xmrig
+	logHandle, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
 		return fmt.Errorf("failed to create log file: %w", err)
 	}
 	// Explicitly chmod to ensure 0666 (OpenFile obeys umask)
 	os.Chmod(logFile, 0666)
 
-	// Build command
+	// Build command, optionally pinned to a CPU list
 	cmd := exec.Command(binaryPath, "-c", configPath)
+	if opts.CPUs != "" {
+		cpus, err := ParseCPUList(opts.CPUs)
+		if err != nil {
+			logHandle.Close()
+			return err
+		}
+		cmd = pinCommand(cmd, opts.CPUs, cpus, configPath)
+	}
 	cmd.Stdout = logHandle
 	cmd.Stderr = logHandle
 	cmd.Dir = filepath.Dir(binaryPath)
@@ -124,17 +184,40 @@ func Start(binaryPath, configPath string, force bool) error {
 	}
 
 	// Detach from the process (don't wait for it)
+	exited := make(chan struct{})
+	var waitErr error
 	go func() {
-		cmd.Wait()
+		waitErr = cmd.Wait()
 		logHandle.Close()
+		// Keep a record of a crash for 'tarish status'. 'tarish stop'
+		// marks the stop before killing, so a stop isn't one.
+		if current, err := readPID(); err == nil && current == pid && !stopRequested(pid) {
+			if rec := newExitRecord(cmd.ProcessState, logFile); rec != nil {
+				saveLastExit(rec)
+			}
+		}
 		// Clean up PID file if process exits
-		os.Remove(GetPIDFile())
+		removePIDFile()
 		// Disable sleep prevention when process exits
 		antisleep.Disable()
+		close(exited)
 	}()
 
+	// A bad config or a permission problem makes xmrig quit right away;
+	// report that rather than a start that didn't stick
+	select {
+	case <-exited:
+		return startupError(waitErr, logFile)
+	case <-time.After(startupCheckDelay):
+	}
+	if !isProcessRunning(pid) {
+		return startupError(nil, logFile)
+	}
+	clearLastExit()
+	clearPauseReasons()
+
 	// Enable sleep prevention to keep system awake during mining
-	if err := antisleep.Enable(); err != nil {
+	if err := antisleep.Enable(opts.SleepMode); err != nil {
 		fmt.Printf("Warning: Failed to enable sleep prevention: %v\n", err)
 		fmt.Println("System may sleep during mining. Consider enabling manually.")
 	} else {
@@ -147,15 +230,153 @@ func Start(binaryPath, configPath string, force bool) error {
 	return nil
 }
 
+// startupCheckDelay is how long StartWithOptions watches a new xmrig
+// before reporting it started
+const startupCheckDelay = time.Second
+
+// startupLogLines is how much of the log a startup failure shows
+const startupLogLines = 10
+
+// startupError describes an xmrig that quit during startupCheckDelay, with
+// the likely cause and the end of its log
+func startupError(waitErr error, logFile string) error {
+	msg := "xmrig exited immediately after start"
+	if waitErr != nil {
+		msg += " (" + waitErr.Error() + ")"
+	}
+	lines, _ := TailFile(logFile, startupLogLines)
+	if cause := likelyExitCause(lines); cause != "" {
+		msg += "\nLikely cause: " + cause
+	}
+	if len(lines) > 0 {
+		msg += "\nLast log lines:\n  " + strings.Join(lines, "\n  ")
+	} else {
+		msg += "\nNothing was logged to " + logFile
+	}
+	return errors.New(msg)
+}
+
+// exitCauses map log text (lower case) to an explanation, checked in order
+var exitCauses = []struct {
+	match, cause string
+}{
+	{"permission denied", "permission denied; check the permissions of the xmrig binary, config and log directory"},
+	{"address already in use", "the HTTP API port is taken, probably by another xmrig (tarish stop, or start --force)"},
+	{"illegal instruction", "this xmrig build doesn't support the CPU; try another --xmrig-version"},
+	{"no valid configuration", "xmrig found no usable config; check the pools section"},
+	{"invalid value", "the config has a value xmrig rejects; see the log line above"},
+	{"parse error", "the config is not valid JSON"},
+	{"syntax error", "the config is not valid JSON"},
+}
+
+// likelyExitCause guesses why xmrig quit from the end of its log, or
+// returns "" if nothing in it is recognised
+func likelyExitCause(lines []string) string {
+	for _, c := range exitCauses {
+		for _, line := range lines {
+			if strings.Contains(strings.ToLower(line), c.match) {
+				return c.cause
+			}
+		}
+	}
+	return ""
+}
+
+// pinCommand restricts xmrig to the given CPUs. On Linux the command is
+// wrapped with taskset; on macOS the runtime config's thread affinities
+// are rewritten instead. Failures only warn: xmrig still starts unpinned.
+func pinCommand(cmd *exec.Cmd, list string, cpus []int, configPath string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "linux":
+		tasksetPath, err := exec.LookPath("taskset")
+		if err != nil {
+			fmt.Println("Warning: taskset not found, running xmrig unpinned (install util-linux)")
+			return cmd
+		}
+		fmt.Printf("Pinning xmrig to CPUs %s (taskset)\n", list)
+		return exec.Command(tasksetPath, append([]string{"-c", list}, cmd.Args...)...)
+	case "darwin":
+		if configPath != GetRuntimeConfigPath() {
+			fmt.Println("Warning: no runtime config, running xmrig unpinned")
+			return cmd
+		}
+		if err := applyCPUAffinity(configPath, cpus); err != nil {
+			fmt.Printf("Warning: failed to set CPU affinity, running xmrig unpinned: %v\n", err)
+			return cmd
+		}
+		fmt.Printf("Pinning xmrig to CPUs %s (cpu.rx affinity)\n", list)
+	}
+	return cmd
+}
+
+// ParseCPUList parses a taskset-style CPU list such as "0-3,6,8-9"
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("invalid CPU list %q", list)
+		}
+		last := first
+		if isRange {
+			last, err = strconv.Atoi(hi)
+			if err != nil || last < first {
+				return nil, fmt.Errorf("invalid CPU list %q", list)
+			}
+		}
+		for c := first; c <= last; c++ {
+			cpus = append(cpus, c)
+		}
+	}
+	return cpus, nil
+}
+
+// applyCPUAffinity rewrites the RandomX thread list of a runtime config so
+// each thread is pinned to one of the given CPUs.
+func applyCPUAffinity(configPath string, cpus []int) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	cpuSection, ok := raw["cpu"].(map[string]interface{})
+	if !ok {
+		cpuSection = make(map[string]interface{})
+		raw["cpu"] = cpuSection
+	}
+	cpuSection["rx"] = cpus
+
+	output, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, append(output, '\n'), 0600)
+}
+
 // Stop stops all xmrig processes
 func Stop() error {
 	killed := false
 
-	// First try to kill by PID file
+	// First try to kill by PID file. The stop marker tells anything
+	// watching (ReapExited, the starting process) this exit isn't a
+	// crash; the PID file stays until xmrig is confirmed gone.
+	ReapExited()
 	if pid, running := IsRunning(); running {
+		markStopRequested(pid)
+		defer os.Remove(stopRequestedFile())
 		if err := killProcess(pid); err == nil {
 			killed = true
 		}
+		if !waitForExit(pid, stopWait) {
+			return fmt.Errorf("xmrig (PID %d) did not exit after SIGKILL", pid)
+		}
 	}
 
 	// Clean up any orphaned xmrig processes
@@ -167,7 +388,7 @@ func Stop() error {
 	}
 
 	// Remove PID file
-	os.Remove(GetPIDFile())
+	removePIDFile()
 
 	// Disable sleep prevention
 	if err := antisleep.Disable(); err != nil {
@@ -186,18 +407,59 @@ func Stop() error {
 	return nil
 }
 
-// IsRunning checks if xmrig is currently running
-func IsRunning() (int, bool) {
+// pidState is what the PID file says about xmrig
+type pidState int
+
+const (
+	pidNone     pidState = iota // no PID file
+	pidRunning                  // names a running xmrig
+	pidPreBoot                  // left over from before the last boot
+	pidVanished                 // names an xmrig that has since died
+)
+
+// checkPID reads the PID file and classifies it, changing nothing
+func checkPID() (int, pidState) {
 	pid, err := readPID()
 	if err != nil {
-		return 0, false
+		return 0, pidNone
+	}
+	if started, err := readStartedAt(); err == nil {
+		if boot, err := bootTime(); err == nil && started.Before(boot) {
+			return pid, pidPreBoot
+		}
 	}
-
 	if isProcessRunning(pid) {
-		return pid, true
+		return pid, pidRunning
 	}
+	return pid, pidVanished
+}
 
-	return 0, false
+// IsRunning checks if xmrig is currently running. A PID file left over
+// from before the last boot isn't trusted. It only reads: ReapExited
+// cleans up after an xmrig that is gone.
+func IsRunning() (int, bool) {
+	pid, state := checkPID()
+	if state != pidRunning {
+		return 0, false
+	}
+	return pid, true
+}
+
+// ReapExited removes a PID file that outlived its xmrig, recording the
+// exit (LastExit) if xmrig died on its own: 'tarish stop' removes the PID
+// file. Start, Stop and the watchdog call it.
+func ReapExited() {
+	switch pid, state := checkPID(); state {
+	case pidPreBoot:
+		debugf("PID file predates boot, removing it")
+		removePIDFile()
+	case pidVanished:
+		if !stopRequested(pid) {
+			debugf("xmrig (PID %d) is gone but its PID file remains, recording the exit", pid)
+			recordVanishedExit()
+		}
+		removePIDFile()
+	}
 }
 
 // Status returns the current status of xmrig
@@ -209,14 +471,27 @@ func Status() (*ProcessStatus, error) {
 	status.PID = pid
 	status.SleepPrevention = antisleep.IsEnabled()
 
+	if _, err := RotateLogIfLarge(); err != nil {
+		fmt.Printf("Warning: failed to rotate xmrig log: %v\n", err)
+	}
+
 	if !running {
+		status.LastExit, _ = LastExit()
+		// not reaped yet: report the exit without recording it
+		if pid, state := checkPID(); state == pidVanished && !stopRequested(pid) {
+			if rec := vanishedExit(); rec != nil {
+				status.LastExit = rec
+			}
+		}
 		return status, nil
 	}
+	status.PausedBy = PauseReasons()
 
 	// Try to get info from HTTP API first (if enabled in config)
 	apiStatus, err := getAPIStatus()
 	if err == nil {
 		status.Version = apiStatus.Version
+		status.Algo = apiStatus.Algo
 		status.Uptime = time.Duration(apiStatus.Uptime) * time.Second
 		if len(apiStatus.Hashrate.Total) >= 3 {
 			status.Hashrate = &HashrateInfo{
@@ -248,19 +523,99 @@ func Status() (*ProcessStatus, error) {
 		status.DonateLevel = logStatus.DonateLevel
 	}
 
+	// An old log may have rotated away the version banner; ask the binary
+	if status.Version == "" {
+		if exe, err := processExecutable(pid); err == nil {
+			if version, err := GetBinaryVersion(exe); err == nil {
+				status.Version = version
+			}
+		}
+	}
+
+	// Uptime isn't in the log; go by when tarish started xmrig
+	if started, err := readStartedAt(); err == nil {
+		status.Uptime = time.Since(started)
+	}
+
 	return status, nil
 }
 
-// savePID saves the process ID to the PID file
+// savePID saves the process ID to the PID file, and the start time next
+// to it so uptime is known without the API
 func savePID(pid int) error {
 	pidFile := GetPIDFile()
 	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(pid)), 0666); err != nil {
 		return err
 	}
 	// Ensure world-writable
-	return os.Chmod(pidFile, 0666)
+	if err := os.Chmod(pidFile, 0666); err != nil {
+		return err
+	}
+
+	startedFile := startedAtFile()
+	if err := os.WriteFile(startedFile, []byte(time.Now().UTC().Format(time.RFC3339)), 0666); err != nil {
+		return err
+	}
+	return os.Chmod(startedFile, 0666)
 }
 
+// removePIDFile removes the PID file and the start time that goes with it
+func removePIDFile() {
+	os.Remove(GetPIDFile())
+	os.Remove(startedAtFile())
+}
+
+// stopRequestedFile names the xmrig 'tarish stop' is killing
+func stopRequestedFile() string {
+	return filepath.Join(GetLogDir(), "xmrig.stopping")
+}
+
+func markStopRequested(pid int) {
+	os.WriteFile(stopRequestedFile(), []byte(strconv.Itoa(pid)), 0644)
+}
+
+// stopRequested reports whether 'tarish stop' is killing pid
+func stopRequested(pid int) bool {
+	data, err := os.ReadFile(stopRequestedFile())
+	if err != nil {
+		return false
+	}
+	marked, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return err == nil && marked == pid
+}
+
+// stopWait bounds how long Stop waits for a killed xmrig to go
+const stopWait = 2 * time.Second
+
+// waitForExit polls until pid is no longer a running xmrig
+func waitForExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for isProcessRunning(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
+
+// startedAtFile holds when the xmrig in the PID file was started
+func startedAtFile() string {
+	return filepath.Join(GetLogDir(), "xmrig.started_at")
+}
+
+// readStartedAt returns when the running xmrig was started
+func readStartedAt() (time.Time, error) {
+	data, err := os.ReadFile(startedAtFile())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+}
+
+// bootTime is swapped out in tests
+var bootTime = proc.BootTime
+
 // readPID reads the process ID from the PID file
 func readPID() (int, error) {
 	data, err := os.ReadFile(GetPIDFile())
@@ -277,6 +632,8 @@ func readPID() (int, error) {
 }
 
 // isProcessRunning checks if a process with the given PID is running
+// and is actually xmrig. After a reboot the PID in a stale PID file may
+// belong to an unrelated program, so the process name is verified too.
 func isProcessRunning(pid int) bool {
 	process, err := os.FindProcess(pid)
 	if err != nil {
@@ -284,8 +641,16 @@ func isProcessRunning(pid int) bool {
 	}
 
 	// On Unix, FindProcess always succeeds, so we need to send signal 0
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
+	if err := process.Signal(syscall.Signal(0)); err != nil {
+		return false
+	}
+
+	name, err := proc.Name(pid)
+	if err != nil {
+		// Can't tell what it is; trust the signal check
+		return true
+	}
+	return strings.Contains(strings.ToLower(name), "xmrig")
 }
 
 // killProcess kills a process by PID
@@ -332,6 +697,12 @@ func findXmrigProcesses() []int {
 	return pids
 }
 
+// APISummary returns xmrig's HTTP API summary; it fails if the API is off
+// or xmrig isn't answering
+func APISummary() (*APIResponse, error) {
+	return getAPIStatus()
+}
+
 // getAPIStatus tries to get status from xmrig's HTTP API.
 // It reads the port and access-token from the active runtime config.
 func getAPIStatus() (*APIResponse, error) {
@@ -342,6 +713,7 @@ func getAPIStatus() (*APIResponse, error) {
 	}
 
 	url := fmt.Sprintf("http://127.0.0.1:%d/1/summary", port)
+	debugf("querying xmrig API at %s (token set: %v)", url, accessToken != "")
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -354,11 +726,13 @@ func getAPIStatus() (*APIResponse, error) {
 
 	resp, err := client.Do(req)
 	if err != nil {
+		debugf("xmrig API request failed: %v", err)
 		return nil, fmt.Errorf("API not available: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
+		debugf("xmrig API returned HTTP %d", resp.StatusCode)
 		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
@@ -375,6 +749,193 @@ func getAPIStatus() (*APIResponse, error) {
 	return &apiResp, nil
 }
 
+// Pause stops hashing without exiting xmrig, so uptime and the pool
+// connection are kept. Requires the API to be unrestricted (the default
+// for tarish configs).
+func Pause() error {
+	return callJSONRPC("pause")
+}
+
+// Resume restarts hashing after Pause
+func Resume() error {
+	return callJSONRPC("resume")
+}
+
+// callJSONRPC invokes a parameterless method on xmrig's /json_rpc endpoint
+func callJSONRPC(method string) error {
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method})
+	if err != nil {
+		return err
+	}
+	resp, err := apiRequest("POST", "/json_rpc", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 200:
+	case 401, 403:
+		return fmt.Errorf("API refused %s (HTTP %d): check the access-token and that the API is not restricted", method, resp.StatusCode)
+	default:
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var rpcResp struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("xmrig %s failed: %s (code %d)", method, rpcResp.Error.Message, rpcResp.Error.Code)
+	}
+	return nil
+}
+
+// GetLiveConfig fetches the running xmrig's full config from GET /1/config
+func GetLiveConfig() (map[string]interface{}, error) {
+	resp, err := apiRequest("GET", "/1/config", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	var cfg map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// PutLiveConfig replaces the running xmrig's config via PUT /1/config;
+// xmrig applies it without restarting.
+func PutLiveConfig(cfg map[string]interface{}) error {
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	resp, err := apiRequest("PUT", "/1/config", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("xmrig rejected config (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// SetLiveMaxThreadsHint updates cpu.max-threads-hint on the running xmrig,
+// cutting an explicit cpu.rx list the way SetMaxThreadsHint did for
+// configPath, the config it was started from
+func SetLiveMaxThreadsHint(configPath string, pct int) (*ThreadsHintResult, error) {
+	cfg, err := GetLiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	cpuSection, ok := cfg["cpu"].(map[string]interface{})
+	if !ok {
+		cpuSection = make(map[string]interface{})
+	}
+	result := applyThreadsHint(cpuSection, savedRxThreads(configPath), pct)
+	cfg["cpu"] = cpuSection
+	if err := PutLiveConfig(cfg); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// apiRequest sends an authenticated request to the local xmrig HTTP API,
+// using the port and access-token from the runtime config
+func apiRequest(method, path string, body []byte) (*http.Response, error) {
+	port, accessToken := GetHTTPConfigFromRuntime()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", port, path)
+	debugf("xmrig API %s %s", method, url)
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		debugf("xmrig API request failed: %v", err)
+		return nil, fmt.Errorf("API not available: %w", err)
+	}
+	return resp, nil
+}
+
+// speedRe matches xmrig's periodic hashrate line, e.g.
+//
+//	speed 10s/60s/15m 1234.5 1230.1 n/a H/s max 1301.7 H/s
+//	speed 10s/60s/15m 12.34 12.30 n/a kH/s max 12.51 kH/s
+//
+// Values may be "n/a" right after start; older builds omit the unit.
+var speedRe = regexp.MustCompile(`speed\s+\S+\s+([\d.]+|n/a)\s+([\d.]+|n/a)\s+([\d.]+|n/a)(?:\s+([kMG]?H/s))?(?:\s+max\s+([\d.]+|n/a)(?:\s+([kMG]?H/s))?)?`)
+
+// parseSpeedLine extracts a hashrate from an xmrig speed line, normalized
+// to H/s. Returns nil if the line isn't a speed line.
+func parseSpeedLine(line string) *HashrateInfo {
+	m := speedRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	unit := m[4]
+	hr := &HashrateInfo{
+		Current: parseRate(m[1], unit),
+		Average: parseRate(m[2], unit),
+		Max:     parseRate(m[3], unit),
+	}
+	if m[5] != "" {
+		maxUnit := m[6]
+		if maxUnit == "" {
+			maxUnit = unit
+		}
+		hr.Max = parseRate(m[5], maxUnit)
+	}
+	return hr
+}
+
+// parseRate converts a value with an optional H/s-family unit to H/s.
+// "n/a" parses as 0.
+func parseRate(value, unit string) float64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case "kH/s":
+		return v * 1e3
+	case "MH/s":
+		return v * 1e6
+	case "GH/s":
+		return v * 1e9
+	}
+	return v
+}
+
 // parseLogFile extracts status information from the xmrig log file
 func parseLogFile() (*ProcessStatus, error) {
 	logFile := GetLogFile()
@@ -394,7 +955,6 @@ func parseLogFile() (*ProcessStatus, error) {
 
 	// Regex patterns
 	versionRe := regexp.MustCompile(`XMRig\s+(\d+\.\d+\.\d+)`)
-	hashrateRe := regexp.MustCompile(`speed\s+[\d.]+s/[\d.]+s/[\d.]+s\s+([\d.]+)\s+([\d.]+)\s+([\d.]+)`)
 	poolRe := regexp.MustCompile(`\[([^\]]+)\]\s+use\s+pool\s+(\S+)`)
 	donateRe := regexp.MustCompile(`donate\s+level:\s+(\d+)%`)
 	userRe := regexp.MustCompile(`\[([^\]]+)\]\s+login\s+(\S+)`)
@@ -404,15 +964,8 @@ func parseLogFile() (*ProcessStatus, error) {
 			status.Version = matches[1]
 		}
 
-		if matches := hashrateRe.FindStringSubmatch(line); len(matches) > 3 {
-			current, _ := strconv.ParseFloat(matches[1], 64)
-			avg, _ := strconv.ParseFloat(matches[2], 64)
-			max, _ := strconv.ParseFloat(matches[3], 64)
-			status.Hashrate = &HashrateInfo{
-				Current: current,
-				Average: avg,
-				Max:     max,
-			}
+		if hr := parseSpeedLine(line); hr != nil {
+			status.Hashrate = hr
 		}
 
 		if matches := poolRe.FindStringSubmatch(line); len(matches) > 2 {
@@ -438,19 +991,154 @@ func parseLogFile() (*ProcessStatus, error) {
 	return status, nil
 }
 
-// tailFile reads the last n lines from a file
+// TailFile returns the last n lines of the file at path
+func TailFile(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return tailFile(file, n)
+}
+
+// logRotations is how many previous xmrig logs (xmrig.log.1 ...) are kept
+const logRotations = 3
+
+// rotateLog shifts path to path.1, path.1 to path.2 and so on, dropping
+// anything beyond path.<keep>. A missing log is not an error.
+func rotateLog(path string, keep int) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if keep <= 0 {
+		return os.Remove(path)
+	}
+	if err := shiftLogs(path, keep); err != nil {
+		return err
+	}
+	return os.Rename(path, path+".1")
+}
+
+// shiftLogs frees path.1 by moving each path.<i> to path.<i+1>, dropping
+// path.<keep>.
+func shiftLogs(path string, keep int) error {
+	oldest := fmt.Sprintf("%s.%d", path, keep)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := keep - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", path, i)
+		to := fmt.Sprintf("%s.%d", path, i+1)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RotateLogIfLarge rotates the xmrig log once it exceeds the configured
+// size (config log_max_size_mb, default 50). Called from Status and the
+// agent heartbeat since tarish has no long-lived process of its own.
+func RotateLogIfLarge() (bool, error) {
+	return rotateLogIfLarge(GetLogFile(), config.GetLogMaxSize(), logRotations)
+}
+
+// rotateLogIfLarge rotates path when it is larger than maxSize. xmrig
+// keeps the log open while running, so instead of renaming (which would
+// leave xmrig writing to path.1) the contents are copied to path.1 and
+// path is truncated in place; xmrig writes with O_APPEND so it carries on
+// at the new end of file.
+func rotateLogIfLarge(path string, maxSize int64, keep int) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if maxSize <= 0 || info.Size() <= maxSize {
+		return false, nil
+	}
+
+	if keep > 0 {
+		if err := shiftLogs(path, keep); err != nil {
+			return false, err
+		}
+		if err := copyLog(path, path+".1"); err != nil {
+			return false, err
+		}
+	}
+	return true, os.Truncate(path, 0)
+}
+
+func copyLog(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// tailChunkSize is how much tailFile reads per step when walking backwards
+const tailChunkSize = 64 * 1024
+
+// tailFile reads the last n lines from a file. It reads backwards from the
+// end in chunks, so the cost depends on n rather than on the log size.
 func tailFile(file *os.File, n int) ([]string, error) {
-	var lines []string
-	scanner := bufio.NewScanner(file)
+	if n <= 0 {
+		return nil, nil
+	}
 
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-		if len(lines) > n {
-			lines = lines[1:]
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk back until the buffer holds more than n newlines (the extra one
+	// marks the start of the first wanted line) or we hit the start of file.
+	var buf []byte
+	newlines := 0
+	offset := info.Size()
+	for offset > 0 && newlines <= n {
+		size := int64(tailChunkSize)
+		if size > offset {
+			size = offset
 		}
+		offset -= size
+
+		chunk := make([]byte, size)
+		if _, err := file.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		newlines += bytes.Count(chunk, []byte{'\n'})
+		buf = append(chunk, buf...)
+	}
+
+	if len(buf) == 0 {
+		return nil, nil
 	}
 
-	return lines, scanner.Err()
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	if offset > 0 {
+		lines = lines[1:] // partial line cut by the chunk boundary
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, nil
 }
 
 // ANSI color codes (consistent with printHelp in main.go)
@@ -471,20 +1159,34 @@ func (s *ProcessStatus) FormatStatus() string {
 	if !s.Running {
 		sb.WriteString(fmt.Sprintf("  %sStatus:           %s%sNOT RUNNING%s\n",
 			colorYellow, colorReset, colorRed, colorReset))
+		if s.LastExit != nil {
+			sb.WriteString(fmt.Sprintf("  %sLast exit:        %s%s%s%s\n",
+				colorYellow, colorReset, colorRed, s.LastExit.Describe(), colorReset))
+		}
 		return sb.String()
 	}
 
 	sb.WriteString(fmt.Sprintf("  %sStatus:           %s%s%sRUNNING%s %s(PID: %d)%s\n",
 		colorYellow, colorReset, colorBold, colorGreen, colorReset, colorGray, s.PID, colorReset))
 
+	if len(s.PausedBy) > 0 {
+		sb.WriteString(fmt.Sprintf("  %sPaused by:        %s%s%s%s\n",
+			colorYellow, colorReset, colorYellow, JoinPauseReasons(s.PausedBy), colorReset))
+	}
+
 	if s.Version != "" {
 		sb.WriteString(fmt.Sprintf("  %sVersion:          %s%s%s%s\n",
 			colorYellow, colorReset, colorCyan, s.Version, colorReset))
 	}
 
+	if s.Algo != "" {
+		sb.WriteString(fmt.Sprintf("  %sAlgorithm:        %s%s%s%s\n",
+			colorYellow, colorReset, colorCyan, s.Algo, colorReset))
+	}
+
 	if s.Uptime > 0 {
 		sb.WriteString(fmt.Sprintf("  %sUptime:           %s%s%s%s\n",
-			colorYellow, colorReset, colorGreen, formatDuration(s.Uptime), colorReset))
+			colorYellow, colorReset, colorGreen, FormatDuration(s.Uptime), colorReset))
 	}
 
 	if s.Hashrate != nil {
@@ -524,8 +1226,8 @@ func (s *ProcessStatus) FormatStatus() string {
 	return sb.String()
 }
 
-// formatDuration formats a duration in a human-readable way
-func formatDuration(d time.Duration) string {
+// FormatDuration formats a duration in a human-readable way
+func FormatDuration(d time.Duration) string {
 	days := int(d.Hours()) / 24
 	hours := int(d.Hours()) % 24
 	minutes := int(d.Minutes()) % 60
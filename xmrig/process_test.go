@@ -0,0 +1,486 @@
+package xmrig
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsProcessRunningRejectsNonXmrig(t *testing.T) {
+	// A live process that isn't xmrig is exactly what a stale PID file
+	// pointing at a reused PID looks like.
+	cmd := exec.Command("sleep", "5")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	if isProcessRunning(cmd.Process.Pid) {
+		t.Fatal("isProcessRunning should reject a live process that isn't xmrig")
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []int
+		wantErr bool
+	}{
+		{"0", []int{0}, false},
+		{"0-3", []int{0, 1, 2, 3}, false},
+		{"0-1,4, 6-7", []int{0, 1, 4, 6, 7}, false},
+		{"", nil, true},
+		{"3-1", nil, true},
+		{"a,b", nil, true},
+		{"-2", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCPUList(tt.list)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCPUList(%q) error = %v, wantErr %v", tt.list, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCPUList(%q) = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestParseSpeedLine(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+		want *HashrateInfo
+	}{
+		{
+			"6.x H/s",
+			"[2024-05-01 10:00:00.123]  miner    speed 10s/60s/15m 1234.5 1230.1 n/a H/s max 1301.7 H/s",
+			&HashrateInfo{Current: 1234.5, Average: 1230.1, Max: 1301.7},
+		},
+		{
+			"6.x kH/s",
+			"[2024-05-01 10:00:00.123]  miner    speed 10s/60s/15m 12.34 12.30 12.28 kH/s max 12.51 kH/s",
+			&HashrateInfo{Current: 12340, Average: 12300, Max: 12510},
+		},
+		{
+			"MH/s",
+			"[2024-05-01 10:00:00.123]  miner    speed 10s/60s/15m 1.5 1.4 n/a MH/s max 1.6 MH/s",
+			&HashrateInfo{Current: 1.5e6, Average: 1.4e6, Max: 1.6e6},
+		},
+		{
+			"startup n/a",
+			"[2024-05-01 10:00:00.123]  miner    speed 10s/60s/15m n/a n/a n/a H/s max n/a H/s",
+			&HashrateInfo{},
+		},
+		{
+			"2.x no unit",
+			"[2018-06-01 10:00:00] speed 10s/60s/15m 850.2 848.9 847.0 H/s max 861.3 H/s",
+			&HashrateInfo{Current: 850.2, Average: 848.9, Max: 861.3},
+		},
+		{
+			"legacy without max",
+			"speed 2.5s/60s/15m 400.1 399.8 398.0",
+			&HashrateInfo{Current: 400.1, Average: 399.8, Max: 398.0},
+		},
+		{"not a speed line", "[2024-05-01 10:00:00.123]  net      use pool 1.2.3.4:3333", nil},
+	}
+
+	const eps = 1e-6
+	near := func(a, b float64) bool { return a-b < eps && b-a < eps }
+
+	for _, c := range cases {
+		got := parseSpeedLine(c.line)
+		if c.want == nil {
+			if got != nil {
+				t.Errorf("%s: got %+v, want nil", c.name, got)
+			}
+			continue
+		}
+		if got == nil {
+			t.Errorf("%s: got nil, want %+v", c.name, c.want)
+			continue
+		}
+		if !near(got.Current, c.want.Current) || !near(got.Average, c.want.Average) || !near(got.Max, c.want.Max) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestTailFileLargeLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xmrig.log")
+
+	// ~5 MB of synthetic xmrig output
+	var sb strings.Builder
+	total := 60000
+	for i := 0; i < total; i++ {
+		fmt.Fprintf(&sb, "[2024-05-01 10:00:00.%03d]  miner    speed 10s/60s/15m %d.0 1230.1 n/a H/s max 1301.7 H/s\n", i%1000, i)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := TailFile(path, 100)
+	if err != nil {
+		t.Fatalf("TailFile: %v", err)
+	}
+	if len(lines) != 100 {
+		t.Fatalf("got %d lines, want 100", len(lines))
+	}
+	for i, line := range lines {
+		want := fmt.Sprintf(" %d.0 ", total-100+i)
+		if !strings.Contains(line, want) {
+			t.Fatalf("line %d = %q, want it to contain %q", i, line, want)
+		}
+	}
+}
+
+func TestTailFileEdgeCases(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name    string
+		content string
+		n       int
+		want    []string
+	}{
+		{"empty", "", 5, nil},
+		{"fewer lines than n", "a\nb\n", 5, []string{"a", "b"}},
+		{"no trailing newline", "a\nb\nc", 2, []string{"b", "c"}},
+		{"crlf", "a\r\nb\r\n", 1, []string{"b"}},
+		{"line longer than a chunk", "x\n" + strings.Repeat("y", tailChunkSize+10) + "\nz\n", 2,
+			[]string{strings.Repeat("y", tailChunkSize+10), "z"}},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, strings.ReplaceAll(c.name, " ", "_"))
+		if err := os.WriteFile(path, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := TailFile(path, c.n)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestRotateLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xmrig.log")
+
+	// Missing log is a no-op
+	if err := rotateLog(path, 3); err != nil {
+		t.Fatalf("rotateLog on missing file: %v", err)
+	}
+
+	// Simulate five runs; each writes its run number then rotates
+	for run := 1; run <= 5; run++ {
+		if err := rotateLog(path, 3); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if err := os.WriteFile(path, []byte(fmt.Sprint(run)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "5",
+		path + ".1": "4",
+		path + ".2": "3",
+		path + ".3": "2",
+	}
+	for p, content := range want {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(p), err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(p), data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".4"); !os.IsNotExist(err) {
+		t.Errorf("xmrig.log.4 should not exist (keep=3)")
+	}
+}
+
+func TestRotateLogIfLarge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xmrig.log")
+
+	// Writer stays open across the rotation, like a running xmrig
+	w, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.WriteString(strings.Repeat("x", 100))
+
+	if rotated, err := rotateLogIfLarge(path, 200, 3); err != nil || rotated {
+		t.Fatalf("under limit: rotated=%v err=%v", rotated, err)
+	}
+
+	w.WriteString(strings.Repeat("y", 150))
+	rotated, err := rotateLogIfLarge(path, 200, 3)
+	if err != nil || !rotated {
+		t.Fatalf("over limit: rotated=%v err=%v", rotated, err)
+	}
+
+	old, _ := os.ReadFile(path + ".1")
+	if len(old) != 250 {
+		t.Errorf("xmrig.log.1 has %d bytes, want 250", len(old))
+	}
+
+	// New writes land at the start of the truncated file, not after a hole
+	w.WriteString("after")
+	cur, _ := os.ReadFile(path)
+	if string(cur) != "after" {
+		t.Errorf("xmrig.log = %q, want %q", cur, "after")
+	}
+}
+
+func TestPauseResumeCallJSONRPC(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	origEuid := geteuid
+	defer func() { geteuid = origEuid }()
+	geteuid = func() int { return 1000 }
+
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json_rpc" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		methods = append(methods, req.Method)
+		if req.Method == "resume" {
+			fmt.Fprint(w, `{"id":1,"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":1,"jsonrpc":"2.0","result":{"status":"OK"}}`)
+	}))
+	defer srv.Close()
+
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+	runtime := fmt.Sprintf(`{"http":{"enabled":true,"port":%d,"access-token":"secret"}}`, port)
+	if err := os.MkdirAll(GetLogDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(GetRuntimeConfigPath(), []byte(runtime), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pause(); err != nil {
+		t.Errorf("Pause: %v", err)
+	}
+	if err := Resume(); err == nil || !strings.Contains(err.Error(), "Method not found") {
+		t.Errorf("Resume error = %v, want the RPC error", err)
+	}
+	if !reflect.DeepEqual(methods, []string{"pause", "resume"}) {
+		t.Errorf("methods = %v", methods)
+	}
+}
+
+func TestHugepagesEnabled(t *testing.T) {
+	tests := []struct {
+		summary string
+		want    bool
+	}{
+		{`{"hugepages": [1168, 1168]}`, true},
+		{`{"hugepages": [0, 1168]}`, false},
+		{`{"hugepages": [0, 0]}`, false},
+		{`{"hugepages": true}`, true},
+		{`{"hugepages": false}`, false},
+		{`{}`, false},
+	}
+	for _, tt := range tests {
+		var resp APIResponse
+		if err := json.Unmarshal([]byte(tt.summary), &resp); err != nil {
+			t.Fatalf("unmarshal %s: %v", tt.summary, err)
+		}
+		if got := resp.HugepagesEnabled(); got != tt.want {
+			t.Errorf("HugepagesEnabled(%s) = %v, want %v", tt.summary, got, tt.want)
+		}
+	}
+}
+
+func TestStartReportsImmediateExit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	origEuid := geteuid
+	geteuid = func() int { return 1000 }
+	defer func() { geteuid = origEuid }()
+
+	// stands in for an xmrig that rejects its config
+	bin := filepath.Join(home, "xmrig")
+	script := "#!/bin/sh\necho '[config.json]<offset:12> \"Invalid value.\"'\nexit 3\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := StartWithOptions(bin, filepath.Join(home, "config.json"), StartOptions{})
+	if err == nil {
+		t.Fatal("StartWithOptions reported success for an xmrig that exited")
+	}
+	for _, want := range []string{"exited immediately", "exit status 3", "value xmrig rejects", "Invalid value."} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+	if _, err := os.Stat(GetPIDFile()); !os.IsNotExist(err) {
+		t.Errorf("PID file left behind: %v", err)
+	}
+
+	rec, err := LastExit()
+	if err != nil || rec == nil {
+		t.Fatalf("LastExit() = %v, %v; want the recorded exit", rec, err)
+	}
+	if rec.Code != 3 || len(rec.LogLines) == 0 || !strings.Contains(rec.LogLines[len(rec.LogLines)-1], "Invalid value.") {
+		t.Errorf("LastExit() = %+v, want code 3 and the log tail", rec)
+	}
+	status := (&ProcessStatus{LastExit: rec}).FormatStatus()
+	if !strings.Contains(status, "last exited abnormally (code 3)") {
+		t.Errorf("FormatStatus() missing the last exit:\n%s", status)
+	}
+}
+
+func TestLikelyExitCause(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"[2024-01-01] net  bind 127.0.0.1:18088 failed: address already in use"}, "HTTP API port"},
+		{[]string{"sh: ./xmrig: Permission denied"}, "permission denied"},
+		{[]string{"[2024-01-01] cpu  use profile rx"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		got := likelyExitCause(tt.lines)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("likelyExitCause(%q) = %q, want it to mention %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestStalePIDFileFromBeforeBootIsCleared(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	origEuid, origBoot := geteuid, bootTime
+	geteuid = func() int { return 1000 }
+	defer func() { geteuid, bootTime = origEuid, origBoot }()
+	if err := os.MkdirAll(GetLogDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// the test binary, xmrig.test, passes for a running xmrig
+	if err := savePID(os.Getpid()); err != nil {
+		t.Fatalf("savePID: %v", err)
+	}
+	bootTime = func() (time.Time, error) { return time.Now().Add(-time.Hour), nil }
+	if _, running := IsRunning(); !running {
+		t.Fatal("IsRunning() = false for a PID file written this boot")
+	}
+	if started, err := readStartedAt(); err != nil || time.Since(started) > time.Minute {
+		t.Errorf("readStartedAt() = %v, %v; want the time savePID ran", started, err)
+	}
+
+	bootTime = func() (time.Time, error) { return time.Now().Add(time.Hour), nil }
+	if _, running := IsRunning(); running {
+		t.Error("IsRunning() = true for a PID file from before boot")
+	}
+	if _, err := os.Stat(GetPIDFile()); err != nil {
+		t.Fatalf("IsRunning() removed the PID file: %v", err)
+	}
+	ReapExited()
+	for _, path := range []string{GetPIDFile(), startedAtFile()} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s not removed: %v", path, err)
+		}
+	}
+}
+
+func TestVanishedXmrigIsRecordedAsExit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	origEuid := geteuid
+	geteuid = func() int { return 1000 }
+	defer func() { geteuid = origEuid }()
+	if err := os.MkdirAll(GetLogDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// a PID that has exited stands in for an xmrig that crashed after
+	// 'tarish start' returned
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run helper process: %v", err)
+	}
+	if err := savePID(cmd.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(GetLogFile(), []byte("[2024-01-01] miner speed 10s/60s/15m 1000.0\nSegmentation fault\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, running := IsRunning(); running {
+		t.Fatal("IsRunning() = true for a dead PID")
+	}
+	if rec, _ := LastExit(); rec != nil {
+		t.Fatalf("IsRunning() recorded an exit: %+v", rec)
+	}
+	if status, _ := Status(); status.LastExit == nil || status.LastExit.Code != -1 {
+		t.Errorf("Status().LastExit = %+v, want the unreaped exit", status.LastExit)
+	}
+	ReapExited()
+	if _, err := os.Stat(GetPIDFile()); !os.IsNotExist(err) {
+		t.Errorf("PID file left behind: %v", err)
+	}
+	rec, err := LastExit()
+	if err != nil || rec == nil {
+		t.Fatalf("LastExit() = %v, %v; want the vanished run recorded", rec, err)
+	}
+	if rec.Code != -1 || len(rec.LogLines) != 2 || rec.LogLines[1] != "Segmentation fault" {
+		t.Errorf("LastExit() = %+v, want an unknown status and the log tail", rec)
+	}
+	if desc := rec.Describe(); !strings.Contains(desc, "exit status unknown") {
+		t.Errorf("Describe() = %q", desc)
+	}
+
+	// after 'tarish stop' there is no PID file and nothing to record
+	clearLastExit()
+	ReapExited()
+	if _, running := IsRunning(); running {
+		t.Fatal("IsRunning() = true without a PID file")
+	}
+	if rec, _ := LastExit(); rec != nil {
+		t.Errorf("LastExit() = %+v without a PID file, want none", rec)
+	}
+
+	// nor when the stop was interrupted between the kill and the cleanup
+	if err := savePID(cmd.Process.Pid); err != nil {
+		t.Fatal(err)
+	}
+	markStopRequested(cmd.Process.Pid)
+	ReapExited()
+	if rec, _ := LastExit(); rec != nil {
+		t.Errorf("LastExit() = %+v for a stopped xmrig, want none", rec)
+	}
+	if _, err := os.Stat(GetPIDFile()); !os.IsNotExist(err) {
+		t.Errorf("PID file left behind after a stop: %v", err)
+	}
+}
+
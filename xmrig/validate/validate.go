@@ -0,0 +1,191 @@
+// Package validate checks an xmrig config for mistakes xmrig would only
+// report when it loads it (or silently mine around). It has no
+// dependencies so tarish-server can share it with the client.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Config checks the known fields of a decoded xmrig config. Unknown keys
+// are left to xmrig.
+func Config(raw map[string]interface{}) error {
+	if err := pools(raw["pools"]); err != nil {
+		return err
+	}
+
+	if v, ok := raw["donate-level"]; ok {
+		n, isInt := integer(v)
+		if !isInt {
+			return fmt.Errorf("donate-level must be a whole number, got %s", describe(v))
+		}
+		if n < 0 || n > 100 {
+			return fmt.Errorf("donate-level %d out of range (0-100)", n)
+		}
+	}
+
+	if err := httpSection(raw["http"]); err != nil {
+		return err
+	}
+	return cpuSection(raw["cpu"])
+}
+
+func pools(v interface{}) error {
+	list, ok := v.([]interface{})
+	if v != nil && !ok {
+		return fmt.Errorf("pools must be a list, got %s", describe(v))
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("no pools configured")
+	}
+	for i, item := range list {
+		pool, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("pool %d: must be an object, got %s", i+1, describe(item))
+		}
+		if s, _ := pool["url"].(string); strings.TrimSpace(s) == "" {
+			return fmt.Errorf("pool %d: url is empty", i+1)
+		}
+		if s, _ := pool["user"].(string); strings.TrimSpace(s) == "" {
+			return fmt.Errorf("pool %d: user (wallet) is empty", i+1)
+		}
+		for _, key := range []string{"pass", "rig-id", "algo", "coin", "tls-fingerprint"} {
+			if err := optionalString(pool, key); err != nil {
+				return fmt.Errorf("pool %d: %w", i+1, err)
+			}
+		}
+		for _, key := range []string{"enabled", "tls", "nicehash", "daemon"} {
+			if err := optionalBool(pool, key); err != nil {
+				return fmt.Errorf("pool %d: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func httpSection(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	section, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("http must be an object, got %s", describe(v))
+	}
+	for _, key := range []string{"enabled", "restricted"} {
+		if err := optionalBool(section, key); err != nil {
+			return fmt.Errorf("http.%w", err)
+		}
+	}
+	for _, key := range []string{"host", "access-token"} {
+		if err := optionalString(section, key); err != nil {
+			return fmt.Errorf("http.%w", err)
+		}
+	}
+
+	enabled, _ := section["enabled"].(bool)
+	if !enabled {
+		return nil
+	}
+	port, isInt := integer(section["port"])
+	if !isInt {
+		return fmt.Errorf("http.port must be a whole number, got %s", describe(section["port"]))
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("http.port %d out of range (1-65535)", port)
+	}
+	return nil
+}
+
+func cpuSection(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	section, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("cpu must be an object, got %s", describe(v))
+	}
+	for _, key := range []string{"enabled", "huge-pages", "huge-pages-jit", "hw-aes", "yield"} {
+		if err := optionalBool(section, key); err != nil {
+			return fmt.Errorf("cpu.%w", err)
+		}
+	}
+	// memory-pool is a switch or a number of pages to reserve
+	if v, ok := section["memory-pool"]; ok && v != nil {
+		if _, isBool := v.(bool); !isBool {
+			if n, isInt := integer(v); !isInt || n < 0 {
+				return fmt.Errorf("cpu.memory-pool must be true, false or a page count >= 0, got %s", describe(v))
+			}
+		}
+	}
+
+	ranges := []struct {
+		key      string
+		min, max int64
+	}{
+		{"priority", 0, 5},
+		{"max-threads-hint", 0, 100},
+	}
+	for _, r := range ranges {
+		v, ok := section[r.key]
+		if !ok || v == nil {
+			continue
+		}
+		n, isInt := integer(v)
+		if !isInt {
+			return fmt.Errorf("cpu.%s must be a whole number, got %s", r.key, describe(v))
+		}
+		if n < r.min || n > r.max {
+			return fmt.Errorf("cpu.%s %d out of range (%d-%d)", r.key, n, r.min, r.max)
+		}
+	}
+	return nil
+}
+
+// optionalBool accepts a missing key, null (xmrig's "auto") or a bool
+func optionalBool(section map[string]interface{}, key string) error {
+	v, ok := section[key]
+	if !ok || v == nil {
+		return nil
+	}
+	if _, isBool := v.(bool); !isBool {
+		return fmt.Errorf("%s must be true or false, got %s", key, describe(v))
+	}
+	return nil
+}
+
+// optionalString accepts a missing key, null or a string
+func optionalString(section map[string]interface{}, key string) error {
+	v, ok := section[key]
+	if !ok || v == nil {
+		return nil
+	}
+	if _, isString := v.(string); !isString {
+		return fmt.Errorf("%s must be a string, got %s", key, describe(v))
+	}
+	return nil
+}
+
+// integer reports whether a decoded JSON value is a whole number
+func integer(v interface{}) (int64, bool) {
+	f, ok := v.(float64)
+	if !ok || f != math.Trunc(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "a list"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,72 @@
+package validate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	const pools = `"pools": [{"url": "pool:3333", "user": "4abc", "pass": "x", "tls": false}]`
+	tests := []struct {
+		name string
+		json string
+		want string // error substring, "" for valid
+	}{
+		{"minimal", `{` + pools + `}`, ""},
+		{"nulls are auto", `{` + pools + `, "cpu": {"hw-aes": null, "priority": null}}`, ""},
+		{"no pools", `{}`, "no pools configured"},
+		{"pools not a list", `{"pools": {"url": "x"}}`, "pools must be a list"},
+		{"pool not an object", `{"pools": ["pool:3333"]}`, "pool 1: must be an object"},
+		{"empty url", `{"pools": [{"url": " ", "user": "4abc"}]}`, "pool 1: url is empty"},
+		{"missing user", `{"pools": [{"url": "pool:3333"}]}`, "pool 1: user (wallet) is empty"},
+		{"tls as string", `{"pools": [{"url": "p", "user": "u", "tls": "yes"}]}`, `pool 1: tls must be true or false, got "yes"`},
+		{"donate too high", `{` + pools + `, "donate-level": 150}`, "donate-level 150 out of range"},
+		{"donate fractional", `{` + pools + `, "donate-level": 1.5}`, "donate-level must be a whole number"},
+		{"http port", `{` + pools + `, "http": {"enabled": true, "port": 70000}}`, "http.port 70000 out of range"},
+		{"http disabled port ignored", `{` + pools + `, "http": {"enabled": false, "port": 0}}`, ""},
+		{"cpu not an object", `{` + pools + `, "cpu": true}`, "cpu must be an object"},
+		{"cpu bool type", `{` + pools + `, "cpu": {"huge-pages": 1}}`, "cpu.huge-pages must be true or false"},
+		{"cpu memory-pool pages", `{` + pools + `, "cpu": {"memory-pool": 64}}`, ""},
+		{"cpu memory-pool bool", `{` + pools + `, "cpu": {"memory-pool": false}}`, ""},
+		{"cpu memory-pool negative", `{` + pools + `, "cpu": {"memory-pool": -1}}`, "cpu.memory-pool must be true, false or a page count"},
+		{"cpu priority", `{` + pools + `, "cpu": {"priority": 9}}`, "cpu.priority 9 out of range (0-5)"},
+		{"cpu threads hint", `{` + pools + `, "cpu": {"max-threads-hint": "50%"}}`, "cpu.max-threads-hint must be a whole number"},
+	}
+	for _, tt := range tests {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(tt.json), &raw); err != nil {
+			t.Fatalf("%s: bad test JSON: %v", tt.name, err)
+		}
+		err := Config(raw)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestShippedConfigsAreValid(t *testing.T) {
+	paths, _ := filepath.Glob(filepath.Join("..", "..", "configs", "*.json"))
+	if len(paths) == 0 {
+		t.Skip("no shipped configs found")
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw map[string]interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if err := Config(raw); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package xmrig
+
+import (
+	"fmt"
+	"os"
+)
+
+// Verbose enables diagnostic output from the lookup helpers (config
+// selection, binary discovery, API probing). Set by main for --verbose.
+var Verbose bool
+
+// debugf prints a diagnostic line to stderr when Verbose is set, so it
+// never mixes into machine-readable stdout such as 'info --json'.
+func debugf(format string, args ...interface{}) {
+	if !Verbose {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\033[90m  [debug] "+format+"\033[0m\n", args...)
+}
+
+// progressf reports work a lookup does on demand, such as extracting
+// embedded assets. Like debugf it writes to stderr, so it stays out of
+// machine-readable stdout.
+func progressf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
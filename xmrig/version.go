@@ -0,0 +1,119 @@
+package xmrig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionQueryTimeout bounds `xmrig --version`, which only prints and exits
+const versionQueryTimeout = 5 * time.Second
+
+// versionLine matches the first line of `xmrig --version`, "XMRig 6.24.0"
+// (forks add a suffix: "XMRig-MO 6.24.0-mo1")
+var versionLine = regexp.MustCompile(`(?m)^XMRig\S*\s+v?(\d+\.\d+\.\d+\S*)`)
+
+// binaryVersionEntry is a cached `xmrig --version` result, or the error
+// it failed with. Size and mtime tell when the binary at the path has been
+// replaced.
+type binaryVersionEntry struct {
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+}
+
+// binaryVersions caches query results in binary-versions.json in the data
+// dir, so commands like status don't run the binary every time
+var binaryVersions struct {
+	sync.Mutex
+	entries map[string]binaryVersionEntry
+}
+
+func binaryVersionsFile() string {
+	return filepath.Join(GetDataDir(), "binary-versions.json")
+}
+
+// QueryBinaryVersion returns the version an xmrig binary reports with
+// --version, cached by path until the file changes. A failed run is cached
+// too, so a binary that can't run isn't retried on every call.
+func QueryBinaryVersion(binaryPath string) (string, error) {
+	fi, err := os.Stat(binaryPath)
+	if err != nil {
+		return "", err
+	}
+	key, _ := filepath.Abs(binaryPath)
+
+	binaryVersions.Lock()
+	defer binaryVersions.Unlock()
+	if binaryVersions.entries == nil {
+		binaryVersions.entries = make(map[string]binaryVersionEntry)
+		if data, err := os.ReadFile(binaryVersionsFile()); err == nil {
+			json.Unmarshal(data, &binaryVersions.entries)
+		}
+	}
+
+	if e, ok := binaryVersions.entries[key]; ok && e.Size == fi.Size() && e.ModTime == fi.ModTime().UnixNano() {
+		if e.Error != "" {
+			return "", errors.New(e.Error)
+		}
+		return e.Version, nil
+	}
+
+	version, err := runVersionQuery(binaryPath)
+	entry := binaryVersionEntry{Version: version, Size: fi.Size(), ModTime: fi.ModTime().UnixNano()}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	binaryVersions.entries[key] = entry
+	if err := EnsureDataDir(); err == nil {
+		if data, err := json.MarshalIndent(binaryVersions.entries, "", "  "); err == nil {
+			os.WriteFile(binaryVersionsFile(), data, 0644)
+		}
+	}
+	return version, err
+}
+
+// runVersionQuery runs `binaryPath --version` and parses the version
+func runVersionQuery(binaryPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionQueryTimeout)
+	defer cancel()
+
+	debugf("running %s --version", binaryPath)
+	out, err := exec.CommandContext(ctx, binaryPath, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s --version: %w", binaryPath, err)
+	}
+	m := versionLine.FindSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("%s --version printed no XMRig version", binaryPath)
+	}
+	return string(m[1]), nil
+}
+
+// processExecutable returns the path of a running process's binary
+// (/proc/<pid>/exe on Linux, ps on macOS)
+func processExecutable(pid int) (string, error) {
+	if runtime.GOOS == "linux" {
+		return os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	}
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "comm=").Output()
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("no path for pid %d", pid)
+	}
+	return path, nil
+}
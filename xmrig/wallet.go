@@ -0,0 +1,83 @@
+package xmrig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// base58Alphabet is the Monero (Bitcoin) base58 alphabet: no 0, O, I or l
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Monero mainnet address lengths
+const (
+	standardAddressLen   = 95  // "4..." primary, "8..." subaddress
+	integratedAddressLen = 106 // "4..." with an embedded payment ID
+)
+
+// ValidateWallet checks that a pool user looks like a Monero mainnet
+// address. A ".worker" or "+difficulty" suffix, as many pools accept, is
+// ignored. Pools that log in with usernames will fail this check, so
+// callers treat the error as a warning unless asked to be strict.
+func ValidateWallet(user string) error {
+	addr := user
+	if i := strings.IndexAny(addr, ".+"); i >= 0 {
+		addr = addr[:i]
+	}
+	if addr == "" {
+		return fmt.Errorf("wallet address is empty")
+	}
+
+	for _, c := range addr {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return fmt.Errorf("wallet %s contains %q, which is not valid base58", shortWallet(addr), c)
+		}
+	}
+
+	switch {
+	case len(addr) == standardAddressLen && (addr[0] == '4' || addr[0] == '8'):
+		return nil
+	case len(addr) == integratedAddressLen && addr[0] == '4':
+		return nil
+	case addr[0] != '4' && addr[0] != '8':
+		return fmt.Errorf("wallet %s does not start with 4 or 8 like a Monero address", shortWallet(addr))
+	default:
+		return fmt.Errorf("wallet %s is %d characters, want %d (or %d for an integrated address)",
+			shortWallet(addr), len(addr), standardAddressLen, integratedAddressLen)
+	}
+}
+
+// ValidateWallets runs ValidateWallet on every pool user in cfg, except
+// for pools pointing at the tarish xmrig-proxy, which mines to its own
+// wallet and takes any login
+func ValidateWallets(cfg *Config) []error {
+	var errs []error
+	for i, pool := range cfg.Pools {
+		if IsTemplate([]byte(pool.User)) {
+			continue // unrendered template, checked when it's rendered
+		}
+		if isProxyPool(pool.URL) {
+			continue
+		}
+		if err := ValidateWallet(pool.User); err != nil {
+			errs = append(errs, fmt.Errorf("pool %d: %w", i+1, err))
+		}
+	}
+	return errs
+}
+
+// isProxyPool reports whether url is the tarish xmrig-proxy, with or
+// without a stratum scheme
+func isProxyPool(url string) bool {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	}
+	return url == NonTLSPoolURL || url == TLSPoolURL
+}
+
+// shortWallet abbreviates an address for messages: "4AdUnd...Vd9yC"
+func shortWallet(addr string) string {
+	if len(addr) <= 16 {
+		return addr
+	}
+	return addr[:6] + "..." + addr[len(addr)-5:]
+}
@@ -0,0 +1,78 @@
+package xmrig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateWallet(t *testing.T) {
+	standard := "4" + strings.Repeat("A", standardAddressLen-1)
+	subaddress := "8" + strings.Repeat("b", standardAddressLen-1)
+	integrated := "4" + strings.Repeat("z", integratedAddressLen-1)
+
+	tests := []struct {
+		name    string
+		user    string
+		wantErr bool
+	}{
+		{"standard", standard, false},
+		{"subaddress", subaddress, false},
+		{"integrated", integrated, false},
+		{"worker suffix", standard + ".rig1", false},
+		{"difficulty suffix", standard + "+50000", false},
+		{"empty", "", true},
+		{"username", "myusername", true},
+		{"bad prefix", "1" + strings.Repeat("A", standardAddressLen-1), true},
+		{"too short", standard[:91], true},
+		{"integrated subaddress", "8" + strings.Repeat("z", integratedAddressLen-1), true},
+		{"not base58", "4" + strings.Repeat("0", standardAddressLen-1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWallet(tt.user)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWallet(%q) error = %v, wantErr %v", tt.user, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWalletsSkipsProxy(t *testing.T) {
+	standard := "4" + strings.Repeat("A", standardAddressLen-1)
+	cfg := &Config{Pools: []Pool{
+		{URL: NonTLSPoolURL, User: "proxy-login"},
+		{URL: "stratum+ssl://" + TLSPoolURL, User: "proxy-login"},
+		{URL: "pool.example:3333", User: standard},
+		{URL: "pool.example:3333", User: "myusername"},
+	}}
+	if errs := ValidateWallets(cfg); len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "pool 4:") {
+		t.Errorf("ValidateWallets() = %v, want only pool 4 flagged", errs)
+	}
+}
+
+// The shipped configs all mine through the proxy and must not warn on
+// every 'tarish start'
+func TestShippedConfigWallets(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "configs", "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no shipped configs found: %v", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if IsTemplate(data) {
+			continue
+		}
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for _, werr := range ValidateWallets(cfg) {
+			t.Errorf("%s: %v", filepath.Base(path), werr)
+		}
+	}
+}